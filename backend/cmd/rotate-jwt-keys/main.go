@@ -0,0 +1,65 @@
+// Command rotate-jwt-keys adds a new JWT signing key to JWT_KEYS_DIR and removes the oldest keys.
+//
+//	go run ./cmd/rotate-jwt-keys [-alg EdDSA|RS256] [-dir keys] [-keep 3]
+//
+// The new key has the greatest kid, so it signs new tokens after the next restart (unless JWT_SIGNING_KID
+// pins a key). Older keys keep verifying tokens that are still valid, so nobody is logged out; keep at
+// least two keys, and retire a key only when no access token signed by it can be unexpired.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"organizer-backend/config"
+	"organizer-backend/utils"
+	"os"
+	"path/filepath"
+	"sort"
+)
+
+func main() {
+	config.LoadEnv()
+	alg := flag.String("alg", "EdDSA", "algorithm of the new key: EdDSA or RS256")
+	dir := flag.String("dir", config.GetEnv("JWT_KEYS_DIR", "keys"), "directory with the keys (JWT_KEYS_DIR)")
+	keep := flag.Int("keep", 3, "number of newest keys to keep, including the new one")
+	flag.Parse()
+
+	if *keep < 2 {
+		log.Fatal("-keep must be at least 2, otherwise tokens signed by the previous key stop working immediately")
+	}
+	if err := os.MkdirAll(*dir, 0o700); err != nil {
+		log.Fatal(err)
+	}
+
+	kid, data, err := utils.GenerateSigningKeyPEM(*alg)
+	if err != nil {
+		log.Fatal(err)
+	}
+	path := filepath.Join(*dir, kid+".pem")
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o600)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if _, err := file.Write(data); err != nil {
+		log.Fatal(err)
+	}
+	if err := file.Close(); err != nil {
+		log.Fatal(err)
+	}
+	fmt.Printf("Created %s key %s\n", *alg, path)
+
+	files, err := filepath.Glob(filepath.Join(*dir, "*.pem"))
+	if err != nil {
+		log.Fatal(err)
+	}
+	sort.Strings(files)
+	for len(files) > *keep {
+		if err := os.Remove(files[0]); err != nil {
+			log.Fatal(err)
+		}
+		fmt.Printf("Removed old key %s\n", files[0])
+		files = files[1:]
+	}
+	fmt.Println("Restart the server to sign with the new key.")
+}
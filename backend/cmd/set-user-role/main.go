@@ -0,0 +1,39 @@
+// Command set-user-role changes the role of an account, e.g. to create the first administrator:
+//
+//	go run ./cmd/set-user-role -email admin@example.com [-role admin|user]
+//
+// Further roles can be assigned by administrators through the admin API. A running server picks the new role
+// up with the next access token of the user.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"organizer-backend/config"
+	"organizer-backend/models"
+	"strings"
+)
+
+func main() {
+	email := flag.String("email", "", "email of the account")
+	role := flag.String("role", models.RoleAdmin, "new role: admin or user")
+	flag.Parse()
+
+	if *email == "" {
+		log.Fatal("-email is required")
+	}
+	if *role != models.RoleAdmin && *role != models.RoleUser {
+		log.Fatalf("unknown role %q, use %s or %s", *role, models.RoleAdmin, models.RoleUser)
+	}
+
+	config.ConnectDatabase()
+	result := config.DB.Model(&models.User{}).Where("email = ?", strings.TrimSpace(*email)).Update("role", *role)
+	if result.Error != nil {
+		log.Fatal(result.Error)
+	}
+	if result.RowsAffected == 0 {
+		log.Fatalf("no user with email %q", *email)
+	}
+	fmt.Printf("%s now has the role %s\n", *email, *role)
+}
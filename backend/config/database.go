@@ -32,13 +32,48 @@ func ConnectDatabase() {
 
 	log.Println("Database connection established.")
 
+	// Custom join table for Note.Tags (cascade deletes)
+	if err = database.SetupJoinTable(&models.Note{}, "Tags", &models.NoteTag{}); err != nil {
+		log.Fatal("Failed to set up note tags join table:", err)
+		os.Exit(1)
+	}
+
 	// Auto-migrate schema
-	err = database.AutoMigrate(&models.User{}, &models.Note{}, &models.KnowledgeLink{})
+	err = database.AutoMigrate(&models.User{}, &models.Notebook{}, &models.Note{}, &models.KnowledgeLink{}, &models.Tag{}, &models.NoteTag{}, &models.NoteRevision{}, &models.NoteLink{}, &models.Session{}, &models.RotatedRefreshToken{}, &models.RecoveryCode{}, &models.UserToken{}, &models.PersonalAccessToken{}, &models.AuditEvent{}, &models.UserIdentity{}, &models.OIDCLoginState{}, &models.WeatherLocation{})
 	if err != nil {
 		log.Fatal("Failed to migrate database:", err)
 		os.Exit(1)
 	}
+	if err = cascadeUserDeletes(database); err != nil {
+		log.Fatal("Failed to migrate user foreign keys:", err)
+		os.Exit(1)
+	}
 	log.Println("Database migrated.")
 
 	DB = database
 }
+
+// cascadeUserDeletes makes notes and knowledge links follow the deletion of their user. Older schemas have
+// these foreign keys without ON DELETE CASCADE, and AutoMigrate does not change existing constraints.
+func cascadeUserDeletes(database *gorm.DB) error {
+	for _, name := range []string{"fk_users_notes", "fk_users_knowledge_links"} {
+		var deleteAction string // "c" for CASCADE, empty when the constraint does not exist
+		if err := database.Raw("SELECT confdeltype FROM pg_constraint WHERE conname = ?", name).Scan(&deleteAction).Error; err != nil {
+			return err
+		}
+		if deleteAction == "" || deleteAction == "c" {
+			continue
+		}
+		err := database.Transaction(func(tx *gorm.DB) error {
+			if err := tx.Migrator().DropConstraint(&models.User{}, name); err != nil {
+				return err
+			}
+			return tx.Migrator().CreateConstraint(&models.User{}, name)
+		})
+		if err != nil {
+			return err
+		}
+		log.Printf("Foreign key %s now cascades deletes.", name)
+	}
+	return nil
+}
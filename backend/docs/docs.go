@@ -24,51 +24,76 @@ const docTemplate = `{
     "host": "{{.Host}}",
     "basePath": "{{.BasePath}}",
     "paths": {
-        "/auth/login": {
-            "post": {
-                "description": "Authenticates a user and returns a JWT token upon successful login.",
-                "consumes": [
-                    "application/json"
+        "/admin/audit-events": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
                 ],
+                "description": "Lists security events (lockouts, admin actions), newest first. Requires the admin role.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "auth"
+                    "admin"
                 ],
-                "summary": "Log in an existing user",
+                "summary": "Get the audit trail",
                 "parameters": [
                     {
-                        "description": "User Login Credentials",
-                        "name": "credentials",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/handlers.LoginInput"
-                        }
+                        "type": "integer",
+                        "description": "Only events of this user",
+                        "name": "userId",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Only events of this type, e.g. account.locked",
+                        "name": "event",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "default": 20,
+                        "description": "Page size (1-100)",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "default": 0,
+                        "description": "Number of events to skip",
+                        "name": "offset",
+                        "in": "query"
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "Successfully logged in",
+                        "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/handlers.UserAuthResponse"
+                            "$ref": "#/definitions/handlers.PaginatedAuditEventsResponse"
                         }
                     },
                     "400": {
-                        "description": "Validation error or invalid input (e.g., {\\\"error\\\": \\\"Invalid input: ...\\\"})",
+                        "description": "Invalid parameters",
                         "schema": {
                             "type": "object"
                         }
                     },
                     "401": {
-                        "description": "Invalid credentials (e.g., {\\\"error\\\": \\\"Invalid credentials\\\"})",
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "object"
+                        }
+                    },
+                    "403": {
+                        "description": "Insufficient permissions",
                         "schema": {
                             "type": "object"
                         }
                     },
                     "500": {
-                        "description": "Internal server error (e.g., {\\\"error\\\": \\\"Database error finding user\\\"})",
+                        "description": "Internal server error (e.g., {\\\"error\\\": \\\"Failed to retrieve audit events\\\"})",
                         "schema": {
                             "type": "object"
                         }
@@ -76,51 +101,67 @@ const docTemplate = `{
                 }
             }
         },
-        "/auth/register": {
-            "post": {
-                "description": "Creates a new user account and returns a JWT token.",
-                "consumes": [
-                    "application/json"
+        "/admin/usage": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
                 ],
+                "description": "Lists the storage usage of users, largest first. Requires the admin role.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "auth"
+                    "admin"
                 ],
-                "summary": "Register a new user",
+                "summary": "Get storage usage of all users",
                 "parameters": [
                     {
-                        "description": "User Registration Data",
-                        "name": "user",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/handlers.RegisterInput"
-                        }
+                        "type": "integer",
+                        "default": 20,
+                        "description": "Page size (1-100)",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "default": 0,
+                        "description": "Number of users to skip",
+                        "name": "offset",
+                        "in": "query"
                     }
                 ],
                 "responses": {
-                    "201": {
-                        "description": "Successfully registered",
+                    "200": {
+                        "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/handlers.UserAuthResponse"
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/handlers.UserStorageUsage"
+                            }
                         }
                     },
                     "400": {
-                        "description": "Validation error or invalid input (e.g., {\\\"error\\\": \\\"Invalid input: Key: 'RegisterInput.Email' Error:Field validation for 'Email' failed on the 'email' tag\\\"})",
+                        "description": "Invalid parameters",
                         "schema": {
                             "type": "object"
                         }
                     },
-                    "409": {
-                        "description": "User with this email already exists (e.g., {\\\"error\\\": \\\"User with this email already exists\\\"})",
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "object"
+                        }
+                    },
+                    "403": {
+                        "description": "Insufficient permissions",
                         "schema": {
                             "type": "object"
                         }
                     },
                     "500": {
-                        "description": "Internal server error (e.g., {\\\"error\\\": \\\"Failed to hash password\\\"})",
+                        "description": "Internal server error (e.g., {\\\"error\\\": \\\"Failed to compute storage usage\\\"})",
                         "schema": {
                             "type": "object"
                         }
@@ -128,30 +169,82 @@ const docTemplate = `{
                 }
             }
         },
-        "/auth/validate-token": {
-            "post": {
+        "/admin/users": {
+            "get": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "Validates the provided JWT and returns user information if valid.",
+                "description": "Lists and searches user accounts, newest first. Requires the admin role.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "auth"
+                    "admin"
+                ],
+                "summary": "List users",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Search in email and full name",
+                        "name": "q",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Filter by role (user, admin)",
+                        "name": "role",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Filter by status (active, disabled)",
+                        "name": "status",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "default": 20,
+                        "description": "Page size (1-100)",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "default": 0,
+                        "description": "Number of users to skip",
+                        "name": "offset",
+                        "in": "query"
+                    }
                 ],
-                "summary": "Validate JWT Token and get user info",
                 "responses": {
                     "200": {
-                        "description": "User data for valid token",
+                        "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/models.User"
+                            "$ref": "#/definitions/handlers.PaginatedUsersResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid parameters",
+                        "schema": {
+                            "type": "object"
                         }
                     },
                     "401": {
-                        "description": "Unauthorized or invalid token (e.g., {\\\"error\\\": \\\"Unauthorized or invalid token\\\"})",
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "object"
+                        }
+                    },
+                    "403": {
+                        "description": "Insufficient permissions",
+                        "schema": {
+                            "type": "object"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error (e.g., {\\\"error\\\": \\\"Failed to retrieve users\\\"})",
                         "schema": {
                             "type": "object"
                         }
@@ -159,29 +252,35 @@ const docTemplate = `{
                 }
             }
         },
-        "/knowledge-links": {
+        "/admin/users/{id}": {
             "get": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "Retrieves a list of knowledge links for the current user",
+                "description": "Returns the account of a user. Requires the admin role.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "knowledge-links"
+                    "admin"
+                ],
+                "summary": "Get a user",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "User ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
                 ],
-                "summary": "Get all knowledge links for the authenticated user",
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "type": "array",
-                            "items": {
-                                "$ref": "#/definitions/models.KnowledgeLink"
-                            }
+                            "$ref": "#/definitions/models.User"
                         }
                     },
                     "401": {
@@ -190,51 +289,54 @@ const docTemplate = `{
                             "type": "object"
                         }
                     },
-                    "500": {
-                        "description": "Internal server error (e.g., {\"error\": \"Failed to retrieve knowledge links\"})",
+                    "403": {
+                        "description": "Insufficient permissions",
+                        "schema": {
+                            "type": "object"
+                        }
+                    },
+                    "404": {
+                        "description": "User not found",
                         "schema": {
                             "type": "object"
                         }
                     }
                 }
-            },
+            }
+        },
+        "/admin/users/{id}/disable": {
             "post": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "Add a new knowledge link for the authenticated user",
-                "consumes": [
-                    "application/json"
-                ],
+                "description": "Blocks the account: all sessions are revoked, personal access tokens stop working and logins are refused until the account is enabled again.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "knowledge-links"
+                    "admin"
                 ],
-                "summary": "Create a new knowledge link",
+                "summary": "Disable a user",
                 "parameters": [
                     {
-                        "description": "Knowledge Link data",
-                        "name": "link",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/handlers.CreateKnowledgeLinkInput"
-                        }
+                        "type": "integer",
+                        "description": "User ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
                     }
                 ],
                 "responses": {
-                    "201": {
-                        "description": "Created",
+                    "200": {
+                        "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/models.KnowledgeLink"
+                            "$ref": "#/definitions/models.User"
                         }
                     },
                     "400": {
-                        "description": "Invalid input (e.g., {\"error\": \"Invalid input: ...\"})",
+                        "description": "Admins cannot disable their own account",
                         "schema": {
                             "type": "object"
                         }
@@ -245,8 +347,20 @@ const docTemplate = `{
                             "type": "object"
                         }
                     },
+                    "403": {
+                        "description": "Insufficient permissions",
+                        "schema": {
+                            "type": "object"
+                        }
+                    },
+                    "404": {
+                        "description": "User not found",
+                        "schema": {
+                            "type": "object"
+                        }
+                    },
                     "500": {
-                        "description": "Internal server error (e.g., {\"error\": \"Failed to create knowledge link\"})",
+                        "description": "Internal server error (e.g., {\\\"error\\\": \\\"Failed to disable user\\\"})",
                         "schema": {
                             "type": "object"
                         }
@@ -254,25 +368,25 @@ const docTemplate = `{
                 }
             }
         },
-        "/knowledge-links/{id}": {
-            "delete": {
+        "/admin/users/{id}/enable": {
+            "post": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "Deletes a specific knowledge link by its ID, if it belongs to the authenticated user",
+                "description": "Lifts the block of a disabled account. The user has to log in again.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "knowledge-links"
+                    "admin"
                 ],
-                "summary": "Delete a knowledge link by ID",
+                "summary": "Enable a user",
                 "parameters": [
                     {
                         "type": "integer",
-                        "description": "Knowledge Link ID",
+                        "description": "User ID",
                         "name": "id",
                         "in": "path",
                         "required": true
@@ -280,9 +394,9 @@ const docTemplate = `{
                 ],
                 "responses": {
                     "200": {
-                        "description": "Knowledge link deleted successfully (e.g., {\"message\": \"Knowledge link deleted successfully\"})",
+                        "description": "OK",
                         "schema": {
-                            "type": "object"
+                            "$ref": "#/definitions/models.User"
                         }
                     },
                     "401": {
@@ -291,14 +405,20 @@ const docTemplate = `{
                             "type": "object"
                         }
                     },
+                    "403": {
+                        "description": "Insufficient permissions",
+                        "schema": {
+                            "type": "object"
+                        }
+                    },
                     "404": {
-                        "description": "Knowledge link not found or access denied",
+                        "description": "User not found",
                         "schema": {
                             "type": "object"
                         }
                     },
                     "500": {
-                        "description": "Internal server error (e.g., {\"error\": \"Failed to delete knowledge link\"})",
+                        "description": "Internal server error (e.g., {\\\"error\\\": \\\"Failed to enable user\\\"})",
                         "schema": {
                             "type": "object"
                         }
@@ -306,71 +426,72 @@ const docTemplate = `{
                 }
             }
         },
-        "/notes": {
-            "get": {
+        "/admin/users/{id}/force-password-reset": {
+            "post": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "Retrieves a paginated list of notes for the current user",
+                "description": "For compromised accounts: the password stops working, all sessions are revoked and personal access tokens are deleted.\nThe user receives a password reset email, valid for 24 hours, and can also request a new one via /auth/forgot-password.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "notes"
+                    "admin"
                 ],
-                "summary": "Get all notes for the authenticated user",
+                "summary": "Force a password reset",
                 "parameters": [
                     {
                         "type": "integer",
-                        "default": 10,
-                        "description": "Limit per page",
-                        "name": "limit",
-                        "in": "query"
-                    },
-                    {
-                        "type": "integer",
-                        "default": 0,
-                        "description": "Offset for pagination",
-                        "name": "offset",
-                        "in": "query"
+                        "description": "User ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "A list of notes with total count",
+                        "description": "Reset forced (e.g., {\\\"message\\\": \\\"Password reset email sent\\\", \\\"revokedSessions\\\": 2})",
                         "schema": {
-                            "$ref": "#/definitions/handlers.PaginatedNotesResponse"
+                            "type": "object"
                         }
                     },
-                    "400": {
-                        "description": "Invalid limit or offset parameters (e.g., {\\\"error\\\": \\\"Invalid limit or offset parameters\\\"})",
+                    "401": {
+                        "description": "Unauthorized",
                         "schema": {
                             "type": "object"
                         }
                     },
-                    "401": {
-                        "description": "Unauthorized",
+                    "403": {
+                        "description": "Insufficient permissions",
+                        "schema": {
+                            "type": "object"
+                        }
+                    },
+                    "404": {
+                        "description": "User not found",
                         "schema": {
                             "type": "object"
                         }
                     },
                     "500": {
-                        "description": "Internal server error (e.g., {\\\"error\\\": \\\"Failed to count notes\\\"})",
+                        "description": "Internal server error (e.g., {\\\"error\\\": \\\"Failed to force password reset\\\"})",
                         "schema": {
                             "type": "object"
                         }
                     }
                 }
-            },
-            "post": {
+            }
+        },
+        "/admin/users/{id}/role": {
+            "put": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "Add a new note for the authenticated user",
+                "description": "Sets the role (user or admin). The sessions of the user are revoked, so tokens with the old role stop working.",
                 "consumes": [
                     "application/json"
                 ],
@@ -378,29 +499,36 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "notes"
+                    "admin"
                 ],
-                "summary": "Create a new note",
+                "summary": "Change the role of a user",
                 "parameters": [
                     {
-                        "description": "Note data",
-                        "name": "note",
+                        "type": "integer",
+                        "description": "User ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "New role",
+                        "name": "role",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/handlers.CreateNoteInput"
+                            "$ref": "#/definitions/handlers.UpdateUserRoleInput"
                         }
                     }
                 ],
                 "responses": {
-                    "201": {
-                        "description": "Created",
+                    "200": {
+                        "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/models.Note"
+                            "$ref": "#/definitions/models.User"
                         }
                     },
                     "400": {
-                        "description": "Invalid input (e.g., {\\\"error\\\": \\\"Invalid input: ...\\\"})",
+                        "description": "Invalid role, or admins changing their own role",
                         "schema": {
                             "type": "object"
                         }
@@ -411,8 +539,20 @@ const docTemplate = `{
                             "type": "object"
                         }
                     },
+                    "403": {
+                        "description": "Insufficient permissions",
+                        "schema": {
+                            "type": "object"
+                        }
+                    },
+                    "404": {
+                        "description": "User not found",
+                        "schema": {
+                            "type": "object"
+                        }
+                    },
                     "500": {
-                        "description": "Internal server error (e.g., {\\\"error\\\": \\\"Failed to create note\\\"})",
+                        "description": "Internal server error (e.g., {\\\"error\\\": \\\"Failed to update role\\\"})",
                         "schema": {
                             "type": "object"
                         }
@@ -420,25 +560,25 @@ const docTemplate = `{
                 }
             }
         },
-        "/notes/{id}": {
+        "/admin/users/{id}/usage": {
             "get": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "Retrieves a specific note by its ID, if it belongs to the authenticated user",
+                "description": "Counts notes, revisions and knowledge links of the user and their size in bytes, trashed items included.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "notes"
+                    "admin"
                 ],
-                "summary": "Get a single note by ID",
+                "summary": "Get storage usage of a user",
                 "parameters": [
                     {
                         "type": "integer",
-                        "description": "Note ID",
+                        "description": "User ID",
                         "name": "id",
                         "in": "path",
                         "required": true
@@ -448,7 +588,7 @@ const docTemplate = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/models.Note"
+                            "$ref": "#/definitions/handlers.UserStorageUsage"
                         }
                     },
                     "401": {
@@ -457,27 +597,82 @@ const docTemplate = `{
                             "type": "object"
                         }
                     },
+                    "403": {
+                        "description": "Insufficient permissions",
+                        "schema": {
+                            "type": "object"
+                        }
+                    },
                     "404": {
-                        "description": "Note not found or access denied (e.g., {\\\"error\\\": \\\"Note not found or access denied\\\"})",
+                        "description": "User not found",
                         "schema": {
                             "type": "object"
                         }
                     },
                     "500": {
-                        "description": "Internal server error",
+                        "description": "Internal server error (e.g., {\\\"error\\\": \\\"Failed to compute storage usage\\\"})",
                         "schema": {
                             "type": "object"
                         }
                     }
                 }
-            },
-            "put": {
-                "security": [
+            }
+        },
+        "/auth/forgot-password": {
+            "post": {
+                "description": "Sends a password reset link to the email if an account with it exists. The response is the same either way, so it does not reveal which emails are registered.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "auth"
+                ],
+                "summary": "Request a password reset",
+                "parameters": [
                     {
-                        "BearerAuth": []
+                        "description": "Account email",
+                        "name": "email",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ForgotPasswordInput"
+                        }
                     }
                 ],
-                "description": "Updates a specific note by its ID, if it belongs to the authenticated user",
+                "responses": {
+                    "200": {
+                        "description": "Request accepted (e.g., {\\\"message\\\": \\\"If an account with this email exists, a password reset link has been sent\\\"})",
+                        "schema": {
+                            "type": "object"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid input",
+                        "schema": {
+                            "type": "object"
+                        }
+                    },
+                    "429": {
+                        "description": "Too many requests, see the Retry-After header",
+                        "schema": {
+                            "type": "object"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "object"
+                        }
+                    }
+                }
+            }
+        },
+        "/auth/login": {
+            "post": {
+                "description": "Authenticates a user and starts a session: returns a short-lived access token and a refresh token (see /auth/refresh).\nFor users with two-factor authentication a TwoFactorChallengeResponse is returned instead; finish the login with /auth/login/2fa.\nAfter repeated failed attempts the account is locked temporarily, with the lockout doubling on every further failure.",
                 "consumes": [
                     "application/json"
                 ],
@@ -485,104 +680,117 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "notes"
+                    "auth"
                 ],
-                "summary": "Update an existing note",
+                "summary": "Log in an existing user",
                 "parameters": [
                     {
-                        "type": "integer",
-                        "description": "Note ID",
-                        "name": "id",
-                        "in": "path",
-                        "required": true
-                    },
-                    {
-                        "description": "Updated note data",
-                        "name": "note",
+                        "description": "User Login Credentials",
+                        "name": "credentials",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/handlers.UpdateNoteInput"
+                            "$ref": "#/definitions/handlers.LoginInput"
                         }
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "OK",
+                        "description": "Successfully logged in (or TwoFactorChallengeResponse when 2FA is enabled)",
                         "schema": {
-                            "$ref": "#/definitions/models.Note"
+                            "$ref": "#/definitions/handlers.UserAuthResponse"
                         }
                     },
                     "400": {
-                        "description": "Invalid input",
+                        "description": "Validation error or invalid input (e.g., {\\\"error\\\": \\\"Invalid input: ...\\\"})",
                         "schema": {
                             "type": "object"
                         }
                     },
                     "401": {
-                        "description": "Unauthorized",
+                        "description": "Invalid credentials (e.g., {\\\"error\\\": \\\"Invalid credentials\\\"})",
                         "schema": {
                             "type": "object"
                         }
                     },
-                    "404": {
-                        "description": "Note not found or access denied",
+                    "403": {
+                        "description": "Account is disabled",
+                        "schema": {
+                            "type": "object"
+                        }
+                    },
+                    "429": {
+                        "description": "Too many requests or failed attempts, see the Retry-After header",
                         "schema": {
                             "type": "object"
                         }
                     },
                     "500": {
-                        "description": "Internal server error (e.g., {\\\"error\\\": \\\"Failed to update note\\\"})",
+                        "description": "Internal server error (e.g., {\\\"error\\\": \\\"Database error finding user\\\"})",
                         "schema": {
                             "type": "object"
                         }
                     }
                 }
-            },
-            "delete": {
-                "security": [
-                    {
-                        "BearerAuth": []
-                    }
+            }
+        },
+        "/auth/login/2fa": {
+            "post": {
+                "description": "Exchanges the challenge token from /auth/login and a TOTP or recovery code for an access and refresh token",
+                "consumes": [
+                    "application/json"
                 ],
-                "description": "Deletes a specific note by its ID, if it belongs to the authenticated user",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "notes"
+                    "auth"
                 ],
-                "summary": "Delete a note by ID",
+                "summary": "Finish a two-factor login",
                 "parameters": [
                     {
-                        "type": "integer",
-                        "description": "Note ID",
-                        "name": "id",
-                        "in": "path",
-                        "required": true
+                        "description": "Challenge token and code",
+                        "name": "credentials",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handlers.TwoFactorLoginInput"
+                        }
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "Note deleted successfully (e.g., {\\\"message\\\": \\\"Note deleted successfully\\\"})",
+                        "description": "Successfully logged in",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.UserAuthResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid input",
                         "schema": {
                             "type": "object"
                         }
                     },
                     "401": {
-                        "description": "Unauthorized",
+                        "description": "Invalid or expired challenge, or invalid code (e.g., {\\\"error\\\": \\\"Invalid two-factor code\\\"})",
                         "schema": {
                             "type": "object"
                         }
                     },
-                    "404": {
-                        "description": "Note not found or access denied",
+                    "403": {
+                        "description": "Account is disabled",
+                        "schema": {
+                            "type": "object"
+                        }
+                    },
+                    "429": {
+                        "description": "Too many requests or failed attempts, see the Retry-After header",
                         "schema": {
                             "type": "object"
                         }
                     },
                     "500": {
-                        "description": "Internal server error (e.g., {\\\"error\\\": \\\"Failed to delete note\\\"})",
+                        "description": "Internal server error (e.g., {\\\"error\\\": \\\"Failed to generate token\\\"})",
                         "schema": {
                             "type": "object"
                         }
@@ -590,85 +798,61 @@ const docTemplate = `{
                 }
             }
         },
-        "/users/me": {
-            "get": {
+        "/auth/logout": {
+            "post": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "Get profile information for the authenticated user",
+                "description": "Revokes the session of the access token, its refresh token can no longer be used",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "users"
+                    "auth"
                 ],
-                "summary": "Get current user's profile",
+                "summary": "Log out",
                 "responses": {
                     "200": {
-                        "description": "OK",
+                        "description": "Logged out",
                         "schema": {
-                            "$ref": "#/definitions/models.User"
+                            "$ref": "#/definitions/handlers.MessageResponse"
                         }
                     },
                     "401": {
-                        "description": "Unauthorized (e.g., {\\\"error\\\": \\\"User ID not found in token\\\"})",
-                        "schema": {
-                            "type": "object"
-                        }
-                    },
-                    "404": {
-                        "description": "User not found (e.g., {\\\"error\\\": \\\"User not found\\\"})",
+                        "description": "Unauthorized",
                         "schema": {
                             "type": "object"
                         }
                     },
                     "500": {
-                        "description": "Internal server error",
+                        "description": "Internal server error (e.g., {\\\"error\\\": \\\"Failed to log out\\\"})",
                         "schema": {
                             "type": "object"
                         }
                     }
                 }
-            },
-            "put": {
+            }
+        },
+        "/auth/logout-all": {
+            "post": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "Update profile information for the authenticated user",
-                "consumes": [
-                    "application/json"
-                ],
+                "description": "Revokes all sessions of the authenticated user, including the current one",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "users"
-                ],
-                "summary": "Update current user's profile",
-                "parameters": [
-                    {
-                        "description": "Profile Update Data",
-                        "name": "profile",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/handlers.UpdateUserProfileInput"
-                        }
-                    }
+                    "auth"
                 ],
+                "summary": "Log out everywhere",
                 "responses": {
                     "200": {
-                        "description": "OK",
-                        "schema": {
-                            "$ref": "#/definitions/models.User"
-                        }
-                    },
-                    "400": {
-                        "description": "Invalid input (e.g., {\\\"error\\\": \\\"Возраст должен быть корректным положительным числом\\\"})",
+                        "description": "Sessions revoked (e.g., {\\\"message\\\": \\\"Logged out from all sessions\\\", \\\"revoked\\\": 3})",
                         "schema": {
                             "type": "object"
                         }
@@ -679,14 +863,8 @@ const docTemplate = `{
                             "type": "object"
                         }
                     },
-                    "404": {
-                        "description": "User not found",
-                        "schema": {
-                            "type": "object"
-                        }
-                    },
                     "500": {
-                        "description": "Internal server error (e.g., {\\\"error\\\": \\\"Failed to update profile\\\"})",
+                        "description": "Internal server error (e.g., {\\\"error\\\": \\\"Failed to log out\\\"})",
                         "schema": {
                             "type": "object"
                         }
@@ -694,14 +872,9 @@ const docTemplate = `{
                 }
             }
         },
-        "/users/me/password": {
+        "/auth/oidc/exchange": {
             "post": {
-                "security": [
-                    {
-                        "BearerAuth": []
-                    }
-                ],
-                "description": "Change password for the authenticated user",
+                "description": "Exchanges the one-time code from the frontend page /oidc/callback for an access and refresh token. The code is valid for two minutes.\nFor users with two-factor authentication a TwoFactorChallengeResponse is returned instead; finish the login with /auth/login/2fa.",
                 "consumes": [
                     "application/json"
                 ],
@@ -709,47 +882,47 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "users"
+                    "auth"
                 ],
-                "summary": "Change current user's password",
+                "summary": "Get tokens after an OpenID Connect login",
                 "parameters": [
                     {
-                        "description": "Password Change Data",
-                        "name": "passwords",
+                        "description": "One-time login code",
+                        "name": "code",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/handlers.ChangePasswordInput"
+                            "$ref": "#/definitions/handlers.OIDCExchangeInput"
                         }
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "Password changed successfully (e.g., {\\\"message\\\": \\\"Password changed successfully\\\"})",
+                        "description": "Successfully logged in (or TwoFactorChallengeResponse when 2FA is enabled)",
                         "schema": {
-                            "type": "object"
+                            "$ref": "#/definitions/handlers.UserAuthResponse"
                         }
                     },
                     "400": {
-                        "description": "Invalid input (e.g., {\\\"error\\\": \\\"Новые пароли не совпадают\\\"})",
+                        "description": "Invalid, used or expired code",
                         "schema": {
                             "type": "object"
                         }
                     },
-                    "401": {
-                        "description": "Unauthorized or incorrect current password (e.g., {\\\"error\\\": \\\"Incorrect current password\\\"})",
+                    "403": {
+                        "description": "Account is disabled",
                         "schema": {
                             "type": "object"
                         }
                     },
-                    "404": {
-                        "description": "User not found",
+                    "429": {
+                        "description": "Too many requests, see the Retry-After header",
                         "schema": {
                             "type": "object"
                         }
                     },
                     "500": {
-                        "description": "Internal server error (e.g., {\\\"error\\\": \\\"Failed to update password\\\"})",
+                        "description": "Internal server error",
                         "schema": {
                             "type": "object"
                         }
@@ -757,202 +930,5169 @@ const docTemplate = `{
                 }
             }
         },
-        "/weather": {
+        "/auth/oidc/providers": {
             "get": {
-                "description": "Fetches current weather information from OpenWeatherMap, WeatherAPI.com, and Open-Meteo.",
+                "description": "Lists the identity providers users can sign in with (configured by OIDC_PROVIDERS)",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "weather"
-                ],
-                "summary": "Get weather data for a city from multiple sources",
-                "parameters": [
-                    {
-                        "type": "string",
-                        "example": "\"London\"",
-                        "description": "City name to fetch weather for",
-                        "name": "city",
-                        "in": "query",
-                        "required": true
-                    }
+                    "auth"
                 ],
+                "summary": "Get OpenID Connect providers",
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/models.WeatherResponse"
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/handlers.OIDCProviderResponse"
+                            }
                         }
-                    },
-                    "400": {
-                        "description": "City parameter is missing (e.g., {\\\"error\\\": \\\"City parameter is required\\\"})",
+                    }
+                }
+            }
+        },
+        "/auth/oidc/{provider}/callback": {
+            "get": {
+                "description": "Called by the identity provider. Exchanges the authorization code, validates the ID token and finds the user by the stored\nprovider subject or, for a verified email, by email. Unknown users are registered. Continues at the frontend page /oidc/callback\nwith a one-time code (query parameter code) or an error (query parameter error). The state must match the cookie set by the login start.",
+                "tags": [
+                    "auth"
+                ],
+                "summary": "Finish an OpenID Connect login",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Provider name",
+                        "name": "provider",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Authorization code",
+                        "name": "code",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "State of the login",
+                        "name": "state",
+                        "in": "query",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "302": {
+                        "description": "Redirect to the frontend"
+                    }
+                }
+            }
+        },
+        "/auth/oidc/{provider}/login": {
+            "get": {
+                "description": "Redirects the browser to the identity provider (authorization code flow with PKCE). The provider redirects back to\n/auth/oidc/{provider}/callback, which continues at the frontend page /oidc/callback with a one-time code for /auth/oidc/exchange.",
+                "tags": [
+                    "auth"
+                ],
+                "summary": "Start an OpenID Connect login",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Provider name",
+                        "name": "provider",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Local frontend path to continue at after the login, e.g. /notes",
+                        "name": "redirect",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "302": {
+                        "description": "Redirect to the identity provider"
+                    },
+                    "404": {
+                        "description": "Unknown provider",
                         "schema": {
                             "type": "object"
                         }
                     },
                     "500": {
-                        "description": "Failed to fetch weather data or all sources failed (e.g., {\\\"error\\\": \\\"Failed to fetch weather data from any source\\\"})",
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "object"
+                        }
+                    },
+                    "502": {
+                        "description": "Provider discovery failed",
                         "schema": {
                             "type": "object"
                         }
                     }
                 }
             }
-        }
-    },
-    "definitions": {
-        "handlers.ChangePasswordInput": {
-            "type": "object",
-            "required": [
-                "currentPassword",
-                "newPassword"
-            ],
-            "properties": {
-                "currentPassword": {
+        },
+        "/auth/refresh": {
+            "post": {
+                "description": "Exchanges a refresh token for a new access token and a new refresh token. Each refresh token can be used once;\npresenting an already used token revokes the whole session, since it means the token was copied.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "auth"
+                ],
+                "summary": "Refresh the access token",
+                "parameters": [
+                    {
+                        "description": "Refresh token",
+                        "name": "token",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handlers.RefreshTokenInput"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.TokenPairResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid input",
+                        "schema": {
+                            "type": "object"
+                        }
+                    },
+                    "401": {
+                        "description": "Invalid, expired or reused refresh token (e.g., {\\\"error\\\": \\\"Invalid or expired refresh token\\\"})",
+                        "schema": {
+                            "type": "object"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error (e.g., {\\\"error\\\": \\\"Failed to refresh token\\\"})",
+                        "schema": {
+                            "type": "object"
+                        }
+                    }
+                }
+            }
+        },
+        "/auth/register": {
+            "post": {
+                "description": "Creates a new user account and returns an access token with a refresh token.\nA verification link is sent to the email address, see /auth/verify-email.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "auth"
+                ],
+                "summary": "Register a new user",
+                "parameters": [
+                    {
+                        "description": "User Registration Data",
+                        "name": "user",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handlers.RegisterInput"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Successfully registered",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.UserAuthResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Validation error or invalid input (e.g., {\\\"error\\\": \\\"Invalid input: Key: 'RegisterInput.Email' Error:Field validation for 'Email' failed on the 'email' tag\\\"})",
+                        "schema": {
+                            "type": "object"
+                        }
+                    },
+                    "409": {
+                        "description": "User with this email already exists (e.g., {\\\"error\\\": \\\"User with this email already exists\\\"})",
+                        "schema": {
+                            "type": "object"
+                        }
+                    },
+                    "429": {
+                        "description": "Too many requests, see the Retry-After header",
+                        "schema": {
+                            "type": "object"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error (e.g., {\\\"error\\\": \\\"Failed to hash password\\\"})",
+                        "schema": {
+                            "type": "object"
+                        }
+                    }
+                }
+            }
+        },
+        "/auth/reset-password": {
+            "post": {
+                "description": "Sets a new password with the token from the password reset email. Tokens are single-use and expire after one hour.\nAll sessions of the user are revoked. The email counts as verified, since the link was received there.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "auth"
+                ],
+                "summary": "Reset the password",
+                "parameters": [
+                    {
+                        "description": "Reset token and new password",
+                        "name": "reset",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ResetPasswordInput"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Password reset (e.g., {\\\"message\\\": \\\"Password has been reset\\\", \\\"revokedSessions\\\": 2})",
+                        "schema": {
+                            "type": "object"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid input or invalid, used or expired token (e.g., {\\\"error\\\": \\\"Invalid or expired token\\\"})",
+                        "schema": {
+                            "type": "object"
+                        }
+                    },
+                    "429": {
+                        "description": "Too many requests, see the Retry-After header",
+                        "schema": {
+                            "type": "object"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error (e.g., {\\\"error\\\": \\\"Failed to reset password\\\"})",
+                        "schema": {
+                            "type": "object"
+                        }
+                    }
+                }
+            }
+        },
+        "/auth/validate-token": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Validates the provided JWT and returns user information if valid.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "auth"
+                ],
+                "summary": "Validate JWT Token and get user info",
+                "responses": {
+                    "200": {
+                        "description": "User data for valid token",
+                        "schema": {
+                            "$ref": "#/definitions/models.User"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized or invalid token (e.g., {\\\"error\\\": \\\"Unauthorized or invalid token\\\"})",
+                        "schema": {
+                            "type": "object"
+                        }
+                    }
+                }
+            }
+        },
+        "/auth/verify-email": {
+            "post": {
+                "description": "Confirms the email address of the user with the token from the verification email. Tokens are single-use and expire after 48 hours.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "auth"
+                ],
+                "summary": "Confirm the email address",
+                "parameters": [
+                    {
+                        "description": "Token from the verification link",
+                        "name": "token",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handlers.EmailTokenInput"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Email verified (e.g., {\\\"message\\\": \\\"Email verified\\\"})",
+                        "schema": {
+                            "type": "object"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid, used or expired token (e.g., {\\\"error\\\": \\\"Invalid or expired token\\\"})",
+                        "schema": {
+                            "type": "object"
+                        }
+                    },
+                    "429": {
+                        "description": "Too many requests, see the Retry-After header",
+                        "schema": {
+                            "type": "object"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error (e.g., {\\\"error\\\": \\\"Failed to verify email\\\"})",
+                        "schema": {
+                            "type": "object"
+                        }
+                    }
+                }
+            }
+        },
+        "/auth/verify-email/resend": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Sends a new verification link to the email of the authenticated user. Links from earlier emails stop working.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "auth"
+                ],
+                "summary": "Resend the verification email",
+                "responses": {
+                    "200": {
+                        "description": "Email sent (e.g., {\\\"message\\\": \\\"Verification email sent\\\"})",
+                        "schema": {
+                            "type": "object"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "object"
+                        }
+                    },
+                    "404": {
+                        "description": "User not found",
+                        "schema": {
+                            "type": "object"
+                        }
+                    },
+                    "409": {
+                        "description": "Email is already verified",
+                        "schema": {
+                            "type": "object"
+                        }
+                    },
+                    "429": {
+                        "description": "An email was sent less than a minute ago",
+                        "schema": {
+                            "type": "object"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error (e.g., {\\\"error\\\": \\\"Failed to send verification email\\\"})",
+                        "schema": {
+                            "type": "object"
+                        }
+                    }
+                }
+            }
+        },
+        "/knowledge-links": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Retrieves a list of knowledge links for the current user.\nWithout limit and cursor all links are returned as a plain array. With limit the response is a page\nwith totalCount (limit/offset); with the cursor parameter pages are read by cursor: pass an empty\ncursor for the first page and then the returned nextCursor.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "knowledge-links"
+                ],
+                "summary": "Get all knowledge links for the authenticated user",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Limit per page (1-100 in cursor mode)",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "default": 0,
+                        "description": "Offset for pagination, ignored in cursor mode",
+                        "name": "offset",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Cursor from nextCursor of the previous page, empty for the first page",
+                        "name": "cursor",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "createdAt",
+                            "updatedAt",
+                            "title"
+                        ],
+                        "type": "string",
+                        "default": "createdAt",
+                        "description": "Sort field",
+                        "name": "sort",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "asc",
+                            "desc"
+                        ],
+                        "type": "string",
+                        "description": "Sort direction; defaults to desc for dates and asc for title",
+                        "name": "order",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "A page of links (with limit or cursor), otherwise an array of models.KnowledgeLink",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.PaginatedKnowledgeLinksResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid pagination or sort parameters",
+                        "schema": {
+                            "type": "object"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "object"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error (e.g., {\"error\": \"Failed to retrieve knowledge links\"})",
+                        "schema": {
+                            "type": "object"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Add a new knowledge link for the authenticated user",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "knowledge-links"
+                ],
+                "summary": "Create a new knowledge link",
+                "parameters": [
+                    {
+                        "description": "Knowledge Link data",
+                        "name": "link",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handlers.CreateKnowledgeLinkInput"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/models.KnowledgeLink"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid input (e.g., {\"error\": \"Invalid input: ...\"})",
+                        "schema": {
+                            "type": "object"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "object"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error (e.g., {\"error\": \"Failed to create knowledge link\"})",
+                        "schema": {
+                            "type": "object"
+                        }
+                    }
+                }
+            }
+        },
+        "/knowledge-links/{id}": {
+            "delete": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Moves a specific knowledge link to the trash, if it belongs to the authenticated user (see /trash)",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "knowledge-links"
+                ],
+                "summary": "Delete a knowledge link by ID",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Knowledge Link ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Knowledge link moved to trash (e.g., {\"message\": \"Knowledge link moved to trash\"})",
+                        "schema": {
+                            "type": "object"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "object"
+                        }
+                    },
+                    "404": {
+                        "description": "Knowledge link not found or access denied",
+                        "schema": {
+                            "type": "object"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error (e.g., {\"error\": \"Failed to delete knowledge link\"})",
+                        "schema": {
+                            "type": "object"
+                        }
+                    }
+                }
+            }
+        },
+        "/notebooks": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Retrieves a flat list of notebooks with parent ids and the number of notes directly in each notebook",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "notebooks"
+                ],
+                "summary": "Get all notebooks of the authenticated user",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.Notebook"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "object"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error (e.g., {\\\"error\\\": \\\"Failed to retrieve notebooks\\\"})",
+                        "schema": {
+                            "type": "object"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Add a new notebook for the authenticated user, optionally inside another notebook",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "notebooks"
+                ],
+                "summary": "Create a new notebook",
+                "parameters": [
+                    {
+                        "description": "Notebook data",
+                        "name": "notebook",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handlers.NotebookInput"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/models.Notebook"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid input or parent notebook not found",
+                        "schema": {
+                            "type": "object"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "object"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error (e.g., {\\\"error\\\": \\\"Failed to create notebook\\\"})",
+                        "schema": {
+                            "type": "object"
+                        }
+                    }
+                }
+            }
+        },
+        "/notebooks/{id}": {
+            "put": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Updates the name and parent of a notebook. A notebook cannot be moved into itself or its sub-notebooks.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "notebooks"
+                ],
+                "summary": "Rename or move a notebook",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Notebook ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Notebook data",
+                        "name": "notebook",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handlers.NotebookInput"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.Notebook"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid input or move would create a cycle (e.g., {\\\"error\\\": \\\"Cannot move a notebook into itself or its sub-notebooks\\\"})",
+                        "schema": {
+                            "type": "object"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "object"
+                        }
+                    },
+                    "404": {
+                        "description": "Notebook not found or access denied",
+                        "schema": {
+                            "type": "object"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error (e.g., {\\\"error\\\": \\\"Failed to update notebook\\\"})",
+                        "schema": {
+                            "type": "object"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Deletes a notebook. With strategy=trash the notebook, its sub-notebooks and all their notes go to the trash; restoring a note brings its notebooks back.\nWith strategy=move its notes and sub-notebooks are moved to the parent notebook (or to the top level).",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "notebooks"
+                ],
+                "summary": "Delete a notebook",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Notebook ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "enum": [
+                            "move",
+                            "trash"
+                        ],
+                        "type": "string",
+                        "default": "move",
+                        "description": "What happens to the content",
+                        "name": "strategy",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Notebook deleted successfully (e.g., {\\\"message\\\": \\\"Notebook deleted successfully\\\"})",
+                        "schema": {
+                            "type": "object"
+                        }
+                    },
+                    "400": {
+                        "description": "Unknown strategy",
+                        "schema": {
+                            "type": "object"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "object"
+                        }
+                    },
+                    "404": {
+                        "description": "Notebook not found or access denied",
+                        "schema": {
+                            "type": "object"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error (e.g., {\\\"error\\\": \\\"Failed to delete notebook\\\"})",
+                        "schema": {
+                            "type": "object"
+                        }
+                    }
+                }
+            }
+        },
+        "/notes": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Retrieves a paginated list of notes for the current user.\nWith ` + "`" + `q` + "`" + ` the notes are filtered by full-text search over title and content, ranked by relevance\nand returned with highlighted ` + "`" + `titleHighlight` + "`" + ` and ` + "`" + `snippet` + "`" + ` fields (matches wrapped in \u003cmark\u003e).\nPagination is either by limit/offset (with totalCount) or, when the cursor parameter is present,\nby an opaque cursor: pass an empty cursor for the first page and then the returned nextCursor.\nCursor pages skip the total count and return tagCounts only on the first page.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "notes"
+                ],
+                "summary": "Get all notes for the authenticated user",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "default": 10,
+                        "description": "Limit per page (1-100 in cursor mode)",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "default": 0,
+                        "description": "Offset for pagination, ignored in cursor mode",
+                        "name": "offset",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Cursor from nextCursor of the previous page, empty for the first page",
+                        "name": "cursor",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "createdAt",
+                            "updatedAt",
+                            "title"
+                        ],
+                        "type": "string",
+                        "description": "Sort field; defaults to relevance when searching, otherwise createdAt",
+                        "name": "sort",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "asc",
+                            "desc"
+                        ],
+                        "type": "string",
+                        "description": "Sort direction; defaults to desc for dates and asc for title",
+                        "name": "order",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Full-text search query (websearch syntax: phrases in quotes, OR, -word)",
+                        "name": "q",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "russian",
+                            "english",
+                            "simple"
+                        ],
+                        "type": "string",
+                        "description": "Text search configuration for the query; defaults to each note's own language",
+                        "name": "lang",
+                        "in": "query"
+                    },
+                    {
+                        "type": "array",
+                        "items": {
+                            "type": "string"
+                        },
+                        "collectionFormat": "multi",
+                        "description": "Filter by tag name, can be repeated",
+                        "name": "tag",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "and",
+                            "or"
+                        ],
+                        "type": "string",
+                        "default": "and",
+                        "description": "How multiple tags are combined: and (all tags) or or (any tag)",
+                        "name": "tagMode",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Only notes of this notebook, 0 for notes outside notebooks",
+                        "name": "notebookId",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "default": false,
+                        "description": "With notebookId, also include notes of all sub-notebooks",
+                        "name": "includeSubNotebooks",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "A list of notes with total count and tag usage counts",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.PaginatedNotesResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid pagination or sort parameters (e.g., {\\\"error\\\": \\\"Invalid limit or offset parameters\\\"})",
+                        "schema": {
+                            "type": "object"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "object"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error (e.g., {\\\"error\\\": \\\"Failed to count notes\\\"})",
+                        "schema": {
+                            "type": "object"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Add a new note for the authenticated user",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "notes"
+                ],
+                "summary": "Create a new note",
+                "parameters": [
+                    {
+                        "description": "Note data",
+                        "name": "note",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handlers.CreateNoteInput"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/models.Note"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid input (e.g., {\\\"error\\\": \\\"Invalid input: ...\\\"})",
+                        "schema": {
+                            "type": "object"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "object"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error (e.g., {\\\"error\\\": \\\"Failed to create note\\\"})",
+                        "schema": {
+                            "type": "object"
+                        }
+                    }
+                }
+            }
+        },
+        "/notes/export": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Streams a zip archive with one Markdown file per note. Files are placed in folders named after notebooks and start with YAML front matter (id, title, createdAt, updatedAt, tags).",
+                "produces": [
+                    "application/zip"
+                ],
+                "tags": [
+                    "notes"
+                ],
+                "summary": "Export all notes",
+                "parameters": [
+                    {
+                        "enum": [
+                            "markdown"
+                        ],
+                        "type": "string",
+                        "default": "markdown",
+                        "description": "Export format",
+                        "name": "format",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Zip archive",
+                        "schema": {
+                            "type": "file"
+                        }
+                    },
+                    "400": {
+                        "description": "Unsupported export format",
+                        "schema": {
+                            "type": "object"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "object"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error (e.g., {\\\"error\\\": \\\"Failed to export notes\\\"})",
+                        "schema": {
+                            "type": "object"
+                        }
+                    }
+                }
+            }
+        },
+        "/notes/graph": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Returns all notes of the authenticated user as nodes and resolved [[...]] links between them as edges",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "notes"
+                ],
+                "summary": "Get the note link graph",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.NoteGraphResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "object"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error (e.g., {\\\"error\\\": \\\"Failed to build note graph\\\"})",
+                        "schema": {
+                            "type": "object"
+                        }
+                    }
+                }
+            }
+        },
+        "/notes/import": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Imports notes from a Markdown zip (created by the export endpoint or a zipped Obsidian-style vault), an Evernote .enex file or a Google Keep Takeout zip. Folders and Evernote notebooks become notebooks, front matter, Evernote tags and Keep labels become tags, titles and timestamps are preserved. Notes with the same id, or the same title in the same notebook, are updated; unchanged notes are skipped. Notes are stored in batches, each in its own transaction, so a failed import can be retried. Problems with single notes are listed in errors.",
+                "consumes": [
+                    "multipart/form-data"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "notes"
+                ],
+                "summary": "Import notes",
+                "parameters": [
+                    {
+                        "enum": [
+                            "markdown",
+                            "enex",
+                            "keep"
+                        ],
+                        "type": "string",
+                        "default": "markdown",
+                        "description": "Import format",
+                        "name": "format",
+                        "in": "query"
+                    },
+                    {
+                        "type": "file",
+                        "description": "Zip archive with .md files, .enex file or Keep Takeout zip",
+                        "name": "file",
+                        "in": "formData",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.NoteImportResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Missing or invalid file, or unsupported format",
+                        "schema": {
+                            "type": "object"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "object"
+                        }
+                    },
+                    "413": {
+                        "description": "File too large",
+                        "schema": {
+                            "type": "object"
+                        }
+                    }
+                }
+            }
+        },
+        "/notes/move": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Moves several notes of the authenticated user to a notebook, or out of any notebook when notebookId is null",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "notes"
+                ],
+                "summary": "Move notes to a notebook",
+                "parameters": [
+                    {
+                        "description": "Notes and target notebook",
+                        "name": "move",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handlers.MoveNotesInput"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Notes moved (e.g., {\\\"moved\\\": 3})",
+                        "schema": {
+                            "type": "object"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid input or notebook not found",
+                        "schema": {
+                            "type": "object"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "object"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error (e.g., {\\\"error\\\": \\\"Failed to move notes\\\"})",
+                        "schema": {
+                            "type": "object"
+                        }
+                    }
+                }
+            }
+        },
+        "/notes/unresolved-links": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Lists [[...]] references in the user's notes that do not point to an existing note",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "notes"
+                ],
+                "summary": "Get unresolved note links",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/handlers.UnresolvedNoteLink"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "object"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error (e.g., {\\\"error\\\": \\\"Failed to retrieve unresolved links\\\"})",
+                        "schema": {
+                            "type": "object"
+                        }
+                    }
+                }
+            }
+        },
+        "/notes/{id}": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Retrieves a specific note by its ID, if it belongs to the authenticated user",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "notes"
+                ],
+                "summary": "Get a single note by ID",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Note ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.Note"
+                        },
+                        "headers": {
+                            "ETag": {
+                                "type": "string",
+                                "description": "Entity tag of the note version, send it back in If-Match"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "object"
+                        }
+                    },
+                    "404": {
+                        "description": "Note not found or access denied (e.g., {\\\"error\\\": \\\"Note not found or access denied\\\"})",
+                        "schema": {
+                            "type": "object"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "object"
+                        }
+                    }
+                }
+            },
+            "put": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Updates a specific note by its ID, if it belongs to the authenticated user.\nThe previous title and content are kept as a revision (see /notes/{id}/revisions).\n[[Note Title]] and [[id:123]] references in the content are stored as links (see /notes/{id}/backlinks).",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "notes"
+                ],
+                "summary": "Update an existing note",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Note ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "ETag of the note version being edited",
+                        "name": "If-Match",
+                        "in": "header"
+                    },
+                    {
+                        "description": "Updated note data",
+                        "name": "note",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handlers.UpdateNoteInput"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.Note"
+                        },
+                        "headers": {
+                            "ETag": {
+                                "type": "string",
+                                "description": "Entity tag of the new note version"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid input",
+                        "schema": {
+                            "type": "object"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "object"
+                        }
+                    },
+                    "404": {
+                        "description": "Note not found or access denied",
+                        "schema": {
+                            "type": "object"
+                        }
+                    },
+                    "412": {
+                        "description": "Note was modified in the meantime, the body contains the current copy (e.g., {\\\"error\\\": \\\"...\\\", \\\"current\\\": {...}})",
+                        "schema": {
+                            "type": "object"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error (e.g., {\\\"error\\\": \\\"Failed to update note\\\"})",
+                        "schema": {
+                            "type": "object"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Moves a specific note to the trash, if it belongs to the authenticated user (see /trash)",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "notes"
+                ],
+                "summary": "Delete a note by ID",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Note ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "ETag of the note version being deleted",
+                        "name": "If-Match",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Note moved to trash (e.g., {\\\"message\\\": \\\"Note moved to trash\\\"})",
+                        "schema": {
+                            "type": "object"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "object"
+                        }
+                    },
+                    "404": {
+                        "description": "Note not found or access denied",
+                        "schema": {
+                            "type": "object"
+                        }
+                    },
+                    "412": {
+                        "description": "Note was modified in the meantime, the body contains the current copy",
+                        "schema": {
+                            "type": "object"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error (e.g., {\\\"error\\\": \\\"Failed to delete note\\\"})",
+                        "schema": {
+                            "type": "object"
+                        }
+                    }
+                }
+            }
+        },
+        "/notes/{id}/backlinks": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Lists notes of the authenticated user that contain a [[...]] reference to the note",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "notes"
+                ],
+                "summary": "Get notes linking to a note",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Note ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/handlers.NoteReference"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "object"
+                        }
+                    },
+                    "404": {
+                        "description": "Note not found or access denied",
+                        "schema": {
+                            "type": "object"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error (e.g., {\\\"error\\\": \\\"Failed to retrieve backlinks\\\"})",
+                        "schema": {
+                            "type": "object"
+                        }
+                    }
+                }
+            }
+        },
+        "/notes/{id}/revisions": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Lists saved revisions of the note, newest first. Content is omitted, use the diff endpoint to inspect changes.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "notes"
+                ],
+                "summary": "Get the revision history of a note",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Note ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.NoteRevision"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "object"
+                        }
+                    },
+                    "404": {
+                        "description": "Note not found or access denied",
+                        "schema": {
+                            "type": "object"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error (e.g., {\\\"error\\\": \\\"Failed to retrieve revisions\\\"})",
+                        "schema": {
+                            "type": "object"
+                        }
+                    }
+                }
+            }
+        },
+        "/notes/{id}/revisions/{rev}/diff": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Returns a line-level unified diff from the given revision to the current content of the note",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "notes"
+                ],
+                "summary": "Diff a revision against the current note",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Note ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Revision number",
+                        "name": "rev",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.NoteRevisionDiffResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "object"
+                        }
+                    },
+                    "404": {
+                        "description": "Note or revision not found (e.g., {\\\"error\\\": \\\"Revision not found\\\"})",
+                        "schema": {
+                            "type": "object"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error (e.g., {\\\"error\\\": \\\"Failed to build diff\\\"})",
+                        "schema": {
+                            "type": "object"
+                        }
+                    }
+                }
+            }
+        },
+        "/notes/{id}/revisions/{rev}/restore": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Replaces the title and content of the note with the revision. The current version is saved as a new revision first.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "notes"
+                ],
+                "summary": "Restore a note to a previous revision",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Note ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Revision number",
+                        "name": "rev",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.Note"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "object"
+                        }
+                    },
+                    "404": {
+                        "description": "Note or revision not found",
+                        "schema": {
+                            "type": "object"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error (e.g., {\\\"error\\\": \\\"Failed to restore revision\\\"})",
+                        "schema": {
+                            "type": "object"
+                        }
+                    }
+                }
+            }
+        },
+        "/tags": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Retrieves the user's tags with the number of notes each one is attached to",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "tags"
+                ],
+                "summary": "Get all tags of the authenticated user",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.TagUsage"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "object"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error (e.g., {\\\"error\\\": \\\"Failed to retrieve tags\\\"})",
+                        "schema": {
+                            "type": "object"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Add a new tag for the authenticated user",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "tags"
+                ],
+                "summary": "Create a new tag",
+                "parameters": [
+                    {
+                        "description": "Tag data",
+                        "name": "tag",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handlers.TagInput"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/models.Tag"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid input",
+                        "schema": {
+                            "type": "object"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "object"
+                        }
+                    },
+                    "409": {
+                        "description": "Tag already exists (e.g., {\\\"error\\\": \\\"Tag with this name already exists\\\"})",
+                        "schema": {
+                            "type": "object"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error (e.g., {\\\"error\\\": \\\"Failed to create tag\\\"})",
+                        "schema": {
+                            "type": "object"
+                        }
+                    }
+                }
+            }
+        },
+        "/tags/{id}": {
+            "put": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Renames a tag of the authenticated user. Use merge to combine it with an existing tag.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "tags"
+                ],
+                "summary": "Rename a tag",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Tag ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "New tag name",
+                        "name": "tag",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handlers.TagInput"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.Tag"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid input",
+                        "schema": {
+                            "type": "object"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "object"
+                        }
+                    },
+                    "404": {
+                        "description": "Tag not found or access denied",
+                        "schema": {
+                            "type": "object"
+                        }
+                    },
+                    "409": {
+                        "description": "Another tag already has this name",
+                        "schema": {
+                            "type": "object"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error (e.g., {\\\"error\\\": \\\"Failed to rename tag\\\"})",
+                        "schema": {
+                            "type": "object"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Deletes a tag of the authenticated user. Notes keep existing, only the tag is detached.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "tags"
+                ],
+                "summary": "Delete a tag by ID",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Tag ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Tag deleted successfully (e.g., {\\\"message\\\": \\\"Tag deleted successfully\\\"})",
+                        "schema": {
+                            "type": "object"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "object"
+                        }
+                    },
+                    "404": {
+                        "description": "Tag not found or access denied",
+                        "schema": {
+                            "type": "object"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error (e.g., {\\\"error\\\": \\\"Failed to delete tag\\\"})",
+                        "schema": {
+                            "type": "object"
+                        }
+                    }
+                }
+            }
+        },
+        "/tags/{id}/merge": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Moves all notes of the tag to the target tag and deletes the source tag",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "tags"
+                ],
+                "summary": "Merge a tag into another tag",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Source tag ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Target tag",
+                        "name": "merge",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handlers.MergeTagsInput"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "The target tag",
+                        "schema": {
+                            "$ref": "#/definitions/models.Tag"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid input (e.g., {\\\"error\\\": \\\"Cannot merge a tag into itself\\\"})",
+                        "schema": {
+                            "type": "object"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "object"
+                        }
+                    },
+                    "404": {
+                        "description": "Tag not found or access denied",
+                        "schema": {
+                            "type": "object"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error (e.g., {\\\"error\\\": \\\"Failed to merge tags\\\"})",
+                        "schema": {
+                            "type": "object"
+                        }
+                    }
+                }
+            }
+        },
+        "/trash": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Lists deleted notes and knowledge links of the authenticated user, most recently deleted first. Personal access tokens see only the kinds they have the read scope of (notes:read, links:read).",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "trash"
+                ],
+                "summary": "Get items in the trash",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.TrashResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "object"
+                        }
+                    },
+                    "403": {
+                        "description": "Token has neither notes:read nor links:read",
+                        "schema": {
+                            "type": "object"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error (e.g., {\\\"error\\\": \\\"Failed to retrieve trash\\\"})",
+                        "schema": {
+                            "type": "object"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Permanently deletes all notes and knowledge links in the trash of the authenticated user. Personal access tokens delete only the kinds they have the write scope of (notes:write, links:write).",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "trash"
+                ],
+                "summary": "Empty the trash",
+                "responses": {
+                    "200": {
+                        "description": "Trash emptied (e.g., {\\\"message\\\": \\\"Trash emptied\\\", \\\"deleted\\\": 5})",
+                        "schema": {
+                            "type": "object"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "object"
+                        }
+                    },
+                    "403": {
+                        "description": "Token has neither notes:write nor links:write",
+                        "schema": {
+                            "type": "object"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error (e.g., {\\\"error\\\": \\\"Failed to empty trash\\\"})",
+                        "schema": {
+                            "type": "object"
+                        }
+                    }
+                }
+            }
+        },
+        "/trash/{kind}/{id}": {
+            "delete": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Permanently deletes a note or knowledge link that is already in the trash",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "trash"
+                ],
+                "summary": "Permanently delete an item from the trash",
+                "parameters": [
+                    {
+                        "enum": [
+                            "notes",
+                            "knowledge-links"
+                        ],
+                        "type": "string",
+                        "description": "Item kind",
+                        "name": "kind",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Item ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Item deleted permanently (e.g., {\\\"message\\\": \\\"Note deleted permanently\\\"})",
+                        "schema": {
+                            "type": "object"
+                        }
+                    },
+                    "400": {
+                        "description": "Unknown item kind",
+                        "schema": {
+                            "type": "object"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "object"
+                        }
+                    },
+                    "403": {
+                        "description": "Token does not have the write scope of the kind (notes:write, links:write)",
+                        "schema": {
+                            "type": "object"
+                        }
+                    },
+                    "404": {
+                        "description": "Item not found in trash",
+                        "schema": {
+                            "type": "object"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error (e.g., {\\\"error\\\": \\\"Failed to delete item\\\"})",
+                        "schema": {
+                            "type": "object"
+                        }
+                    }
+                }
+            }
+        },
+        "/trash/{kind}/{id}/restore": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Restores a deleted note or knowledge link of the authenticated user. A note deleted together with its notebook brings the notebook and its parents back.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "trash"
+                ],
+                "summary": "Restore an item from the trash",
+                "parameters": [
+                    {
+                        "enum": [
+                            "notes",
+                            "knowledge-links"
+                        ],
+                        "type": "string",
+                        "description": "Item kind",
+                        "name": "kind",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Item ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Item restored (e.g., {\\\"message\\\": \\\"Note restored successfully\\\"})",
+                        "schema": {
+                            "type": "object"
+                        }
+                    },
+                    "400": {
+                        "description": "Unknown item kind",
+                        "schema": {
+                            "type": "object"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "object"
+                        }
+                    },
+                    "403": {
+                        "description": "Token does not have the write scope of the kind (notes:write, links:write)",
+                        "schema": {
+                            "type": "object"
+                        }
+                    },
+                    "404": {
+                        "description": "Item not found in trash",
+                        "schema": {
+                            "type": "object"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error (e.g., {\\\"error\\\": \\\"Failed to restore item\\\"})",
+                        "schema": {
+                            "type": "object"
+                        }
+                    }
+                }
+            }
+        },
+        "/users/me": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Get profile information for the authenticated user",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "users"
+                ],
+                "summary": "Get current user's profile",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.User"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized (e.g., {\\\"error\\\": \\\"User ID not found in token\\\"})",
+                        "schema": {
+                            "type": "object"
+                        }
+                    },
+                    "404": {
+                        "description": "User not found (e.g., {\\\"error\\\": \\\"User not found\\\"})",
+                        "schema": {
+                            "type": "object"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "object"
+                        }
+                    }
+                }
+            },
+            "put": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Update profile information for the authenticated user",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "users"
+                ],
+                "summary": "Update current user's profile",
+                "parameters": [
+                    {
+                        "description": "Profile Update Data",
+                        "name": "profile",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handlers.UpdateUserProfileInput"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.User"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid input (e.g., {\\\"error\\\": \\\"Возраст должен быть корректным положительным числом\\\"})",
+                        "schema": {
+                            "type": "object"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "object"
+                        }
+                    },
+                    "404": {
+                        "description": "User not found",
+                        "schema": {
+                            "type": "object"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error (e.g., {\\\"error\\\": \\\"Failed to update profile\\\"})",
+                        "schema": {
+                            "type": "object"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Schedules the deletion of the authenticated user after a grace period (ACCOUNT_DELETION_GRACE_DAYS, 14 days by default). All other sessions are signed out and personal access tokens stop working; logging in and cancelling stays possible until the deletion. Then the account and all its data (notes, notebooks, tags, links, sessions, tokens) are deleted permanently. With a grace period of 0 the account is deleted right away. Accounts created through OpenID Connect have no password and set one with the password reset first.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "users"
+                ],
+                "summary": "Delete the account",
+                "parameters": [
+                    {
+                        "description": "Current password",
+                        "name": "confirmation",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handlers.DeleteAccountInput"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.AccountDeletionResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid input",
+                        "schema": {
+                            "type": "object"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized or incorrect password (e.g., {\\\"error\\\": \\\"Incorrect password\\\"})",
+                        "schema": {
+                            "type": "object"
+                        }
+                    },
+                    "404": {
+                        "description": "User not found",
+                        "schema": {
+                            "type": "object"
+                        }
+                    },
+                    "429": {
+                        "description": "Too many failed attempts, see the Retry-After header",
+                        "schema": {
+                            "type": "object"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error (e.g., {\\\"error\\\": \\\"Failed to delete account\\\"})",
+                        "schema": {
+                            "type": "object"
+                        }
+                    }
+                }
+            }
+        },
+        "/users/me/2fa/confirm": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Enables 2FA after checking the first code from the authenticator app and returns one-time recovery codes",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "users"
+                ],
+                "summary": "Confirm two-factor enrollment",
+                "parameters": [
+                    {
+                        "description": "Code from the authenticator app",
+                        "name": "code",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handlers.TwoFactorCodeInput"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.RecoveryCodesResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid input or no enrollment in progress",
+                        "schema": {
+                            "type": "object"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized or invalid code (e.g., {\\\"error\\\": \\\"Invalid two-factor code\\\"})",
+                        "schema": {
+                            "type": "object"
+                        }
+                    },
+                    "409": {
+                        "description": "Two-factor authentication is already enabled",
+                        "schema": {
+                            "type": "object"
+                        }
+                    },
+                    "429": {
+                        "description": "Too many failed attempts, see the Retry-After header",
+                        "schema": {
+                            "type": "object"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error (e.g., {\\\"error\\\": \\\"Failed to enable two-factor authentication\\\"})",
+                        "schema": {
+                            "type": "object"
+                        }
+                    }
+                }
+            }
+        },
+        "/users/me/2fa/disable": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Turns 2FA off. Requires the password and a TOTP or recovery code; remaining recovery codes are deleted.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "users"
+                ],
+                "summary": "Disable two-factor authentication",
+                "parameters": [
+                    {
+                        "description": "Password and code",
+                        "name": "credentials",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handlers.DisableTwoFactorInput"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Two-factor authentication disabled",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.MessageResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid input or 2FA not enabled",
+                        "schema": {
+                            "type": "object"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized, incorrect password or invalid code",
+                        "schema": {
+                            "type": "object"
+                        }
+                    },
+                    "429": {
+                        "description": "Too many failed attempts, see the Retry-After header",
+                        "schema": {
+                            "type": "object"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error (e.g., {\\\"error\\\": \\\"Failed to disable two-factor authentication\\\"})",
+                        "schema": {
+                            "type": "object"
+                        }
+                    }
+                }
+            }
+        },
+        "/users/me/2fa/recovery-codes": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Replaces all recovery codes with a new set. Requires a current TOTP or recovery code.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "users"
+                ],
+                "summary": "Regenerate recovery codes",
+                "parameters": [
+                    {
+                        "description": "TOTP or recovery code",
+                        "name": "code",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handlers.TwoFactorCodeInput"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.RecoveryCodesResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid input or 2FA not enabled",
+                        "schema": {
+                            "type": "object"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized or invalid code",
+                        "schema": {
+                            "type": "object"
+                        }
+                    },
+                    "429": {
+                        "description": "Too many failed attempts, see the Retry-After header",
+                        "schema": {
+                            "type": "object"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error (e.g., {\\\"error\\\": \\\"Failed to regenerate recovery codes\\\"})",
+                        "schema": {
+                            "type": "object"
+                        }
+                    }
+                }
+            }
+        },
+        "/users/me/2fa/setup": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Generates a TOTP secret (RFC 6238) and returns it as otpauth URI and QR code. 2FA is enabled only after /users/me/2fa/confirm.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "users"
+                ],
+                "summary": "Start two-factor enrollment",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.TwoFactorSetupResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "object"
+                        }
+                    },
+                    "409": {
+                        "description": "Two-factor authentication is already enabled",
+                        "schema": {
+                            "type": "object"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error (e.g., {\\\"error\\\": \\\"Failed to set up two-factor authentication\\\"})",
+                        "schema": {
+                            "type": "object"
+                        }
+                    }
+                }
+            }
+        },
+        "/users/me/deletion/cancel": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Keeps the account of the authenticated user when its deletion is scheduled",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "users"
+                ],
+                "summary": "Cancel the account deletion",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.User"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "object"
+                        }
+                    },
+                    "404": {
+                        "description": "User not found",
+                        "schema": {
+                            "type": "object"
+                        }
+                    },
+                    "409": {
+                        "description": "No deletion is scheduled",
+                        "schema": {
+                            "type": "object"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error (e.g., {\\\"error\\\": \\\"Failed to cancel account deletion\\\"})",
+                        "schema": {
+                            "type": "object"
+                        }
+                    }
+                }
+            }
+        },
+        "/users/me/export": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Downloads everything stored about the authenticated user: profile, notebooks, tags, notes with revisions, knowledge links (trashed items included), sessions, personal access tokens, linked identities, saved weather locations and audit events. The zip archive has one JSON file per section, the json format is a single document.",
+                "produces": [
+                    "application/zip",
+                    "application/json"
+                ],
+                "tags": [
+                    "users"
+                ],
+                "summary": "Export all personal data",
+                "parameters": [
+                    {
+                        "enum": [
+                            "zip",
+                            "json"
+                        ],
+                        "type": "string",
+                        "default": "zip",
+                        "description": "Export format",
+                        "name": "format",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Zip archive or JSON document",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.AccountExport"
+                        }
+                    },
+                    "400": {
+                        "description": "Unsupported export format",
+                        "schema": {
+                            "type": "object"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "object"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error (e.g., {\\\"error\\\": \\\"Failed to export account\\\"})",
+                        "schema": {
+                            "type": "object"
+                        }
+                    }
+                }
+            }
+        },
+        "/users/me/password": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Change password for the authenticated user. By default all other sessions are revoked, pass revokeOtherSessions=false to keep them.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "users"
+                ],
+                "summary": "Change current user's password",
+                "parameters": [
+                    {
+                        "description": "Password Change Data",
+                        "name": "passwords",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ChangePasswordInput"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Password changed successfully (e.g., {\\\"message\\\": \\\"Password changed successfully\\\", \\\"revokedSessions\\\": 2})",
+                        "schema": {
+                            "type": "object"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid input (e.g., {\\\"error\\\": \\\"Новые пароли не совпадают\\\"})",
+                        "schema": {
+                            "type": "object"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized or incorrect current password (e.g., {\\\"error\\\": \\\"Incorrect current password\\\"})",
+                        "schema": {
+                            "type": "object"
+                        }
+                    },
+                    "404": {
+                        "description": "User not found",
+                        "schema": {
+                            "type": "object"
+                        }
+                    },
+                    "429": {
+                        "description": "Too many failed attempts, see the Retry-After header",
+                        "schema": {
+                            "type": "object"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error (e.g., {\\\"error\\\": \\\"Failed to update password\\\"})",
+                        "schema": {
+                            "type": "object"
+                        }
+                    }
+                }
+            }
+        },
+        "/users/me/revision-policy": {
+            "put": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Sets how many note revisions are kept and how old they can get. Existing revisions are pruned immediately.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "users"
+                ],
+                "summary": "Update note revision retention policy",
+                "parameters": [
+                    {
+                        "description": "Retention policy",
+                        "name": "policy",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handlers.RevisionPolicyInput"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.User"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid input",
+                        "schema": {
+                            "type": "object"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "object"
+                        }
+                    },
+                    "404": {
+                        "description": "User not found",
+                        "schema": {
+                            "type": "object"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error (e.g., {\\\"error\\\": \\\"Failed to update revision policy\\\"})",
+                        "schema": {
+                            "type": "object"
+                        }
+                    }
+                }
+            }
+        },
+        "/users/me/sessions": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Lists devices the user is signed in on, most recently active first. The session of the request is marked as current.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "users"
+                ],
+                "summary": "Get active sessions of the current user",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.Session"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "object"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error (e.g., {\\\"error\\\": \\\"Failed to retrieve sessions\\\"})",
+                        "schema": {
+                            "type": "object"
+                        }
+                    }
+                }
+            }
+        },
+        "/users/me/sessions/{id}": {
+            "delete": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Revokes one session of the current user, e.g. a lost device. Its tokens stop working immediately.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "users"
+                ],
+                "summary": "Sign out a session",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Session ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Session revoked",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.MessageResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "object"
+                        }
+                    },
+                    "404": {
+                        "description": "Session not found or already revoked",
+                        "schema": {
+                            "type": "object"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error (e.g., {\\\"error\\\": \\\"Failed to revoke session\\\"})",
+                        "schema": {
+                            "type": "object"
+                        }
+                    }
+                }
+            }
+        },
+        "/users/me/tokens": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Lists the tokens with their scopes, expiry and last use, newest first. The tokens themselves are not returned.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "users"
+                ],
+                "summary": "Get personal access tokens of the current user",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.PersonalAccessToken"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "object"
+                        }
+                    },
+                    "403": {
+                        "description": "Tokens cannot be managed with a personal access token",
+                        "schema": {
+                            "type": "object"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error (e.g., {\\\"error\\\": \\\"Failed to retrieve tokens\\\"})",
+                        "schema": {
+                            "type": "object"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Creates a named token for scripts and integrations. It is sent like a JWT in the Authorization header, but works only for the granted scopes:\nnotes:read, notes:write (notes, notebooks, tags, trash), links:read, links:write (knowledge base) and profile:read.\nThe token is returned only in this response.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "users"
+                ],
+                "summary": "Create a personal access token",
+                "parameters": [
+                    {
+                        "description": "Token name, scopes and optional lifetime",
+                        "name": "token",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handlers.CreateAccessTokenInput"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.CreatedAccessTokenResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid input (e.g., unknown scope)",
+                        "schema": {
+                            "type": "object"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "object"
+                        }
+                    },
+                    "403": {
+                        "description": "Tokens cannot be managed with a personal access token",
+                        "schema": {
+                            "type": "object"
+                        }
+                    },
+                    "409": {
+                        "description": "A token with this name already exists",
+                        "schema": {
+                            "type": "object"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error (e.g., {\\\"error\\\": \\\"Failed to create token\\\"})",
+                        "schema": {
+                            "type": "object"
+                        }
+                    }
+                }
+            }
+        },
+        "/users/me/tokens/{id}": {
+            "delete": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Deletes the token, requests made with it are rejected immediately",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "users"
+                ],
+                "summary": "Revoke a personal access token",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Token ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Token revoked",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.MessageResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "object"
+                        }
+                    },
+                    "403": {
+                        "description": "Tokens cannot be managed with a personal access token",
+                        "schema": {
+                            "type": "object"
+                        }
+                    },
+                    "404": {
+                        "description": "Token not found or access denied",
+                        "schema": {
+                            "type": "object"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error (e.g., {\\\"error\\\": \\\"Failed to revoke token\\\"})",
+                        "schema": {
+                            "type": "object"
+                        }
+                    }
+                }
+            }
+        },
+        "/users/me/weather-preferences": {
+            "put": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Sets the units (metric: °C, imperial: °F) and the language of the personal weather dashboard",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "users"
+                ],
+                "summary": "Update weather preferences",
+                "parameters": [
+                    {
+                        "description": "Units and language",
+                        "name": "preferences",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handlers.WeatherPreferencesInput"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.User"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid input",
+                        "schema": {
+                            "type": "object"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "object"
+                        }
+                    },
+                    "404": {
+                        "description": "User not found",
+                        "schema": {
+                            "type": "object"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error (e.g., {\\\"error\\\": \\\"Failed to update weather preferences\\\"})",
+                        "schema": {
+                            "type": "object"
+                        }
+                    }
+                }
+            }
+        },
+        "/weather": {
+            "get": {
+                "description": "Fetches current weather information from all enabled providers (OpenWeatherMap, WeatherAPI.com and Open-Meteo by default, see WEATHER_PROVIDERS) in parallel.\nAnswers are cached per city and provider for WEATHER_CACHE_TTL; fetchedAt of each source tells its age, the X-Cache header (HIT, MISS, PARTIAL) whether the sources came from the cache.\nThe temperatures are combined with the aggregation strategy (WEATHER_AGGREGATION by default). With three or more sources, those further than WEATHER_OUTLIER_THRESHOLD °C from the median are flagged as outliers and left out. spread and confidence tell how well the sources agree, failures lists the providers that did not answer and why.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "weather"
+                ],
+                "summary": "Get weather data for a city from multiple sources",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "example": "\"London\"",
+                        "description": "City name to fetch weather for",
+                        "name": "city",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "enum": [
+                            "metric",
+                            "imperial"
+                        ],
+                        "type": "string",
+                        "default": "metric",
+                        "description": "metric (°C) or imperial (°F)",
+                        "name": "units",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "ru",
+                            "en"
+                        ],
+                        "type": "string",
+                        "default": "ru",
+                        "description": "Language of the descriptions",
+                        "name": "lang",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "mean",
+                            "median",
+                            "trimmed",
+                            "weighted"
+                        ],
+                        "type": "string",
+                        "description": "How to combine the temperatures of the sources, WEATHER_AGGREGATION (median) by default",
+                        "name": "aggregation",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.WeatherResponse"
+                        },
+                        "headers": {
+                            "X-Cache": {
+                                "type": "string",
+                                "description": "HIT, MISS or PARTIAL"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "City parameter is missing or units/lang/aggregation are invalid (e.g., {\\\"error\\\": \\\"City parameter is required\\\"})",
+                        "schema": {
+                            "type": "object"
+                        }
+                    },
+                    "500": {
+                        "description": "All sources failed (e.g., {\\\"error\\\": \\\"Failed to fetch weather data from any source\\\", \\\"failures\\\": [...]})",
+                        "schema": {
+                            "type": "object"
+                        }
+                    }
+                }
+            }
+        },
+        "/weather/forecast": {
+            "get": {
+                "description": "Fetches a daily forecast (min/max temperature, precipitation probability) and optionally hourly temperatures from all enabled providers that support forecasts, and combines them per date and per point in time with the aggregation strategy, leaving out outliers like for current weather. OpenWeatherMap forecasts up to 5 days in 3-hour steps, WeatherAPI.com up to 14 days (3 on the free plan), Open-Meteo up to 16 days. Forecasts are cached like current weather.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "weather"
+                ],
+                "summary": "Get a weather forecast for a city",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "example": "\"London\"",
+                        "description": "City name to fetch the forecast for",
+                        "name": "city",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "default": 3,
+                        "description": "Number of days, starting today (1-16)",
+                        "name": "days",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "default": false,
+                        "description": "Include hourly temperatures",
+                        "name": "hourly",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "metric",
+                            "imperial"
+                        ],
+                        "type": "string",
+                        "default": "metric",
+                        "description": "metric (°C) or imperial (°F)",
+                        "name": "units",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "ru",
+                            "en"
+                        ],
+                        "type": "string",
+                        "default": "ru",
+                        "description": "Language of the place lookup",
+                        "name": "lang",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "mean",
+                            "median",
+                            "trimmed",
+                            "weighted"
+                        ],
+                        "type": "string",
+                        "description": "How to combine the temperatures of the sources, WEATHER_AGGREGATION (median) by default",
+                        "name": "aggregation",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.ForecastResponse"
+                        },
+                        "headers": {
+                            "X-Cache": {
+                                "type": "string",
+                                "description": "HIT, MISS or PARTIAL"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid parameters (e.g., {\\\"error\\\": \\\"City parameter is required\\\"})",
+                        "schema": {
+                            "type": "object"
+                        }
+                    },
+                    "500": {
+                        "description": "All sources failed (e.g., {\\\"error\\\": \\\"Failed to fetch forecast from any source\\\", \\\"failures\\\": [...]})",
+                        "schema": {
+                            "type": "object"
+                        }
+                    }
+                }
+            }
+        },
+        "/weather/locations": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Lists the saved locations of the user, the default location first",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "weather"
+                ],
+                "summary": "Get saved weather locations",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.WeatherLocation"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "object"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error (e.g., {\\\"error\\\": \\\"Failed to retrieve locations\\\"})",
+                        "schema": {
+                            "type": "object"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Saves a named place for the weather dashboard, up to 20 per user. The first location, or one saved with isDefault, becomes the default.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "weather"
+                ],
+                "summary": "Save a weather location",
+                "parameters": [
+                    {
+                        "description": "Name and coordinates",
+                        "name": "location",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handlers.WeatherLocationInput"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/models.WeatherLocation"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid input",
+                        "schema": {
+                            "type": "object"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "object"
+                        }
+                    },
+                    "409": {
+                        "description": "The user already has 20 locations",
+                        "schema": {
+                            "type": "object"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error (e.g., {\\\"error\\\": \\\"Failed to save location\\\"})",
+                        "schema": {
+                            "type": "object"
+                        }
+                    }
+                }
+            }
+        },
+        "/weather/locations/{id}": {
+            "put": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Changes the name and coordinates of a location. Setting isDefault makes it the default location; the default cannot be unset, make another location the default instead.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "weather"
+                ],
+                "summary": "Update a saved weather location",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Location ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Name and coordinates",
+                        "name": "location",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handlers.WeatherLocationInput"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.WeatherLocation"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid input",
+                        "schema": {
+                            "type": "object"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "object"
+                        }
+                    },
+                    "404": {
+                        "description": "Location not found or access denied",
+                        "schema": {
+                            "type": "object"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error (e.g., {\\\"error\\\": \\\"Failed to update location\\\"})",
+                        "schema": {
+                            "type": "object"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Deletes the location. When it was the default, the oldest remaining location becomes the default.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "weather"
+                ],
+                "summary": "Delete a saved weather location",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Location ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Location deleted",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.MessageResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "object"
+                        }
+                    },
+                    "404": {
+                        "description": "Location not found or access denied",
+                        "schema": {
+                            "type": "object"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error (e.g., {\\\"error\\\": \\\"Failed to delete location\\\"})",
+                        "schema": {
+                            "type": "object"
+                        }
+                    }
+                }
+            }
+        },
+        "/weather/me": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Fetches the current weather of all saved locations of the user in parallel, in the units and language of the user's weather preferences.\nLocations whose weather could not be fetched have an error. The temperatures are combined with the WEATHER_AGGREGATION strategy. The X-Cache header is HIT when all sources of all locations came from the cache, MISS when none did.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "weather"
+                ],
+                "summary": "Get the current weather of all saved locations",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.PersonalWeatherResponse"
+                        },
+                        "headers": {
+                            "X-Cache": {
+                                "type": "string",
+                                "description": "HIT, MISS or PARTIAL"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "object"
+                        }
+                    },
+                    "404": {
+                        "description": "User not found",
+                        "schema": {
+                            "type": "object"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error (e.g., {\\\"error\\\": \\\"Failed to retrieve locations\\\"})",
+                        "schema": {
+                            "type": "object"
+                        }
+                    }
+                }
+            }
+        }
+    },
+    "definitions": {
+        "handlers.AccountDeletionResponse": {
+            "type": "object",
+            "properties": {
+                "deletionScheduledAt": {
+                    "description": "Null when the account was deleted right away",
+                    "type": "string"
+                },
+                "message": {
+                    "type": "string",
+                    "example": "Account deletion scheduled"
+                }
+            }
+        },
+        "handlers.AccountExport": {
+            "type": "object",
+            "properties": {
+                "accessTokens": {
+                    "description": "Without the tokens themselves, which are not stored",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.PersonalAccessToken"
+                    }
+                },
+                "auditEvents": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.AuditEvent"
+                    }
+                },
+                "exportedAt": {
+                    "type": "string"
+                },
+                "identities": {
+                    "description": "Linked OpenID Connect accounts",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.UserIdentity"
+                    }
+                },
+                "knowledgeLinks": {
+                    "description": "Trashed links included, see deletedAt",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.KnowledgeLink"
+                    }
+                },
+                "noteRevisions": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.NoteRevision"
+                    }
+                },
+                "notebooks": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.Notebook"
+                    }
+                },
+                "notes": {
+                    "description": "Trashed notes included, see deletedAt",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.Note"
+                    }
+                },
+                "profile": {
+                    "$ref": "#/definitions/models.User"
+                },
+                "sessions": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.Session"
+                    }
+                },
+                "tags": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.Tag"
+                    }
+                },
+                "weatherLocations": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.WeatherLocation"
+                    }
+                }
+            }
+        },
+        "handlers.ChangePasswordInput": {
+            "type": "object",
+            "required": [
+                "currentPassword",
+                "newPassword"
+            ],
+            "properties": {
+                "currentPassword": {
+                    "type": "string"
+                },
+                "newPassword": {
+                    "type": "string",
+                    "minLength": 6
+                },
+                "revokeOtherSessions": {
+                    "description": "Sign out all other devices, true when omitted",
+                    "type": "boolean",
+                    "example": true
+                }
+            }
+        },
+        "handlers.CreateAccessTokenInput": {
+            "type": "object",
+            "required": [
+                "name",
+                "scopes"
+            ],
+            "properties": {
+                "expiresInDays": {
+                    "description": "Never expires when omitted",
+                    "type": "integer",
+                    "maximum": 3650,
+                    "minimum": 1,
+                    "example": 90
+                },
+                "name": {
+                    "type": "string",
+                    "maxLength": 100,
+                    "example": "CI note sync"
+                },
+                "scopes": {
+                    "type": "array",
+                    "minItems": 1,
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "notes:read",
+                        "notes:write"
+                    ]
+                }
+            }
+        },
+        "handlers.CreateKnowledgeLinkInput": {
+            "type": "object",
+            "required": [
+                "url"
+            ],
+            "properties": {
+                "title": {
+                    "type": "string"
+                },
+                "url": {
+                    "type": "string"
+                }
+            }
+        },
+        "handlers.CreateNoteInput": {
+            "type": "object",
+            "required": [
+                "content"
+            ],
+            "properties": {
+                "content": {
+                    "type": "string"
+                },
+                "language": {
+                    "type": "string",
+                    "enum": [
+                        "russian",
+                        "english",
+                        "simple"
+                    ],
+                    "example": "russian"
+                },
+                "notebookId": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "tags": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "work",
+                        "ideas"
+                    ]
+                },
+                "title": {
+                    "type": "string"
+                }
+            }
+        },
+        "handlers.CreatedAccessTokenResponse": {
+            "type": "object",
+            "properties": {
+                "accessToken": {
+                    "$ref": "#/definitions/models.PersonalAccessToken"
+                },
+                "token": {
+                    "description": "Send as \"Authorization: Bearer \u003ctoken\u003e\"",
+                    "type": "string",
+                    "example": "org_pat_k3J9mD0cX2kq5Jx0mVb2n8Yk1s9cW4tZr7LhP3dG6fA0e"
+                }
+            }
+        },
+        "handlers.DeleteAccountInput": {
+            "type": "object",
+            "required": [
+                "password"
+            ],
+            "properties": {
+                "password": {
+                    "type": "string"
+                }
+            }
+        },
+        "handlers.DisableTwoFactorInput": {
+            "type": "object",
+            "required": [
+                "code",
+                "password"
+            ],
+            "properties": {
+                "code": {
+                    "description": "TOTP or recovery code",
+                    "type": "string",
+                    "example": "123456"
+                },
+                "password": {
+                    "type": "string",
+                    "example": "password123"
+                }
+            }
+        },
+        "handlers.EmailTokenInput": {
+            "type": "object",
+            "required": [
+                "token"
+            ],
+            "properties": {
+                "token": {
+                    "type": "string",
+                    "example": "q5Jx0mVb2n8Yk1s9cW4tZr7LhP3dG6fA0eU2iO8yTnM"
+                }
+            }
+        },
+        "handlers.ForgotPasswordInput": {
+            "type": "object",
+            "required": [
+                "email"
+            ],
+            "properties": {
+                "email": {
+                    "type": "string",
+                    "example": "user@example.com"
+                }
+            }
+        },
+        "handlers.LocationWeather": {
+            "type": "object",
+            "properties": {
+                "error": {
+                    "type": "string",
+                    "example": "Failed to fetch weather data from any source"
+                },
+                "location": {
+                    "$ref": "#/definitions/models.WeatherLocation"
+                },
+                "weather": {
+                    "$ref": "#/definitions/models.WeatherResponse"
+                }
+            }
+        },
+        "handlers.LoginInput": {
+            "type": "object",
+            "required": [
+                "email",
+                "password"
+            ],
+            "properties": {
+                "email": {
+                    "type": "string",
+                    "example": "user@example.com"
+                },
+                "password": {
+                    "type": "string",
+                    "example": "password123"
+                }
+            }
+        },
+        "handlers.MergeTagsInput": {
+            "type": "object",
+            "required": [
+                "targetId"
+            ],
+            "properties": {
+                "targetId": {
+                    "type": "integer",
+                    "example": 2
+                }
+            }
+        },
+        "handlers.MessageResponse": {
+            "type": "object",
+            "properties": {
+                "message": {
+                    "type": "string",
+                    "example": "Успешное выполнение"
+                }
+            }
+        },
+        "handlers.MoveNotesInput": {
+            "type": "object",
+            "required": [
+                "noteIds"
+            ],
+            "properties": {
+                "noteIds": {
+                    "type": "array",
+                    "minItems": 1,
+                    "items": {
+                        "type": "integer"
+                    },
+                    "example": [
+                        1,
+                        2,
+                        3
+                    ]
+                },
+                "notebookId": {
+                    "description": "null moves the notes out of any notebook",
+                    "type": "integer",
+                    "example": 4
+                }
+            }
+        },
+        "handlers.NoteGraphEdge": {
+            "type": "object",
+            "properties": {
+                "source": {
+                    "type": "integer",
+                    "example": 3
+                },
+                "target": {
+                    "type": "integer",
+                    "example": 7
+                }
+            }
+        },
+        "handlers.NoteGraphNode": {
+            "type": "object",
+            "properties": {
+                "id": {
+                    "type": "integer",
+                    "example": 3
+                },
+                "title": {
+                    "type": "string",
+                    "example": "Daily log"
+                }
+            }
+        },
+        "handlers.NoteGraphResponse": {
+            "type": "object",
+            "properties": {
+                "edges": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/handlers.NoteGraphEdge"
+                    }
+                },
+                "nodes": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/handlers.NoteGraphNode"
+                    }
+                }
+            }
+        },
+        "handlers.NoteImportError": {
+            "type": "object",
+            "properties": {
+                "error": {
+                    "type": "string",
+                    "example": "invalid front matter"
+                },
+                "source": {
+                    "description": "File or entry the note came from",
+                    "type": "string",
+                    "example": "Work/Meeting.md"
+                }
+            }
+        },
+        "handlers.NoteImportResponse": {
+            "type": "object",
+            "properties": {
+                "created": {
+                    "type": "integer",
+                    "example": 10
+                },
+                "errors": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/handlers.NoteImportError"
+                    }
+                },
+                "failed": {
+                    "type": "integer",
+                    "example": 0
+                },
+                "skipped": {
+                    "description": "Notes identical to the stored version",
+                    "type": "integer",
+                    "example": 1
+                },
+                "updated": {
+                    "type": "integer",
+                    "example": 2
+                }
+            }
+        },
+        "handlers.NoteReference": {
+            "type": "object",
+            "properties": {
+                "id": {
+                    "type": "integer",
+                    "example": 7
+                },
+                "title": {
+                    "type": "string",
+                    "example": "Project ideas"
+                },
+                "updatedAt": {
+                    "type": "string"
+                }
+            }
+        },
+        "handlers.NoteRevisionDiffResponse": {
+            "type": "object",
+            "properties": {
+                "diff": {
+                    "type": "string",
+                    "example": "--- revision 3\n+++ current\n@@ -1 +1 @@\n-old line\n+new line\n"
+                },
+                "noteId": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "revision": {
+                    "type": "integer",
+                    "example": 3
+                },
+                "titleChanged": {
+                    "type": "boolean",
+                    "example": true
+                },
+                "titleFrom": {
+                    "type": "string",
+                    "example": "Old title"
+                },
+                "titleTo": {
+                    "type": "string",
+                    "example": "New title"
+                }
+            }
+        },
+        "handlers.NotebookInput": {
+            "type": "object",
+            "required": [
+                "name"
+            ],
+            "properties": {
+                "name": {
+                    "type": "string",
+                    "maxLength": 255,
+                    "example": "Work"
+                },
+                "parentId": {
+                    "description": "null for a top-level notebook",
+                    "type": "integer",
+                    "example": 1
+                }
+            }
+        },
+        "handlers.OIDCExchangeInput": {
+            "type": "object",
+            "required": [
+                "code"
+            ],
+            "properties": {
+                "code": {
+                    "type": "string",
+                    "example": "q5Jx0mVb2n8Yk1s9cW4tZr7LhP3dG6fA0eU2iO8yTnM"
+                }
+            }
+        },
+        "handlers.OIDCProviderResponse": {
+            "type": "object",
+            "properties": {
+                "displayName": {
+                    "type": "string",
+                    "example": "Company SSO"
+                },
+                "loginUrl": {
+                    "type": "string",
+                    "example": "/api/auth/oidc/company/login"
+                },
+                "name": {
+                    "type": "string",
+                    "example": "company"
+                }
+            }
+        },
+        "handlers.PaginatedAuditEventsResponse": {
+            "type": "object",
+            "properties": {
+                "events": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.AuditEvent"
+                    }
+                },
+                "totalCount": {
+                    "type": "integer",
+                    "example": 42
+                }
+            }
+        },
+        "handlers.PaginatedKnowledgeLinksResponse": {
+            "type": "object",
+            "properties": {
+                "links": {
+                    "description": "Массив ссылок",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.KnowledgeLink"
+                    }
+                },
+                "nextCursor": {
+                    "description": "Курсор следующей страницы, null если страниц больше нет",
+                    "type": "string",
+                    "example": "eyJzIjoiY3JlYXRlZEF0In0"
+                },
+                "totalCount": {
+                    "description": "Общее количество ссылок; не возвращается при пагинации по курсору",
+                    "type": "integer",
+                    "example": 42
+                }
+            }
+        },
+        "handlers.PaginatedNotesResponse": {
+            "type": "object",
+            "properties": {
+                "nextCursor": {
+                    "description": "Курсор следующей страницы, null если страниц больше нет",
+                    "type": "string",
+                    "example": "eyJzIjoiY3JlYXRlZEF0In0"
+                },
+                "notes": {
+                    "description": "Массив заметок",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.Note"
+                    }
+                },
+                "tagCounts": {
+                    "description": "Количество заметок по каждому тегу среди подходящих под критерии; при пагинации по курсору только на первой странице",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.TagUsage"
+                    }
+                },
+                "totalCount": {
+                    "description": "Общее количество заметок, подходящих под критерии (до пагинации); не возвращается при пагинации по курсору",
+                    "type": "integer",
+                    "example": 100
+                }
+            }
+        },
+        "handlers.PaginatedUsersResponse": {
+            "type": "object",
+            "properties": {
+                "totalCount": {
+                    "description": "Users matching the filters, before pagination",
+                    "type": "integer",
+                    "example": 42
+                },
+                "users": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.User"
+                    }
+                }
+            }
+        },
+        "handlers.PersonalWeatherResponse": {
+            "type": "object",
+            "properties": {
+                "language": {
+                    "type": "string",
+                    "example": "ru"
+                },
+                "locations": {
+                    "description": "The default location first, then the oldest first",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/handlers.LocationWeather"
+                    }
+                },
+                "units": {
+                    "type": "string",
+                    "example": "metric"
+                }
+            }
+        },
+        "handlers.RecoveryCodesResponse": {
+            "type": "object",
+            "properties": {
+                "recoveryCodes": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "k3j9d-x7q2m",
+                        "p0w8e-r5t1y"
+                    ]
+                }
+            }
+        },
+        "handlers.RefreshTokenInput": {
+            "type": "object",
+            "required": [
+                "refreshToken"
+            ],
+            "properties": {
+                "refreshToken": {
+                    "type": "string",
+                    "example": "q3J9mD0cX2k..."
+                }
+            }
+        },
+        "handlers.RegisterInput": {
+            "type": "object",
+            "required": [
+                "email",
+                "password"
+            ],
+            "properties": {
+                "age": {
+                    "type": "integer",
+                    "example": 30
+                },
+                "contacts": {
+                    "type": "string",
+                    "example": "+1234567890"
+                },
+                "email": {
+                    "type": "string",
+                    "example": "user@example.com"
+                },
+                "fullname": {
+                    "type": "string",
+                    "example": "John Doe"
+                },
+                "locale": {
+                    "description": "Language of emails, taken from Accept-Language when omitted",
+                    "type": "string",
+                    "enum": [
+                        "ru",
+                        "en"
+                    ],
+                    "example": "ru"
+                },
+                "password": {
+                    "type": "string",
+                    "minLength": 6,
+                    "example": "password123"
+                }
+            }
+        },
+        "handlers.ResetPasswordInput": {
+            "type": "object",
+            "required": [
+                "newPassword",
+                "token"
+            ],
+            "properties": {
+                "newPassword": {
+                    "type": "string",
+                    "minLength": 6,
+                    "example": "newpassword123"
+                },
+                "token": {
+                    "type": "string",
+                    "example": "q5Jx0mVb2n8Yk1s9cW4tZr7LhP3dG6fA0eU2iO8yTnM"
+                }
+            }
+        },
+        "handlers.RevisionPolicyInput": {
+            "type": "object",
+            "required": [
+                "revisionLimit",
+                "revisionMaxAgeDays"
+            ],
+            "properties": {
+                "revisionLimit": {
+                    "description": "Max revisions kept per note, 0 = unlimited",
+                    "type": "integer",
+                    "minimum": 0,
+                    "example": 50
+                },
+                "revisionMaxAgeDays": {
+                    "description": "Max age of revisions in days, 0 = unlimited",
+                    "type": "integer",
+                    "minimum": 0,
+                    "example": 90
+                }
+            }
+        },
+        "handlers.TagInput": {
+            "type": "object",
+            "required": [
+                "name"
+            ],
+            "properties": {
+                "name": {
+                    "type": "string",
+                    "maxLength": 64,
+                    "example": "work"
+                }
+            }
+        },
+        "handlers.TokenPairResponse": {
+            "type": "object",
+            "properties": {
+                "expiresIn": {
+                    "description": "Access token lifetime in seconds",
+                    "type": "integer",
+                    "example": 900
+                },
+                "refreshToken": {
+                    "description": "Opaque, single use: every refresh returns a new one",
+                    "type": "string",
+                    "example": "q3J9mD0cX2k..."
+                },
+                "token": {
+                    "type": "string",
+                    "example": "eyJhbGciOiJIUzI1NiIsInR5cCI6IkpXVCJ9..."
+                }
+            }
+        },
+        "handlers.TrashResponse": {
+            "type": "object",
+            "properties": {
+                "knowledgeLinks": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.KnowledgeLink"
+                    }
+                },
+                "notes": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.Note"
+                    }
+                },
+                "retentionDays": {
+                    "description": "Items are purged automatically after this many days",
+                    "type": "integer",
+                    "example": 30
+                }
+            }
+        },
+        "handlers.TwoFactorCodeInput": {
+            "type": "object",
+            "required": [
+                "code"
+            ],
+            "properties": {
+                "code": {
+                    "type": "string",
+                    "example": "123456"
+                }
+            }
+        },
+        "handlers.TwoFactorLoginInput": {
+            "type": "object",
+            "required": [
+                "challengeToken",
+                "code"
+            ],
+            "properties": {
+                "challengeToken": {
+                    "type": "string"
+                },
+                "code": {
+                    "description": "TOTP or recovery code",
+                    "type": "string",
+                    "example": "123456"
+                }
+            }
+        },
+        "handlers.TwoFactorSetupResponse": {
+            "type": "object",
+            "properties": {
+                "otpauthUri": {
+                    "type": "string",
+                    "example": "otpauth://totp/Organizer:user@example.com?issuer=Organizer\u0026secret=JBSWY3DPEHPK3PXP"
+                },
+                "qrCode": {
+                    "description": "PNG with the otpauth URI",
+                    "type": "string",
+                    "example": "data:image/png;base64,iVBORw0KGgo..."
+                },
+                "secret": {
+                    "type": "string",
+                    "example": "JBSWY3DPEHPK3PXP"
+                }
+            }
+        },
+        "handlers.UnresolvedNoteLink": {
+            "type": "object",
+            "properties": {
+                "sourceNoteId": {
+                    "type": "integer",
+                    "example": 3
+                },
+                "sourceTitle": {
+                    "type": "string",
+                    "example": "Daily log"
+                },
+                "target": {
+                    "type": "string",
+                    "example": "Meeting notes"
+                }
+            }
+        },
+        "handlers.UpdateNoteInput": {
+            "type": "object",
+            "properties": {
+                "content": {
+                    "type": "string"
+                },
+                "language": {
+                    "type": "string",
+                    "enum": [
+                        "russian",
+                        "english",
+                        "simple"
+                    ],
+                    "example": "english"
+                },
+                "rewriteLinks": {
+                    "description": "On rename, rewrite [[Old Title]] references in other notes to the new title. References are kept\nwhen the new title contains [, ], | or a line break, or starts with id:",
+                    "type": "boolean",
+                    "example": true
+                },
+                "tags": {
+                    "description": "Omit to keep tags, [] to remove all",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "work"
+                    ]
+                },
+                "title": {
+                    "type": "string"
+                }
+            }
+        },
+        "handlers.UpdateUserProfileInput": {
+            "type": "object",
+            "properties": {
+                "age": {
+                    "type": "integer"
+                },
+                "contacts": {
+                    "type": "string"
+                },
+                "fullname": {
+                    "type": "string"
+                },
+                "locale": {
+                    "description": "Language of emails, unchanged when omitted",
+                    "type": "string",
+                    "enum": [
+                        "ru",
+                        "en"
+                    ],
+                    "example": "en"
+                }
+            }
+        },
+        "handlers.UpdateUserRoleInput": {
+            "type": "object",
+            "required": [
+                "role"
+            ],
+            "properties": {
+                "role": {
+                    "type": "string",
+                    "enum": [
+                        "user",
+                        "admin"
+                    ],
+                    "example": "admin"
+                }
+            }
+        },
+        "handlers.UserAuthResponse": {
+            "type": "object",
+            "properties": {
+                "expiresIn": {
+                    "description": "Access token lifetime in seconds",
+                    "type": "integer",
+                    "example": 900
+                },
+                "refreshToken": {
+                    "description": "Opaque, single use: every refresh returns a new one",
+                    "type": "string",
+                    "example": "q3J9mD0cX2k..."
+                },
+                "token": {
+                    "type": "string",
+                    "example": "eyJhbGciOiJIUzI1NiIsInR5cCI6IkpXVCJ9..."
+                },
+                "user": {
+                    "description": "User model without PasswordHash",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.User"
+                        }
+                    ]
+                }
+            }
+        },
+        "handlers.UserStorageUsage": {
+            "type": "object",
+            "properties": {
+                "email": {
+                    "type": "string",
+                    "example": "user@example.com"
+                },
+                "links": {
+                    "description": "Knowledge links outside the trash",
+                    "type": "integer",
+                    "example": 35
+                },
+                "linksBytes": {
+                    "type": "integer",
+                    "example": 2048
+                },
+                "notes": {
+                    "description": "Notes outside the trash",
+                    "type": "integer",
+                    "example": 120
+                },
+                "notesBytes": {
+                    "description": "Size of titles and contents",
+                    "type": "integer",
+                    "example": 5242
+                },
+                "revisions": {
+                    "type": "integer",
+                    "example": 450
+                },
+                "revisionsBytes": {
+                    "type": "integer",
+                    "example": 20480
+                },
+                "totalBytes": {
+                    "type": "integer",
+                    "example": 27770
+                },
+                "trashedLinks": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "trashedNotes": {
+                    "type": "integer",
+                    "example": 3
+                },
+                "userId": {
+                    "type": "integer",
+                    "example": 7
+                }
+            }
+        },
+        "handlers.WeatherLocationInput": {
+            "type": "object",
+            "required": [
+                "latitude",
+                "longitude",
+                "name"
+            ],
+            "properties": {
+                "isDefault": {
+                    "description": "The first location is always the default",
+                    "type": "boolean"
+                },
+                "latitude": {
+                    "type": "number",
+                    "maximum": 90,
+                    "minimum": -90,
+                    "example": 55.7558
+                },
+                "longitude": {
+                    "type": "number",
+                    "maximum": 180,
+                    "minimum": -180,
+                    "example": 37.6173
+                },
+                "name": {
+                    "type": "string",
+                    "maxLength": 100,
+                    "example": "Home"
+                }
+            }
+        },
+        "handlers.WeatherPreferencesInput": {
+            "type": "object",
+            "required": [
+                "language",
+                "units"
+            ],
+            "properties": {
+                "language": {
+                    "type": "string",
+                    "enum": [
+                        "ru",
+                        "en"
+                    ],
+                    "example": "ru"
+                },
+                "units": {
+                    "type": "string",
+                    "enum": [
+                        "metric",
+                        "imperial"
+                    ],
+                    "example": "metric"
+                }
+            }
+        },
+        "models.AggregatedDailyForecast": {
+            "type": "object",
+            "properties": {
+                "date": {
+                    "description": "Local date at the place",
+                    "type": "string",
+                    "example": "2026-10-18"
+                },
+                "precipitationProbability": {
+                    "description": "Percent, null when the provider does not report it",
+                    "type": "number",
+                    "example": 40
+                },
+                "sources": {
+                    "description": "Number of sources with a forecast for the date",
+                    "type": "integer",
+                    "example": 3
+                },
+                "tempMax": {
+                    "type": "number",
+                    "example": 13.2
+                },
+                "tempMin": {
+                    "description": "In the units of the response",
+                    "type": "number",
+                    "example": 6.5
+                }
+            }
+        },
+        "models.AggregatedHourlyForecast": {
+            "type": "object",
+            "properties": {
+                "precipitationProbability": {
+                    "type": "number",
+                    "example": 20
+                },
+                "sources": {
+                    "type": "integer",
+                    "example": 2
+                },
+                "temp": {
+                    "description": "In the units of the response",
+                    "type": "number",
+                    "example": 9.8
+                },
+                "time": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.AuditEvent": {
+            "type": "object",
+            "properties": {
+                "account": {
+                    "description": "Email the event refers to",
+                    "type": "string",
+                    "example": "user@example.com"
+                },
+                "createdAt": {
+                    "type": "string"
+                },
+                "details": {
+                    "type": "object",
+                    "additionalProperties": true
+                },
+                "event": {
+                    "type": "string",
+                    "example": "account.locked"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "ip": {
+                    "type": "string",
+                    "example": "203.0.113.7"
+                },
+                "userAgent": {
+                    "type": "string"
+                },
+                "userId": {
+                    "description": "Null when the account does not exist",
+                    "type": "integer"
+                }
+            }
+        },
+        "models.DailyForecast": {
+            "type": "object",
+            "properties": {
+                "date": {
+                    "description": "Local date at the place",
+                    "type": "string",
+                    "example": "2026-10-18"
+                },
+                "precipitationProbability": {
+                    "description": "Percent, null when the provider does not report it",
+                    "type": "number",
+                    "example": 40
+                },
+                "tempMax": {
+                    "type": "number",
+                    "example": 13.2
+                },
+                "tempMin": {
+                    "description": "In the units of the response",
+                    "type": "number",
+                    "example": 6.5
+                }
+            }
+        },
+        "models.ForecastResponse": {
+            "type": "object",
+            "properties": {
+                "aggregation": {
+                    "description": "Combination of the temperatures of the sources, like for current weather",
+                    "type": "string",
+                    "example": "median"
+                },
+                "city": {
+                    "type": "string",
+                    "example": "London"
+                },
+                "daily": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.AggregatedDailyForecast"
+                    }
+                },
+                "days": {
+                    "description": "Days in daily, fewer than requested when the sources do not forecast that far",
+                    "type": "integer",
+                    "example": 3
+                },
+                "failures": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.ProviderFailure"
+                    }
+                },
+                "hourly": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.AggregatedHourlyForecast"
+                    }
+                },
+                "sources": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.SourceForecast"
+                    }
+                },
+                "units": {
+                    "type": "string",
+                    "example": "metric"
+                }
+            }
+        },
+        "models.HourlyForecast": {
+            "type": "object",
+            "properties": {
+                "precipitationProbability": {
+                    "type": "number",
+                    "example": 20
+                },
+                "temp": {
+                    "description": "In the units of the response",
+                    "type": "number",
+                    "example": 9.8
+                },
+                "time": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.KnowledgeLink": {
+            "type": "object",
+            "properties": {
+                "createdAt": {
+                    "type": "string"
+                },
+                "deletedAt": {
+                    "description": "Set when moved to trash",
+                    "type": "string",
+                    "format": "date-time"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "title": {
+                    "type": "string"
+                },
+                "updatedAt": {
+                    "type": "string"
+                },
+                "url": {
+                    "type": "string"
+                },
+                "userId": {
+                    "type": "integer"
+                }
+            }
+        },
+        "models.Note": {
+            "type": "object",
+            "properties": {
+                "content": {
+                    "type": "string"
+                },
+                "createdAt": {
+                    "description": "Indexes back the sort options of GetNotes",
+                    "type": "string"
+                },
+                "date": {
+                    "type": "string"
+                },
+                "deletedAt": {
+                    "description": "Set when moved to trash",
+                    "type": "string",
+                    "format": "date-time"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "language": {
+                    "description": "Text search configuration",
+                    "type": "string"
+                },
+                "notebookId": {
+                    "description": "nil for notes outside notebooks",
+                    "type": "integer"
+                },
+                "rank": {
+                    "description": "Filled only by search queries (see handlers.GetNotes)",
+                    "type": "number"
+                },
+                "snippet": {
                     "type": "string"
                 },
-                "newPassword": {
-                    "type": "string",
-                    "minLength": 6
-                }
-            }
-        },
-        "handlers.CreateKnowledgeLinkInput": {
-            "type": "object",
-            "required": [
-                "url"
-            ],
-            "properties": {
+                "tags": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.Tag"
+                    }
+                },
                 "title": {
                     "type": "string"
                 },
-                "url": {
+                "titleHighlight": {
+                    "type": "string"
+                },
+                "updatedAt": {
                     "type": "string"
+                },
+                "userId": {
+                    "description": "Foreign key",
+                    "type": "integer"
+                },
+                "version": {
+                    "description": "Incremented on every update, used for ETag / If-Match",
+                    "type": "integer"
                 }
             }
         },
-        "handlers.CreateNoteInput": {
+        "models.NoteRevision": {
             "type": "object",
-            "required": [
-                "content"
-            ],
             "properties": {
                 "content": {
-                    "description": "Content is required",
                     "type": "string"
                 },
+                "createdAt": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "noteId": {
+                    "type": "integer"
+                },
+                "revision": {
+                    "description": "Sequential number per note",
+                    "type": "integer"
+                },
                 "title": {
                     "type": "string"
                 }
             }
         },
-        "handlers.LoginInput": {
+        "models.Notebook": {
             "type": "object",
-            "required": [
-                "email",
-                "password"
-            ],
             "properties": {
-                "email": {
-                    "type": "string",
-                    "example": "user@example.com"
+                "createdAt": {
+                    "type": "string"
                 },
-                "password": {
+                "deletedAt": {
+                    "description": "Set when deleted with its notes to the trash, so restored notes get their notebook back",
                     "type": "string",
-                    "example": "password123"
+                    "format": "date-time"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "noteCount": {
+                    "description": "Filled by handlers.GetNotebooks",
+                    "type": "integer"
+                },
+                "parentId": {
+                    "description": "nil for top-level notebooks",
+                    "type": "integer"
+                },
+                "updatedAt": {
+                    "type": "string"
+                },
+                "userId": {
+                    "type": "integer"
                 }
             }
         },
-        "handlers.PaginatedNotesResponse": {
+        "models.PersonalAccessToken": {
             "type": "object",
             "properties": {
-                "notes": {
-                    "description": "Массив заметок",
+                "createdAt": {
+                    "type": "string"
+                },
+                "expiresAt": {
+                    "description": "Never expires when null",
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "lastUsedAt": {
+                    "description": "Updated at most once a minute by AuthMiddleware",
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string",
+                    "example": "CI note sync"
+                },
+                "scopes": {
                     "type": "array",
                     "items": {
-                        "$ref": "#/definitions/models.Note"
-                    }
+                        "type": "string"
+                    },
+                    "example": [
+                        "notes:read",
+                        "notes:write"
+                    ]
                 },
-                "totalCount": {
-                    "description": "Общее количество заметок, подходящих под критерии (до пагинации)",
-                    "type": "integer",
-                    "example": 100
+                "tokenPrefix": {
+                    "description": "First characters of the token, to recognize it",
+                    "type": "string",
+                    "example": "org_pat_k3J9"
                 }
             }
         },
-        "handlers.RegisterInput": {
+        "models.ProviderFailure": {
             "type": "object",
-            "required": [
-                "email",
-                "password"
-            ],
             "properties": {
-                "age": {
-                    "type": "integer",
-                    "example": 30
-                },
-                "contacts": {
+                "kind": {
+                    "description": "request, status, decode or not_found",
                     "type": "string",
-                    "example": "+1234567890"
+                    "example": "status"
                 },
-                "email": {
+                "message": {
+                    "description": "Details are only logged on the server",
                     "type": "string",
-                    "example": "user@example.com"
+                    "example": "provider rate limited"
                 },
-                "fullname": {
+                "provider": {
                     "type": "string",
-                    "example": "John Doe"
+                    "example": "weatherapi"
                 },
-                "password": {
-                    "type": "string",
-                    "minLength": 6,
-                    "example": "password123"
+                "statusCode": {
+                    "description": "HTTP status of the provider for the status kind",
+                    "type": "integer",
+                    "example": 401
                 }
             }
         },
-        "handlers.UpdateNoteInput": {
+        "models.Session": {
             "type": "object",
             "properties": {
-                "content": {
+                "createdAt": {
                     "type": "string"
                 },
-                "title": {
+                "current": {
+                    "description": "Set for the session of the request in session listings",
+                    "type": "boolean"
+                },
+                "expiresAt": {
+                    "description": "Refresh token expiry, extended on rotation",
                     "type": "string"
-                }
-            }
-        },
-        "handlers.UpdateUserProfileInput": {
-            "type": "object",
-            "properties": {
-                "age": {
+                },
+                "id": {
                     "type": "integer"
                 },
-                "contacts": {
+                "ip": {
+                    "description": "Address of the last login or refresh",
+                    "type": "string",
+                    "example": "203.0.113.7"
+                },
+                "lastSeenAt": {
+                    "description": "Updated at most once a minute by AuthMiddleware",
                     "type": "string"
                 },
-                "fullname": {
+                "revokedAt": {
+                    "type": "string"
+                },
+                "updatedAt": {
                     "type": "string"
+                },
+                "userAgent": {
+                    "type": "string",
+                    "example": "Mozilla/5.0 (X11; Linux x86_64) Firefox/128.0"
                 }
             }
         },
-        "handlers.UserAuthResponse": {
+        "models.SourceForecast": {
             "type": "object",
             "properties": {
-                "token": {
+                "daily": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.DailyForecast"
+                    }
+                },
+                "fetchedAt": {
+                    "type": "string"
+                },
+                "hourly": {
+                    "description": "Only when requested; some providers have 3-hour steps",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.HourlyForecast"
+                    }
+                },
+                "name": {
                     "type": "string",
-                    "example": "eyJhbGciOiJIUzI1NiIsInR5cCI6IkpXVCJ9..."
+                    "example": "Open-Meteo"
                 },
-                "user": {
-                    "description": "User model without PasswordHash",
-                    "allOf": [
-                        {
-                            "$ref": "#/definitions/models.User"
-                        }
-                    ]
+                "provider": {
+                    "type": "string",
+                    "example": "openmeteo"
                 }
             }
         },
-        "models.KnowledgeLink": {
+        "models.Tag": {
             "type": "object",
             "properties": {
                 "createdAt": {
@@ -961,44 +6101,27 @@ const docTemplate = `{
                 "id": {
                     "type": "integer"
                 },
-                "title": {
+                "name": {
                     "type": "string"
                 },
                 "updatedAt": {
                     "type": "string"
                 },
-                "url": {
-                    "type": "string"
-                },
                 "userId": {
                     "type": "integer"
                 }
             }
         },
-        "models.Note": {
+        "models.TagUsage": {
             "type": "object",
             "properties": {
-                "content": {
-                    "type": "string"
-                },
-                "createdAt": {
-                    "type": "string"
-                },
-                "date": {
-                    "description": "Compatibility with frontend mock",
-                    "type": "string"
-                },
                 "id": {
                     "type": "integer"
                 },
-                "title": {
-                    "type": "string"
-                },
-                "updatedAt": {
+                "name": {
                     "type": "string"
                 },
-                "userId": {
-                    "description": "Foreign key",
+                "noteCount": {
                     "type": "integer"
                 }
             }
@@ -1015,18 +6138,115 @@ const docTemplate = `{
                 "createdAt": {
                     "type": "string"
                 },
+                "deletionScheduledAt": {
+                    "description": "The account and all its data are deleted at this time unless the user cancels",
+                    "type": "string"
+                },
+                "disabledAt": {
+                    "description": "Set by an admin, disabled users cannot log in",
+                    "type": "string"
+                },
                 "email": {
                     "type": "string"
                 },
+                "emailVerified": {
+                    "type": "boolean"
+                },
                 "fullname": {
                     "type": "string"
                 },
                 "id": {
                     "type": "integer"
                 },
+                "locale": {
+                    "description": "Language of emails sent to the user (ru, en)",
+                    "type": "string",
+                    "example": "ru"
+                },
+                "revisionLimit": {
+                    "description": "Max note revisions kept per note, 0 = unlimited",
+                    "type": "integer"
+                },
+                "revisionMaxAgeDays": {
+                    "description": "Max age of note revisions in days, 0 = unlimited",
+                    "type": "integer"
+                },
+                "role": {
+                    "type": "string",
+                    "example": "user"
+                },
                 "telegramHash": {
                     "type": "string"
                 },
+                "twoFactorEnabled": {
+                    "type": "boolean"
+                },
+                "updatedAt": {
+                    "type": "string"
+                },
+                "weatherLanguage": {
+                    "description": "Language of weather descriptions (ru, en)",
+                    "type": "string",
+                    "example": "ru"
+                },
+                "weatherUnits": {
+                    "description": "Units of the personal weather dashboard (metric, imperial)",
+                    "type": "string",
+                    "example": "metric"
+                }
+            }
+        },
+        "models.UserIdentity": {
+            "type": "object",
+            "properties": {
+                "createdAt": {
+                    "type": "string"
+                },
+                "email": {
+                    "description": "Email reported by the provider at the last login",
+                    "type": "string",
+                    "example": "user@example.com"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "lastLoginAt": {
+                    "type": "string"
+                },
+                "provider": {
+                    "type": "string",
+                    "example": "company"
+                },
+                "subject": {
+                    "description": "\"sub\" claim, stable at the provider",
+                    "type": "string"
+                }
+            }
+        },
+        "models.WeatherLocation": {
+            "type": "object",
+            "properties": {
+                "createdAt": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "isDefault": {
+                    "type": "boolean"
+                },
+                "latitude": {
+                    "type": "number",
+                    "example": 55.7558
+                },
+                "longitude": {
+                    "type": "number",
+                    "example": 37.6173
+                },
+                "name": {
+                    "type": "string",
+                    "example": "Home"
+                },
                 "updatedAt": {
                     "type": "string"
                 }
@@ -1035,17 +6255,45 @@ const docTemplate = `{
         "models.WeatherResponse": {
             "type": "object",
             "properties": {
+                "aggregation": {
+                    "description": "mean, median, trimmed or weighted",
+                    "type": "string",
+                    "example": "median"
+                },
                 "averageTemp": {
+                    "description": "Combined temperature of the sources that are not outliers",
                     "type": "number"
                 },
                 "city": {
                     "type": "string"
                 },
+                "confidence": {
+                    "description": "From 0 to 1, lower when providers fail, are outliers or disagree",
+                    "type": "number",
+                    "example": 0.9
+                },
+                "failures": {
+                    "description": "Providers that did not answer",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.ProviderFailure"
+                    }
+                },
                 "sources": {
                     "type": "array",
                     "items": {
                         "$ref": "#/definitions/models.WeatherSource"
                     }
+                },
+                "spread": {
+                    "description": "Standard deviation of the combined sources",
+                    "type": "number",
+                    "example": 0.8
+                },
+                "units": {
+                    "description": "metric (°C) or imperial (°F)",
+                    "type": "string",
+                    "example": "metric"
                 }
             }
         },
@@ -1055,11 +6303,24 @@ const docTemplate = `{
                 "description": {
                     "type": "string"
                 },
+                "fetchedAt": {
+                    "description": "When the provider was asked, earlier than the request for cached answers",
+                    "type": "string"
+                },
                 "name": {
                     "type": "string"
                 },
+                "outlier": {
+                    "description": "Too far from the other sources, not part of averageTemp",
+                    "type": "boolean"
+                },
+                "provider": {
+                    "description": "Provider ID, as in WEATHER_PROVIDERS",
+                    "type": "string",
+                    "example": "openmeteo"
+                },
                 "temp": {
-                    "description": "Temperature in Celsius",
+                    "description": "Temperature in the units of the response",
                     "type": "number"
                 }
             }
@@ -0,0 +1,157 @@
+package handlers
+
+import (
+	"net/http"
+	"organizer-backend/config"
+	"organizer-backend/models"
+	"organizer-backend/utils"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+type CreateAccessTokenInput struct {
+	Name          string   `json:"name" binding:"required,max=100" example:"CI note sync"`
+	Scopes        []string `json:"scopes" binding:"required,min=1,dive,oneof=notes:read notes:write links:read links:write profile:read" example:"notes:read,notes:write"`
+	ExpiresInDays *int     `json:"expiresInDays" binding:"omitempty,min=1,max=3650" example:"90"` // Never expires when omitted
+}
+
+// CreatedAccessTokenResponse contains the new token. It is shown only once.
+type CreatedAccessTokenResponse struct {
+	Token       string                     `json:"token" example:"org_pat_k3J9mD0cX2kq5Jx0mVb2n8Yk1s9cW4tZr7LhP3dG6fA0e"` // Send as "Authorization: Bearer <token>"
+	AccessToken models.PersonalAccessToken `json:"accessToken"`
+}
+
+// CreateAccessToken godoc
+// @Summary Create a personal access token
+// @Description Creates a named token for scripts and integrations. It is sent like a JWT in the Authorization header, but works only for the granted scopes:
+// @Description notes:read, notes:write (notes, notebooks, tags, trash), links:read, links:write (knowledge base) and profile:read.
+// @Description The token is returned only in this response.
+// @Tags users
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param token body CreateAccessTokenInput true "Token name, scopes and optional lifetime"
+// @Success 201 {object} handlers.CreatedAccessTokenResponse
+// @Failure 400 {object} object "Invalid input (e.g., unknown scope)"
+// @Failure 401 {object} object "Unauthorized"
+// @Failure 403 {object} object "Tokens cannot be managed with a personal access token"
+// @Failure 409 {object} object "A token with this name already exists"
+// @Failure 500 {object} object "Internal server error (e.g., {\"error\": \"Failed to create token\"})"
+// @Router /users/me/tokens [post]
+func CreateAccessToken(c *gin.Context) {
+	userID, _ := c.Get("userID")
+
+	var input CreateAccessTokenInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input: " + err.Error()})
+		return
+	}
+	input.Name = strings.TrimSpace(input.Name)
+	if input.Name == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Token name must not be empty"})
+		return
+	}
+
+	var count int64
+	if err := config.DB.Model(&models.PersonalAccessToken{}).Where("user_id = ? AND name = ?", userID, input.Name).Count(&count).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create token", "details": err.Error()})
+		return
+	}
+	if count > 0 {
+		c.JSON(http.StatusConflict, gin.H{"error": "A token with this name already exists"})
+		return
+	}
+
+	secret, err := utils.GenerateOpaqueToken()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create token", "details": err.Error()})
+		return
+	}
+	plain := models.PersonalAccessTokenPrefix + secret
+
+	token := models.PersonalAccessToken{
+		UserID:      userID.(uint),
+		Name:        input.Name,
+		TokenHash:   utils.HashToken(plain),
+		TokenPrefix: plain[:len(models.PersonalAccessTokenPrefix)+4],
+		Scopes:      uniqueScopes(input.Scopes),
+	}
+	if input.ExpiresInDays != nil {
+		expiresAt := time.Now().AddDate(0, 0, *input.ExpiresInDays)
+		token.ExpiresAt = &expiresAt
+	}
+	if err := config.DB.Create(&token).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create token", "details": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, CreatedAccessTokenResponse{Token: plain, AccessToken: token})
+}
+
+// uniqueScopes removes duplicate scopes and orders them like models.TokenScopes
+func uniqueScopes(scopes []string) []string {
+	result := []string{}
+	for _, scope := range models.TokenScopes {
+		for _, s := range scopes {
+			if s == scope {
+				result = append(result, scope)
+				break
+			}
+		}
+	}
+	return result
+}
+
+// GetAccessTokens godoc
+// @Summary Get personal access tokens of the current user
+// @Description Lists the tokens with their scopes, expiry and last use, newest first. The tokens themselves are not returned.
+// @Tags users
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {array} models.PersonalAccessToken
+// @Failure 401 {object} object "Unauthorized"
+// @Failure 403 {object} object "Tokens cannot be managed with a personal access token"
+// @Failure 500 {object} object "Internal server error (e.g., {\"error\": \"Failed to retrieve tokens\"})"
+// @Router /users/me/tokens [get]
+func GetAccessTokens(c *gin.Context) {
+	userID, _ := c.Get("userID")
+
+	tokens := []models.PersonalAccessToken{}
+	if err := config.DB.Where("user_id = ?", userID).Order("created_at DESC").Find(&tokens).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve tokens", "details": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, tokens)
+}
+
+// DeleteAccessToken godoc
+// @Summary Revoke a personal access token
+// @Description Deletes the token, requests made with it are rejected immediately
+// @Tags users
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Token ID"
+// @Success 200 {object} handlers.MessageResponse "Token revoked"
+// @Failure 401 {object} object "Unauthorized"
+// @Failure 403 {object} object "Tokens cannot be managed with a personal access token"
+// @Failure 404 {object} object "Token not found or access denied"
+// @Failure 500 {object} object "Internal server error (e.g., {\"error\": \"Failed to revoke token\"})"
+// @Router /users/me/tokens/{id} [delete]
+func DeleteAccessToken(c *gin.Context) {
+	userID, _ := c.Get("userID")
+
+	result := config.DB.Where("id = ? AND user_id = ?", c.Param("id"), userID).Delete(&models.PersonalAccessToken{})
+	if result.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to revoke token", "details": result.Error.Error()})
+		return
+	}
+	if result.RowsAffected == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Token not found or access denied"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Token revoked"})
+}
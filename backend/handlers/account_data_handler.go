@@ -0,0 +1,306 @@
+package handlers
+
+import (
+	"archive/zip"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"organizer-backend/config"
+	"organizer-backend/models"
+	"organizer-backend/utils"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// AccountExport contains all personal data stored about a user
+type AccountExport struct {
+	ExportedAt     time.Time                    `json:"exportedAt"`
+	Profile        models.User                  `json:"profile"`
+	Notebooks      []models.Notebook            `json:"notebooks"`
+	Tags           []models.Tag                 `json:"tags"`
+	Notes          []models.Note                `json:"notes"` // Trashed notes included, see deletedAt
+	NoteRevisions  []models.NoteRevision        `json:"noteRevisions"`
+	KnowledgeLinks []models.KnowledgeLink       `json:"knowledgeLinks"` // Trashed links included, see deletedAt
+	Sessions       []models.Session             `json:"sessions"`
+	AccessTokens   []models.PersonalAccessToken `json:"accessTokens"` // Without the tokens themselves, which are not stored
+	Identities     []models.UserIdentity        `json:"identities"`   // Linked OpenID Connect accounts
+	Locations      []models.WeatherLocation     `json:"weatherLocations"`
+	AuditEvents    []models.AuditEvent          `json:"auditEvents"`
+}
+
+type DeleteAccountInput struct {
+	Password string `json:"password" binding:"required"`
+}
+
+// AccountDeletionResponse tells when a scheduled account deletion happens
+type AccountDeletionResponse struct {
+	Message             string     `json:"message" example:"Account deletion scheduled"`
+	DeletionScheduledAt *time.Time `json:"deletionScheduledAt"` // Null when the account was deleted right away
+}
+
+var accountDeletionGraceDays = 14
+
+// StartAccountPurger runs a background job that deletes accounts whose deletion grace period
+// (ACCOUNT_DELETION_GRACE_DAYS) is over
+func StartAccountPurger() {
+	config.LoadEnv()
+	if days, err := strconv.Atoi(config.GetEnv("ACCOUNT_DELETION_GRACE_DAYS", "14")); err == nil && days >= 0 {
+		accountDeletionGraceDays = days
+	} else {
+		log.Println("Warning: invalid ACCOUNT_DELETION_GRACE_DAYS, using default of 14 days")
+	}
+
+	go func() {
+		ticker := time.NewTicker(time.Hour)
+		defer ticker.Stop()
+		for ; true; <-ticker.C {
+			purgeScheduledAccounts()
+		}
+	}()
+}
+
+func purgeScheduledAccounts() {
+	var userIDs []uint
+	if err := config.DB.Model(&models.User{}).Where("deletion_scheduled_at <= ?", time.Now()).Pluck("id", &userIDs).Error; err != nil {
+		log.Printf("Account purger: failed to find accounts to delete: %v", err)
+		return
+	}
+	for _, userID := range userIDs {
+		// The deletion may have been canceled since the IDs were read, so it is checked again
+		deleted, err := deleteAccount(config.DB.Where("deletion_scheduled_at IS NOT NULL AND deletion_scheduled_at <= ?", time.Now()), userID)
+		if err != nil {
+			log.Printf("Account purger: failed to delete user %d: %v", userID, err)
+			continue
+		}
+		if deleted {
+			log.Printf("Account purger: deleted user %d", userID)
+		}
+	}
+}
+
+// deleteAccount permanently deletes the user if it matches the conditions of the query, and reports whether
+// it did. The database cascades the deletion to all data of the user within the same statement, so the
+// conditions are checked atomically with the deletion.
+func deleteAccount(query *gorm.DB, userID uint) (bool, error) {
+	result := query.Where("id = ?", userID).Delete(&models.User{})
+	if result.Error != nil || result.RowsAffected == 0 {
+		return false, result.Error
+	}
+	record := models.AuditEvent{Event: models.AuditAccountDeleted, Details: map[string]interface{}{"userId": userID}}
+	if err := config.DB.Create(&record).Error; err != nil {
+		log.Printf("Failed to record audit event %s for user %d: %v", record.Event, userID, err)
+	}
+	return true, nil
+}
+
+// collectAccountExport loads all data of the user, including trashed notes and links
+func collectAccountExport(userID interface{}) (AccountExport, error) {
+	export := AccountExport{ExportedAt: time.Now()}
+	if err := config.DB.First(&export.Profile, userID).Error; err != nil {
+		return export, err
+	}
+
+	ownNotes := config.DB.Unscoped().Model(&models.Note{}).Select("id").Where("user_id = ?", userID)
+	queries := []*gorm.DB{
+		config.DB.Unscoped().Where("user_id = ?", userID).Order("id").Find(&export.Notebooks),
+		config.DB.Where("user_id = ?", userID).Order("id").Find(&export.Tags),
+		config.DB.Unscoped().Preload("Tags", orderTagsByName).Where("user_id = ?", userID).Order("id").Find(&export.Notes),
+		config.DB.Where("note_id IN (?)", ownNotes).Order("note_id, revision").Find(&export.NoteRevisions),
+		config.DB.Unscoped().Where("user_id = ?", userID).Order("id").Find(&export.KnowledgeLinks),
+		config.DB.Where("user_id = ?", userID).Order("id").Find(&export.Sessions),
+		config.DB.Where("user_id = ?", userID).Order("id").Find(&export.AccessTokens),
+		config.DB.Where("user_id = ?", userID).Order("id").Find(&export.Identities),
+		config.DB.Where("user_id = ?", userID).Order("id").Find(&export.Locations),
+		config.DB.Where("user_id = ?", userID).Order("id").Find(&export.AuditEvents),
+	}
+	for _, query := range queries {
+		if query.Error != nil {
+			return export, query.Error
+		}
+	}
+	return export, nil
+}
+
+// ExportAccount godoc
+// @Summary Export all personal data
+// @Description Downloads everything stored about the authenticated user: profile, notebooks, tags, notes with revisions, knowledge links (trashed items included), sessions, personal access tokens, linked identities, saved weather locations and audit events. The zip archive has one JSON file per section, the json format is a single document.
+// @Tags users
+// @Produce application/zip,json
+// @Security BearerAuth
+// @Param format query string false "Export format" Enums(zip, json) default(zip)
+// @Success 200 {object} handlers.AccountExport "Zip archive or JSON document"
+// @Failure 400 {object} object "Unsupported export format"
+// @Failure 401 {object} object "Unauthorized"
+// @Failure 500 {object} object "Internal server error (e.g., {\"error\": \"Failed to export account\"})"
+// @Router /users/me/export [get]
+func ExportAccount(c *gin.Context) {
+	userID, _ := c.Get("userID")
+
+	format := c.DefaultQuery("format", "zip")
+	if format != "zip" && format != "json" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Unsupported export format", "details": "supported formats: zip, json"})
+		return
+	}
+
+	export, err := collectAccountExport(userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to export account", "details": err.Error()})
+		return
+	}
+
+	fileName := "organizer-account-" + export.ExportedAt.Format("20060102")
+	if format == "json" {
+		c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s.json"`, fileName))
+		c.IndentedJSON(http.StatusOK, export)
+		return
+	}
+
+	c.Header("Content-Type", "application/zip")
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s.zip"`, fileName))
+	c.Status(http.StatusOK)
+
+	// The response is already streaming, errors from here on can only abort the archive
+	archive := zip.NewWriter(c.Writer)
+	files := []struct {
+		name string
+		data interface{}
+	}{
+		{"profile.json", export.Profile},
+		{"notebooks.json", export.Notebooks},
+		{"tags.json", export.Tags},
+		{"notes.json", export.Notes},
+		{"note-revisions.json", export.NoteRevisions},
+		{"knowledge-links.json", export.KnowledgeLinks},
+		{"sessions.json", export.Sessions},
+		{"access-tokens.json", export.AccessTokens},
+		{"identities.json", export.Identities},
+		{"weather-locations.json", export.Locations},
+		{"audit-events.json", export.AuditEvents},
+	}
+	for _, file := range files {
+		writer, err := archive.CreateHeader(&zip.FileHeader{Name: file.name, Method: zip.Deflate, Modified: export.ExportedAt})
+		if err != nil {
+			log.Printf("Account export for user %v aborted: %v", userID, err)
+			return
+		}
+		encoder := json.NewEncoder(writer)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(file.data); err != nil {
+			log.Printf("Account export for user %v aborted: %v", userID, err)
+			return
+		}
+	}
+	if err := archive.Close(); err != nil {
+		log.Printf("Account export for user %v aborted: %v", userID, err)
+	}
+}
+
+// DeleteAccount godoc
+// @Summary Delete the account
+// @Description Schedules the deletion of the authenticated user after a grace period (ACCOUNT_DELETION_GRACE_DAYS, 14 days by default). All other sessions are signed out and personal access tokens stop working; logging in and cancelling stays possible until the deletion. Then the account and all its data (notes, notebooks, tags, links, sessions, tokens) are deleted permanently. With a grace period of 0 the account is deleted right away. Accounts created through OpenID Connect have no password and set one with the password reset first.
+// @Tags users
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param confirmation body DeleteAccountInput true "Current password"
+// @Success 200 {object} handlers.AccountDeletionResponse
+// @Failure 400 {object} object "Invalid input"
+// @Failure 401 {object} object "Unauthorized or incorrect password (e.g., {\"error\": \"Incorrect password\"})"
+// @Failure 404 {object} object "User not found"
+// @Failure 429 {object} object "Too many failed attempts, see the Retry-After header"
+// @Failure 500 {object} object "Internal server error (e.g., {\"error\": \"Failed to delete account\"})"
+// @Router /users/me [delete]
+func DeleteAccount(c *gin.Context) {
+	userID, _ := c.Get("userID")
+
+	var input DeleteAccountInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var user models.User
+	if err := config.DB.First(&user, userID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return
+	}
+
+	lockKey := lockoutKey(user.ID, user.Email)
+	if rejectLockedAccount(c, lockKey) {
+		return
+	}
+	if !utils.CheckPasswordHash(input.Password, user.PasswordHash) {
+		recordFailedAttempt(c, lockKey, user.ID, user.Email)
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Incorrect password"})
+		return
+	}
+
+	if accountDeletionGraceDays == 0 {
+		if _, err := deleteAccount(config.DB, user.ID); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete account", "details": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, AccountDeletionResponse{Message: "Account deleted"})
+		return
+	}
+	if user.DeletionScheduledAt != nil {
+		c.JSON(http.StatusOK, AccountDeletionResponse{Message: "Account deletion already scheduled", DeletionScheduledAt: user.DeletionScheduledAt})
+		return
+	}
+
+	deleteAt := time.Now().AddDate(0, 0, accountDeletionGraceDays)
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&user).Update("deletion_scheduled_at", deleteAt).Error; err != nil {
+			return err
+		}
+		sessionID, _ := c.Get("sessionID")
+		_, err := revokeSessions(tx.Where("user_id = ? AND id <> ?", user.ID, sessionID), "account-deletion")
+		return err
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete account", "details": err.Error()})
+		return
+	}
+	recordAuditEvent(c, models.AuditAccountDeletionScheduled, user.ID, user.Email, map[string]interface{}{"deletionScheduledAt": deleteAt})
+
+	c.JSON(http.StatusOK, AccountDeletionResponse{Message: "Account deletion scheduled", DeletionScheduledAt: &deleteAt})
+}
+
+// CancelAccountDeletion godoc
+// @Summary Cancel the account deletion
+// @Description Keeps the account of the authenticated user when its deletion is scheduled
+// @Tags users
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} models.User
+// @Failure 401 {object} object "Unauthorized"
+// @Failure 404 {object} object "User not found"
+// @Failure 409 {object} object "No deletion is scheduled"
+// @Failure 500 {object} object "Internal server error (e.g., {\"error\": \"Failed to cancel account deletion\"})"
+// @Router /users/me/deletion/cancel [post]
+func CancelAccountDeletion(c *gin.Context) {
+	userID, _ := c.Get("userID")
+
+	var user models.User
+	if err := config.DB.First(&user, userID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return
+	}
+	if user.DeletionScheduledAt == nil {
+		c.JSON(http.StatusConflict, gin.H{"error": "No account deletion is scheduled"})
+		return
+	}
+
+	if err := config.DB.Model(&user).Update("deletion_scheduled_at", nil).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to cancel account deletion", "details": err.Error()})
+		return
+	}
+	recordAuditEvent(c, models.AuditAccountDeletionCanceled, user.ID, user.Email, nil)
+
+	user.DeletionScheduledAt = nil
+	c.JSON(http.StatusOK, user)
+}
@@ -0,0 +1,278 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"net/url"
+	"organizer-backend/config"
+	"organizer-backend/models"
+	"organizer-backend/utils"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	emailVerificationTTL = 48 * time.Hour
+	passwordResetTTL     = time.Hour
+	emailResendInterval  = time.Minute // Minimum time between two emails of the same kind to one user
+)
+
+var errInvalidUserToken = errors.New("invalid or expired token")
+
+type EmailTokenInput struct {
+	Token string `json:"token" binding:"required" example:"q5Jx0mVb2n8Yk1s9cW4tZr7LhP3dG6fA0eU2iO8yTnM"`
+}
+
+type ForgotPasswordInput struct {
+	Email string `json:"email" binding:"required,email" example:"user@example.com"`
+}
+
+type ResetPasswordInput struct {
+	Token       string `json:"token" binding:"required" example:"q5Jx0mVb2n8Yk1s9cW4tZr7LhP3dG6fA0eU2iO8yTnM"`
+	NewPassword string `json:"newPassword" binding:"required,min=6" example:"newpassword123"`
+}
+
+// issueUserToken creates a single-use token and invalidates earlier unused tokens of the same purpose,
+// so only the link from the latest email works
+func issueUserToken(tx *gorm.DB, userID uint, purpose string, ttl time.Duration) (string, error) {
+	token, err := utils.GenerateOpaqueToken()
+	if err != nil {
+		return "", err
+	}
+	if err := tx.Model(&models.UserToken{}).Where("user_id = ? AND purpose = ? AND used_at IS NULL", userID, purpose).
+		Update("used_at", time.Now()).Error; err != nil {
+		return "", err
+	}
+	record := models.UserToken{UserID: userID, Purpose: purpose, TokenHash: utils.HashToken(token), ExpiresAt: time.Now().Add(ttl)}
+	return token, tx.Create(&record).Error
+}
+
+// consumeUserToken marks a valid token as used. Must run in a transaction, the row is locked so a token
+// cannot be used twice concurrently.
+func consumeUserToken(tx *gorm.DB, token, purpose string) (*models.UserToken, error) {
+	var record models.UserToken
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("token_hash = ? AND purpose = ?", utils.HashToken(token), purpose).First(&record).Error
+	if err == gorm.ErrRecordNotFound {
+		return nil, errInvalidUserToken
+	} else if err != nil {
+		return nil, err
+	}
+	if record.UsedAt != nil || time.Now().After(record.ExpiresAt) {
+		return nil, errInvalidUserToken
+	}
+
+	now := time.Now()
+	if err := tx.Model(&record).Update("used_at", now).Error; err != nil {
+		return nil, err
+	}
+	record.UsedAt = &now
+	return &record, nil
+}
+
+// recentlyEmailed reports whether a token of the purpose was sent to the user within emailResendInterval
+func recentlyEmailed(db *gorm.DB, userID uint, purpose string) (bool, error) {
+	var count int64
+	err := db.Model(&models.UserToken{}).
+		Where("user_id = ? AND purpose = ? AND created_at > ?", userID, purpose, time.Now().Add(-emailResendInterval)).
+		Count(&count).Error
+	return count > 0, err
+}
+
+// sendEmailWithToken issues a token and emails a link with it to the user in their language
+func sendEmailWithToken(db *gorm.DB, user models.User, purpose, template, path string, ttl time.Duration) error {
+	token, err := issueUserToken(db, user.ID, purpose, ttl)
+	if err != nil {
+		return err
+	}
+	msg, err := utils.RenderEmail(template, user.Locale, user.Email, utils.EmailData{
+		Name:     user.Fullname,
+		Link:     utils.AppURL(path + "?token=" + url.QueryEscape(token)),
+		ValidFor: ttl,
+	})
+	if err != nil {
+		return err
+	}
+	utils.SendMailAsync(msg)
+	return nil
+}
+
+func sendVerificationEmail(db *gorm.DB, user models.User) error {
+	return sendEmailWithToken(db, user, models.TokenPurposeEmailVerification, utils.EmailVerification, "/verify-email", emailVerificationTTL)
+}
+
+// VerifyEmail godoc
+// @Summary Confirm the email address
+// @Description Confirms the email address of the user with the token from the verification email. Tokens are single-use and expire after 48 hours.
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param token body EmailTokenInput true "Token from the verification link"
+// @Success 200 {object} object "Email verified (e.g., {\"message\": \"Email verified\"})"
+// @Failure 400 {object} object "Invalid, used or expired token (e.g., {\"error\": \"Invalid or expired token\"})"
+// @Failure 429 {object} object "Too many requests, see the Retry-After header"
+// @Failure 500 {object} object "Internal server error (e.g., {\"error\": \"Failed to verify email\"})"
+// @Router /auth/verify-email [post]
+func VerifyEmail(c *gin.Context) {
+	var input EmailTokenInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input: " + err.Error()})
+		return
+	}
+
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		record, err := consumeUserToken(tx, input.Token, models.TokenPurposeEmailVerification)
+		if err != nil {
+			return err
+		}
+		return tx.Model(&models.User{}).Where("id = ?", record.UserID).Update("email_verified", true).Error
+	})
+	if err == errInvalidUserToken {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid or expired token"})
+		return
+	} else if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to verify email", "details": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Email verified"})
+}
+
+// ResendVerificationEmail godoc
+// @Summary Resend the verification email
+// @Description Sends a new verification link to the email of the authenticated user. Links from earlier emails stop working.
+// @Tags auth
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} object "Email sent (e.g., {\"message\": \"Verification email sent\"})"
+// @Failure 401 {object} object "Unauthorized"
+// @Failure 404 {object} object "User not found"
+// @Failure 409 {object} object "Email is already verified"
+// @Failure 429 {object} object "An email was sent less than a minute ago"
+// @Failure 500 {object} object "Internal server error (e.g., {\"error\": \"Failed to send verification email\"})"
+// @Router /auth/verify-email/resend [post]
+func ResendVerificationEmail(c *gin.Context) {
+	userID, _ := c.Get("userID")
+
+	var user models.User
+	if err := config.DB.First(&user, userID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return
+	}
+	if user.EmailVerified {
+		c.JSON(http.StatusConflict, gin.H{"error": "Email is already verified"})
+		return
+	}
+
+	recent, err := recentlyEmailed(config.DB, user.ID, models.TokenPurposeEmailVerification)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to send verification email", "details": err.Error()})
+		return
+	}
+	if recent {
+		c.Header("Retry-After", utils.RetryAfterSeconds(emailResendInterval))
+		c.JSON(http.StatusTooManyRequests, gin.H{"error": "An email was sent less than a minute ago"})
+		return
+	}
+
+	if err := sendVerificationEmail(config.DB, user); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to send verification email", "details": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Verification email sent"})
+}
+
+// ForgotPassword godoc
+// @Summary Request a password reset
+// @Description Sends a password reset link to the email if an account with it exists. The response is the same either way, so it does not reveal which emails are registered.
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param email body ForgotPasswordInput true "Account email"
+// @Success 200 {object} object "Request accepted (e.g., {\"message\": \"If an account with this email exists, a password reset link has been sent\"})"
+// @Failure 400 {object} object "Invalid input"
+// @Failure 429 {object} object "Too many requests, see the Retry-After header"
+// @Failure 500 {object} object "Internal server error"
+// @Router /auth/forgot-password [post]
+func ForgotPassword(c *gin.Context) {
+	var input ForgotPasswordInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input: " + err.Error()})
+		return
+	}
+
+	var user models.User
+	err := config.DB.Where("email = ?", input.Email).First(&user).Error
+	if err != nil && err != gorm.ErrRecordNotFound {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error finding user", "details": err.Error()})
+		return
+	}
+
+	if err == nil {
+		// Repeated requests are answered without a new email, so the endpoint cannot be used to flood a mailbox
+		recent, err := recentlyEmailed(config.DB, user.ID, models.TokenPurposePasswordReset)
+		if err == nil && !recent {
+			err = sendEmailWithToken(config.DB, user, models.TokenPurposePasswordReset, utils.EmailPasswordReset, "/reset-password", passwordResetTTL)
+		}
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to send password reset email", "details": err.Error()})
+			return
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "If an account with this email exists, a password reset link has been sent"})
+}
+
+// ResetPassword godoc
+// @Summary Reset the password
+// @Description Sets a new password with the token from the password reset email. Tokens are single-use and expire after one hour.
+// @Description All sessions of the user are revoked. The email counts as verified, since the link was received there.
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param reset body ResetPasswordInput true "Reset token and new password"
+// @Success 200 {object} object "Password reset (e.g., {\"message\": \"Password has been reset\", \"revokedSessions\": 2})"
+// @Failure 400 {object} object "Invalid input or invalid, used or expired token (e.g., {\"error\": \"Invalid or expired token\"})"
+// @Failure 429 {object} object "Too many requests, see the Retry-After header"
+// @Failure 500 {object} object "Internal server error (e.g., {\"error\": \"Failed to reset password\"})"
+// @Router /auth/reset-password [post]
+func ResetPassword(c *gin.Context) {
+	var input ResetPasswordInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input: " + err.Error()})
+		return
+	}
+
+	hashedPassword, err := utils.HashPassword(input.NewPassword)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to hash password"})
+		return
+	}
+
+	var revokedSessions int64
+	err = config.DB.Transaction(func(tx *gorm.DB) error {
+		record, err := consumeUserToken(tx, input.Token, models.TokenPurposePasswordReset)
+		if err != nil {
+			return err
+		}
+		if err := tx.Model(&models.User{}).Where("id = ?", record.UserID).
+			Updates(map[string]interface{}{"password_hash": hashedPassword, "email_verified": true}).Error; err != nil {
+			return err
+		}
+		revokedSessions, err = revokeSessions(tx.Where("user_id = ?", record.UserID), "password-reset")
+		return err
+	})
+	if err == errInvalidUserToken {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid or expired token"})
+		return
+	} else if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to reset password", "details": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Password has been reset", "revokedSessions": revokedSessions})
+}
@@ -0,0 +1,461 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"organizer-backend/config"
+	"organizer-backend/models"
+	"organizer-backend/utils"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+const forcedPasswordResetTTL = 24 * time.Hour // Forced resets are not requested by the user, who may read the email later
+
+// PaginatedUsersResponse is a page of users for the admin API
+type PaginatedUsersResponse struct {
+	Users      []models.User `json:"users"`
+	TotalCount int64         `json:"totalCount" example:"42"` // Users matching the filters, before pagination
+}
+
+// PaginatedAuditEventsResponse is a page of the audit trail
+type PaginatedAuditEventsResponse struct {
+	Events     []models.AuditEvent `json:"events"`
+	TotalCount int64               `json:"totalCount" example:"42"`
+}
+
+type UpdateUserRoleInput struct {
+	Role string `json:"role" binding:"required,oneof=user admin" example:"admin"`
+}
+
+// UserStorageUsage is the space a user's notes and knowledge links take, trashed items included
+type UserStorageUsage struct {
+	UserID         uint   `json:"userId" example:"7"`
+	Email          string `json:"email" example:"user@example.com"`
+	Notes          int64  `json:"notes" example:"120"`       // Notes outside the trash
+	TrashedNotes   int64  `json:"trashedNotes" example:"3"`  //
+	NotesBytes     int64  `json:"notesBytes" example:"5242"` // Size of titles and contents
+	Revisions      int64  `json:"revisions" example:"450"`
+	RevisionsBytes int64  `json:"revisionsBytes" example:"20480"`
+	Links          int64  `json:"links" example:"35"` // Knowledge links outside the trash
+	TrashedLinks   int64  `json:"trashedLinks" example:"1"`
+	LinksBytes     int64  `json:"linksBytes" example:"2048"`
+	TotalBytes     int64  `json:"totalBytes" example:"27770"`
+}
+
+// parseLimitOffset reads limit (1-100, default 20) and offset query parameters
+func parseLimitOffset(c *gin.Context) (int, int, error) {
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "20"))
+	if err != nil || limit < 1 || limit > 100 {
+		return 0, 0, errors.New("limit must be between 1 and 100")
+	}
+	offset, err := strconv.Atoi(c.DefaultQuery("offset", "0"))
+	if err != nil || offset < 0 {
+		return 0, 0, errors.New("offset must be a non-negative number")
+	}
+	return limit, offset, nil
+}
+
+// findAdminTarget loads the user of the :id parameter, responding with 404 when it does not exist
+func findAdminTarget(c *gin.Context) (models.User, bool) {
+	var user models.User
+	if err := config.DB.First(&user, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return user, false
+	}
+	return user, true
+}
+
+// rejectSelfAction prevents admins from locking themselves out, e.g. by disabling their own account
+func rejectSelfAction(c *gin.Context, user models.User) bool {
+	adminID, _ := c.Get("userID")
+	if adminID != user.ID {
+		return false
+	}
+	c.JSON(http.StatusBadRequest, gin.H{"error": "This action cannot be applied to your own account"})
+	return true
+}
+
+func adminDetails(c *gin.Context, details map[string]interface{}) map[string]interface{} {
+	adminID, _ := c.Get("userID")
+	if details == nil {
+		details = map[string]interface{}{}
+	}
+	details["adminId"] = adminID
+	return details
+}
+
+// AdminGetUsers godoc
+// @Summary List users
+// @Description Lists and searches user accounts, newest first. Requires the admin role.
+// @Tags admin
+// @Produce json
+// @Security BearerAuth
+// @Param q query string false "Search in email and full name"
+// @Param role query string false "Filter by role (user, admin)"
+// @Param status query string false "Filter by status (active, disabled)"
+// @Param limit query int false "Page size (1-100)" default(20)
+// @Param offset query int false "Number of users to skip" default(0)
+// @Success 200 {object} handlers.PaginatedUsersResponse
+// @Failure 400 {object} object "Invalid parameters"
+// @Failure 401 {object} object "Unauthorized"
+// @Failure 403 {object} object "Insufficient permissions"
+// @Failure 500 {object} object "Internal server error (e.g., {\"error\": \"Failed to retrieve users\"})"
+// @Router /admin/users [get]
+func AdminGetUsers(c *gin.Context) {
+	limit, offset, err := parseLimitOffset(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	query := config.DB.Model(&models.User{})
+	if search := strings.TrimSpace(c.Query("q")); search != "" {
+		pattern := "%" + strings.NewReplacer("\\", "\\\\", "%", "\\%", "_", "\\_").Replace(search) + "%"
+		query = query.Where("email ILIKE ? OR fullname ILIKE ?", pattern, pattern)
+	}
+	if role := c.Query("role"); role != "" {
+		query = query.Where("role = ?", role)
+	}
+	switch c.Query("status") {
+	case "":
+	case "active":
+		query = query.Where("disabled_at IS NULL")
+	case "disabled":
+		query = query.Where("disabled_at IS NOT NULL")
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "status must be 'active' or 'disabled'"})
+		return
+	}
+
+	response := PaginatedUsersResponse{Users: []models.User{}}
+	if err := query.Session(&gorm.Session{}).Count(&response.TotalCount).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve users", "details": err.Error()})
+		return
+	}
+	if err := query.Order("created_at DESC, id DESC").Limit(limit).Offset(offset).Find(&response.Users).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve users", "details": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// AdminGetUser godoc
+// @Summary Get a user
+// @Description Returns the account of a user. Requires the admin role.
+// @Tags admin
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "User ID"
+// @Success 200 {object} models.User
+// @Failure 401 {object} object "Unauthorized"
+// @Failure 403 {object} object "Insufficient permissions"
+// @Failure 404 {object} object "User not found"
+// @Router /admin/users/{id} [get]
+func AdminGetUser(c *gin.Context) {
+	user, ok := findAdminTarget(c)
+	if !ok {
+		return
+	}
+	c.JSON(http.StatusOK, user)
+}
+
+// AdminDisableUser godoc
+// @Summary Disable a user
+// @Description Blocks the account: all sessions are revoked, personal access tokens stop working and logins are refused until the account is enabled again.
+// @Tags admin
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "User ID"
+// @Success 200 {object} models.User
+// @Failure 400 {object} object "Admins cannot disable their own account"
+// @Failure 401 {object} object "Unauthorized"
+// @Failure 403 {object} object "Insufficient permissions"
+// @Failure 404 {object} object "User not found"
+// @Failure 500 {object} object "Internal server error (e.g., {\"error\": \"Failed to disable user\"})"
+// @Router /admin/users/{id}/disable [post]
+func AdminDisableUser(c *gin.Context) {
+	user, ok := findAdminTarget(c)
+	if !ok || rejectSelfAction(c, user) {
+		return
+	}
+	if user.DisabledAt != nil {
+		c.JSON(http.StatusOK, user)
+		return
+	}
+
+	now := time.Now()
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&user).Update("disabled_at", now).Error; err != nil {
+			return err
+		}
+		_, err := revokeSessions(tx.Where("user_id = ?", user.ID), "account-disabled")
+		return err
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to disable user", "details": err.Error()})
+		return
+	}
+	recordAuditEvent(c, models.AuditUserDisabled, user.ID, user.Email, adminDetails(c, nil))
+
+	user.DisabledAt = &now
+	c.JSON(http.StatusOK, user)
+}
+
+// AdminEnableUser godoc
+// @Summary Enable a user
+// @Description Lifts the block of a disabled account. The user has to log in again.
+// @Tags admin
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "User ID"
+// @Success 200 {object} models.User
+// @Failure 401 {object} object "Unauthorized"
+// @Failure 403 {object} object "Insufficient permissions"
+// @Failure 404 {object} object "User not found"
+// @Failure 500 {object} object "Internal server error (e.g., {\"error\": \"Failed to enable user\"})"
+// @Router /admin/users/{id}/enable [post]
+func AdminEnableUser(c *gin.Context) {
+	user, ok := findAdminTarget(c)
+	if !ok {
+		return
+	}
+	if user.DisabledAt == nil {
+		c.JSON(http.StatusOK, user)
+		return
+	}
+
+	if err := config.DB.Model(&user).Update("disabled_at", nil).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to enable user", "details": err.Error()})
+		return
+	}
+	recordAuditEvent(c, models.AuditUserEnabled, user.ID, user.Email, adminDetails(c, nil))
+
+	user.DisabledAt = nil
+	c.JSON(http.StatusOK, user)
+}
+
+// AdminUpdateUserRole godoc
+// @Summary Change the role of a user
+// @Description Sets the role (user or admin). The sessions of the user are revoked, so tokens with the old role stop working.
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "User ID"
+// @Param role body UpdateUserRoleInput true "New role"
+// @Success 200 {object} models.User
+// @Failure 400 {object} object "Invalid role, or admins changing their own role"
+// @Failure 401 {object} object "Unauthorized"
+// @Failure 403 {object} object "Insufficient permissions"
+// @Failure 404 {object} object "User not found"
+// @Failure 500 {object} object "Internal server error (e.g., {\"error\": \"Failed to update role\"})"
+// @Router /admin/users/{id}/role [put]
+func AdminUpdateUserRole(c *gin.Context) {
+	var input UpdateUserRoleInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input: " + err.Error()})
+		return
+	}
+	user, ok := findAdminTarget(c)
+	if !ok || rejectSelfAction(c, user) {
+		return
+	}
+	if user.Role == input.Role {
+		c.JSON(http.StatusOK, user)
+		return
+	}
+
+	oldRole := user.Role
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&user).Update("role", input.Role).Error; err != nil {
+			return err
+		}
+		_, err := revokeSessions(tx.Where("user_id = ?", user.ID), "role-change")
+		return err
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update role", "details": err.Error()})
+		return
+	}
+	recordAuditEvent(c, models.AuditRoleChanged, user.ID, user.Email, adminDetails(c, map[string]interface{}{"oldRole": oldRole, "newRole": input.Role}))
+
+	user.Role = input.Role
+	c.JSON(http.StatusOK, user)
+}
+
+// AdminForcePasswordReset godoc
+// @Summary Force a password reset
+// @Description For compromised accounts: the password stops working, all sessions are revoked and personal access tokens are deleted.
+// @Description The user receives a password reset email, valid for 24 hours, and can also request a new one via /auth/forgot-password.
+// @Tags admin
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "User ID"
+// @Success 200 {object} object "Reset forced (e.g., {\"message\": \"Password reset email sent\", \"revokedSessions\": 2})"
+// @Failure 401 {object} object "Unauthorized"
+// @Failure 403 {object} object "Insufficient permissions"
+// @Failure 404 {object} object "User not found"
+// @Failure 500 {object} object "Internal server error (e.g., {\"error\": \"Failed to force password reset\"})"
+// @Router /admin/users/{id}/force-password-reset [post]
+func AdminForcePasswordReset(c *gin.Context) {
+	user, ok := findAdminTarget(c)
+	if !ok {
+		return
+	}
+
+	var revokedSessions int64
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		// An empty hash matches no password, only the reset link lets the user in again
+		if err := tx.Model(&user).Update("password_hash", "").Error; err != nil {
+			return err
+		}
+		if err := tx.Where("user_id = ?", user.ID).Delete(&models.PersonalAccessToken{}).Error; err != nil {
+			return err
+		}
+		var err error
+		revokedSessions, err = revokeSessions(tx.Where("user_id = ?", user.ID), "password-reset-forced")
+		return err
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to force password reset", "details": err.Error()})
+		return
+	}
+	recordAuditEvent(c, models.AuditPasswordResetForced, user.ID, user.Email, adminDetails(c, nil))
+
+	if err := sendEmailWithToken(config.DB, user, models.TokenPurposePasswordReset, utils.EmailPasswordReset, "/reset-password", forcedPasswordResetTTL); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Password was reset, but the reset email could not be sent", "details": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Password reset email sent", "revokedSessions": revokedSessions})
+}
+
+// storageUsageQuery computes UserStorageUsage for every user
+func storageUsageQuery(db *gorm.DB) *gorm.DB {
+	perUser := db.Table("users").Select(`users.id AS user_id, users.email,
+		(SELECT COUNT(*) FROM notes WHERE notes.user_id = users.id AND notes.deleted_at IS NULL) AS notes,
+		(SELECT COUNT(*) FROM notes WHERE notes.user_id = users.id AND notes.deleted_at IS NOT NULL) AS trashed_notes,
+		(SELECT COALESCE(SUM(octet_length(COALESCE(notes.title, '')) + octet_length(COALESCE(notes.content, ''))), 0)
+			FROM notes WHERE notes.user_id = users.id) AS notes_bytes,
+		(SELECT COUNT(*) FROM note_revisions JOIN notes ON notes.id = note_revisions.note_id WHERE notes.user_id = users.id) AS revisions,
+		(SELECT COALESCE(SUM(octet_length(COALESCE(note_revisions.title, '')) + octet_length(COALESCE(note_revisions.content, ''))), 0)
+			FROM note_revisions JOIN notes ON notes.id = note_revisions.note_id WHERE notes.user_id = users.id) AS revisions_bytes,
+		(SELECT COUNT(*) FROM knowledge_links WHERE knowledge_links.user_id = users.id AND knowledge_links.deleted_at IS NULL) AS links,
+		(SELECT COUNT(*) FROM knowledge_links WHERE knowledge_links.user_id = users.id AND knowledge_links.deleted_at IS NOT NULL) AS trashed_links,
+		(SELECT COALESCE(SUM(octet_length(knowledge_links.url) + octet_length(COALESCE(knowledge_links.title, ''))), 0)
+			FROM knowledge_links WHERE knowledge_links.user_id = users.id) AS links_bytes`)
+	return db.Table("(?) AS usage", perUser).Select("usage.*, notes_bytes + revisions_bytes + links_bytes AS total_bytes")
+}
+
+// AdminGetUserUsage godoc
+// @Summary Get storage usage of a user
+// @Description Counts notes, revisions and knowledge links of the user and their size in bytes, trashed items included.
+// @Tags admin
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "User ID"
+// @Success 200 {object} handlers.UserStorageUsage
+// @Failure 401 {object} object "Unauthorized"
+// @Failure 403 {object} object "Insufficient permissions"
+// @Failure 404 {object} object "User not found"
+// @Failure 500 {object} object "Internal server error (e.g., {\"error\": \"Failed to compute storage usage\"})"
+// @Router /admin/users/{id}/usage [get]
+func AdminGetUserUsage(c *gin.Context) {
+	user, ok := findAdminTarget(c)
+	if !ok {
+		return
+	}
+
+	var usage UserStorageUsage
+	if err := storageUsageQuery(config.DB).Where("user_id = ?", user.ID).Scan(&usage).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to compute storage usage", "details": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, usage)
+}
+
+// AdminGetStorageUsage godoc
+// @Summary Get storage usage of all users
+// @Description Lists the storage usage of users, largest first. Requires the admin role.
+// @Tags admin
+// @Produce json
+// @Security BearerAuth
+// @Param limit query int false "Page size (1-100)" default(20)
+// @Param offset query int false "Number of users to skip" default(0)
+// @Success 200 {array} handlers.UserStorageUsage
+// @Failure 400 {object} object "Invalid parameters"
+// @Failure 401 {object} object "Unauthorized"
+// @Failure 403 {object} object "Insufficient permissions"
+// @Failure 500 {object} object "Internal server error (e.g., {\"error\": \"Failed to compute storage usage\"})"
+// @Router /admin/usage [get]
+func AdminGetStorageUsage(c *gin.Context) {
+	limit, offset, err := parseLimitOffset(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	usage := []UserStorageUsage{}
+	if err := storageUsageQuery(config.DB).Order("total_bytes DESC, user_id").Limit(limit).Offset(offset).Scan(&usage).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to compute storage usage", "details": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, usage)
+}
+
+// AdminGetAuditEvents godoc
+// @Summary Get the audit trail
+// @Description Lists security events (lockouts, admin actions), newest first. Requires the admin role.
+// @Tags admin
+// @Produce json
+// @Security BearerAuth
+// @Param userId query int false "Only events of this user"
+// @Param event query string false "Only events of this type, e.g. account.locked"
+// @Param limit query int false "Page size (1-100)" default(20)
+// @Param offset query int false "Number of events to skip" default(0)
+// @Success 200 {object} handlers.PaginatedAuditEventsResponse
+// @Failure 400 {object} object "Invalid parameters"
+// @Failure 401 {object} object "Unauthorized"
+// @Failure 403 {object} object "Insufficient permissions"
+// @Failure 500 {object} object "Internal server error (e.g., {\"error\": \"Failed to retrieve audit events\"})"
+// @Router /admin/audit-events [get]
+func AdminGetAuditEvents(c *gin.Context) {
+	limit, offset, err := parseLimitOffset(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	query := config.DB.Model(&models.AuditEvent{})
+	if userID := c.Query("userId"); userID != "" {
+		id, err := strconv.ParseUint(userID, 10, 64)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid userId parameter"})
+			return
+		}
+		query = query.Where("user_id = ?", id)
+	}
+	if event := c.Query("event"); event != "" {
+		query = query.Where("event = ?", event)
+	}
+
+	response := PaginatedAuditEventsResponse{Events: []models.AuditEvent{}}
+	if err := query.Session(&gorm.Session{}).Count(&response.TotalCount).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve audit events", "details": err.Error()})
+		return
+	}
+	if err := query.Order("created_at DESC, id DESC").Limit(limit).Offset(offset).Find(&response.Events).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve audit events", "details": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
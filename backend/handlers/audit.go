@@ -0,0 +1,27 @@
+package handlers
+
+import (
+	"log"
+	"organizer-backend/config"
+	"organizer-backend/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// recordAuditEvent stores an event about the user (0 when unknown) in the audit trail. Failures are only
+// logged, so auditing never breaks the action itself.
+func recordAuditEvent(c *gin.Context, event string, userID uint, account string, details map[string]interface{}) {
+	record := models.AuditEvent{
+		Event:     event,
+		Account:   truncate(account, 255),
+		IP:        c.ClientIP(),
+		UserAgent: truncate(c.Request.UserAgent(), 512),
+		Details:   details,
+	}
+	if userID != 0 {
+		record.UserID = &userID
+	}
+	if err := config.DB.Create(&record).Error; err != nil {
+		log.Printf("Failed to record audit event %s for %s: %v", event, account, err)
+	}
+}
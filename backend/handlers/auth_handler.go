@@ -2,6 +2,7 @@ package handlers
 
 import (
 	"fmt"
+	"log"
 	"net/http"
 	"organizer-backend/config"
 	"organizer-backend/models"
@@ -18,17 +19,19 @@ type RegisterInput struct {
 	Fullname string `json:"fullname" example:"John Doe"`
 	Age      int    `json:"age" example:"30"`
 	Contacts string `json:"contacts" example:"+1234567890"`
+	Locale   string `json:"locale" binding:"omitempty,oneof=ru en" example:"ru"` // Language of emails, taken from Accept-Language when omitted
 }
 
 // UserAuthResponse defines the structure for successful authentication responses.
 type UserAuthResponse struct {
-	Token string      `json:"token" example:"eyJhbGciOiJIUzI1NiIsInR5cCI6IkpXVCJ9..."`
-	User  models.User `json:"user"` // User model without PasswordHash
+	TokenPairResponse
+	User models.User `json:"user"` // User model without PasswordHash
 }
 
 // RegisterUser godoc
 // @Summary Register a new user
-// @Description Creates a new user account and returns a JWT token.
+// @Description Creates a new user account and returns an access token with a refresh token.
+// @Description A verification link is sent to the email address, see /auth/verify-email.
 // @Tags auth
 // @Accept  json
 // @Produce  json
@@ -36,6 +39,7 @@ type UserAuthResponse struct {
 // @Success 201 {object} UserAuthResponse "Successfully registered"
 // @Failure 400 {object} object "Validation error or invalid input (e.g., {\"error\": \"Invalid input: Key: 'RegisterInput.Email' Error:Field validation for 'Email' failed on the 'email' tag\"})"
 // @Failure 409 {object} object "User with this email already exists (e.g., {\"error\": \"User with this email already exists\"})"
+// @Failure 429 {object} object "Too many requests, see the Retry-After header"
 // @Failure 500 {object} object "Internal server error (e.g., {\"error\": \"Failed to hash password\"})"
 // @Router /auth/register [post]
 func RegisterUser(c *gin.Context) {
@@ -57,6 +61,14 @@ func RegisterUser(c *gin.Context) {
 		Fullname:     input.Fullname,
 		Age:          input.Age,
 		Contacts:     input.Contacts,
+		Locale:       input.Locale,
+		Role:         models.RoleUser,
+	}
+	if user.Locale == "" {
+		user.Locale = utils.NormalizeLocale(c.GetHeader("Accept-Language"))
+	}
+	if user.Locale == "" {
+		user.Locale = utils.DefaultLocale
 	}
 
 	// Check if user already exists
@@ -74,19 +86,24 @@ func RegisterUser(c *gin.Context) {
 		return
 	}
 
-	token, err := utils.GenerateJWT(user.ID, user.Email)
+	// The account is usable right away, a failed email only means the user has to request a new link
+	if err := sendVerificationEmail(config.DB, user); err != nil {
+		log.Printf("Failed to send verification email to user %d: %v", user.ID, err)
+	}
+
+	tokens, err := startSession(c, user)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate token"})
 		return
 	}
 	// Prepare user response without sensitive data
 	userResponse := models.User{
-		ID: user.ID, Email: user.Email, Fullname: user.Fullname, Age: user.Age,
+		ID: user.ID, Email: user.Email, EmailVerified: user.EmailVerified, Locale: user.Locale, Role: user.Role, Fullname: user.Fullname, Age: user.Age,
 		Contacts: user.Contacts, TelegramHash: user.TelegramHash, // Include TelegramHash if it's set during registration or by default
 		CreatedAt: user.CreatedAt, UpdatedAt: user.UpdatedAt,
 	}
 
-	c.JSON(http.StatusCreated, UserAuthResponse{Token: token, User: userResponse})
+	c.JSON(http.StatusCreated, UserAuthResponse{TokenPairResponse: tokens, User: userResponse})
 }
 
 // LoginInput defines the structure for user login request body.
@@ -97,14 +114,18 @@ type LoginInput struct {
 
 // LoginUser godoc
 // @Summary Log in an existing user
-// @Description Authenticates a user and returns a JWT token upon successful login.
+// @Description Authenticates a user and starts a session: returns a short-lived access token and a refresh token (see /auth/refresh).
+// @Description For users with two-factor authentication a TwoFactorChallengeResponse is returned instead; finish the login with /auth/login/2fa.
+// @Description After repeated failed attempts the account is locked temporarily, with the lockout doubling on every further failure.
 // @Tags auth
 // @Accept  json
 // @Produce  json
 // @Param   credentials body LoginInput true "User Login Credentials"
-// @Success 200 {object} UserAuthResponse "Successfully logged in"
+// @Success 200 {object} UserAuthResponse "Successfully logged in (or TwoFactorChallengeResponse when 2FA is enabled)"
 // @Failure 400 {object} object "Validation error or invalid input (e.g., {\"error\": \"Invalid input: ...\"})"
 // @Failure 401 {object} object "Invalid credentials (e.g., {\"error\": \"Invalid credentials\"})"
+// @Failure 403 {object} object "Account is disabled"
+// @Failure 429 {object} object "Too many requests or failed attempts, see the Retry-After header"
 // @Failure 500 {object} object "Internal server error (e.g., {\"error\": \"Database error finding user\"})"
 // @Router /auth/login [post]
 func LoginUser(c *gin.Context) {
@@ -115,33 +136,58 @@ func LoginUser(c *gin.Context) {
 	}
 
 	var user models.User
-	if err := config.DB.Where("email = ?", input.Email).First(&user).Error; err != nil {
-		if err == gorm.ErrRecordNotFound {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid credentials"})
-			return
-		}
+	err := config.DB.Where("email = ?", input.Email).First(&user).Error
+	if err != nil && err != gorm.ErrRecordNotFound {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error finding user", "details": err.Error()})
 		return
 	}
 
+	lockKey := lockoutKey(user.ID, input.Email)
+	if rejectLockedAccount(c, lockKey) {
+		return
+	}
+	// Unknown users have no password hash, which is checked against a dummy hash to take as long
 	if !utils.CheckPasswordHash(input.Password, user.PasswordHash) {
+		recordFailedAttempt(c, lockKey, user.ID, input.Email)
 		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid credentials"})
 		return
 	}
+	if rejectDisabledAccount(c, user) {
+		return
+	}
+	if utils.PasswordHashOutdated(user.PasswordHash) {
+		if hash, err := utils.HashPassword(input.Password); err == nil {
+			if err := config.DB.Model(&user).UpdateColumn("password_hash", hash).Error; err != nil {
+				log.Printf("Failed to rehash password of user %d: %v", user.ID, err)
+			}
+		}
+	}
+
+	if user.TwoFactorEnabled {
+		challenge, err := utils.GenerateChallengeToken(user.ID, user.Email)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate token"})
+			return
+		}
+		c.JSON(http.StatusOK, TwoFactorChallengeResponse{TwoFactorRequired: true, ChallengeToken: challenge, ExpiresIn: int(utils.ChallengeTTL.Seconds())})
+		return
+	}
 
-	token, err := utils.GenerateJWT(user.ID, user.Email)
+	// With 2FA the failures are only forgotten after the second factor, otherwise codes could be guessed endlessly
+	utils.ResetLoginFailures(lockKey)
+	tokens, err := startSession(c, user)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate token"})
 		return
 	}
 
 	userResponse := models.User{
-		ID: user.ID, Email: user.Email, Fullname: user.Fullname, Age: user.Age,
-		Contacts: user.Contacts, TelegramHash: user.TelegramHash,
+		ID: user.ID, Email: user.Email, EmailVerified: user.EmailVerified, Locale: user.Locale, Role: user.Role, Fullname: user.Fullname, Age: user.Age,
+		Contacts: user.Contacts, TelegramHash: user.TelegramHash, TwoFactorEnabled: user.TwoFactorEnabled,
 		CreatedAt: user.CreatedAt, UpdatedAt: user.UpdatedAt,
 	}
 
-	c.JSON(http.StatusOK, UserAuthResponse{Token: token, User: userResponse})
+	c.JSON(http.StatusOK, UserAuthResponse{TokenPairResponse: tokens, User: userResponse})
 }
 
 // Optional: Handler for token validation (can be useful for client-side checks or refresh logic)
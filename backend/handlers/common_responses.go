@@ -11,6 +11,14 @@ type MessageResponse struct {
 }
 
 type PaginatedNotesResponse struct {
-	Notes      []models.Note `json:"notes"`                    // Массив заметок
-	TotalCount int64         `json:"totalCount" example:"100"` // Общее количество заметок, подходящих под критерии (до пагинации)
+	Notes      []models.Note     `json:"notes"`                                        // Массив заметок
+	TotalCount *int64            `json:"totalCount,omitempty" example:"100"`           // Общее количество заметок, подходящих под критерии (до пагинации); не возвращается при пагинации по курсору
+	TagCounts  []models.TagUsage `json:"tagCounts,omitempty"`                          // Количество заметок по каждому тегу среди подходящих под критерии; при пагинации по курсору только на первой странице
+	NextCursor *string           `json:"nextCursor" example:"eyJzIjoiY3JlYXRlZEF0In0"` // Курсор следующей страницы, null если страниц больше нет
+}
+
+type PaginatedKnowledgeLinksResponse struct {
+	Links      []models.KnowledgeLink `json:"links"`                                        // Массив ссылок
+	TotalCount *int64                 `json:"totalCount,omitempty" example:"42"`            // Общее количество ссылок; не возвращается при пагинации по курсору
+	NextCursor *string                `json:"nextCursor" example:"eyJzIjoiY3JlYXRlZEF0In0"` // Курсор следующей страницы, null если страниц больше нет
 }
@@ -0,0 +1,16 @@
+package handlers
+
+import (
+	"net/http"
+	"organizer-backend/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetJWKS publishes the public keys that verify access tokens (RFC 7517), so other services can check our
+// tokens without sharing a secret. Served at /.well-known/jwks.json, outside of /api, and therefore not
+// part of the Swagger documentation.
+func GetJWKS(c *gin.Context) {
+	c.Header("Cache-Control", "public, max-age=300")
+	c.JSON(http.StatusOK, utils.PublicJWKS())
+}
@@ -4,6 +4,7 @@ import (
 	"net/http"
 	"organizer-backend/config"
 	"organizer-backend/models"
+	"strconv"
 
 	"github.com/gin-gonic/gin"
 )
@@ -15,24 +16,98 @@ type CreateKnowledgeLinkInput struct {
 
 // GetKnowledgeLinks godoc
 // @Summary Get all knowledge links for the authenticated user
-// @Description Retrieves a list of knowledge links for the current user
+// @Description Retrieves a list of knowledge links for the current user.
+// @Description Without limit and cursor all links are returned as a plain array. With limit the response is a page
+// @Description with totalCount (limit/offset); with the cursor parameter pages are read by cursor: pass an empty
+// @Description cursor for the first page and then the returned nextCursor.
 // @Tags knowledge-links
 // @Produce json
 // @Security BearerAuth
-// @Success 200 {array} models.KnowledgeLink
+// @Param limit query int false "Limit per page (1-100 in cursor mode)"
+// @Param offset query int false "Offset for pagination, ignored in cursor mode" default(0)
+// @Param cursor query string false "Cursor from nextCursor of the previous page, empty for the first page"
+// @Param sort query string false "Sort field" Enums(createdAt, updatedAt, title) default(createdAt)
+// @Param order query string false "Sort direction; defaults to desc for dates and asc for title" Enums(asc, desc)
+// @Success 200 {object} handlers.PaginatedKnowledgeLinksResponse "A page of links (with limit or cursor), otherwise an array of models.KnowledgeLink"
+// @Failure 400 {object} object "Invalid pagination or sort parameters"
 // @Failure 401 {object} object "Unauthorized"
 // @Failure 500 {object} object "Internal server error (e.g., {"error": "Failed to retrieve knowledge links"})"
 // @Router /knowledge-links [get]
 func GetKnowledgeLinks(c *gin.Context) {
 	userID, _ := c.Get("userID")
 
-	var links []models.KnowledgeLink
-	if err := config.DB.Where("user_id = ?", userID).Order("created_at DESC").Find(&links).Error; err != nil {
+	sort, err := parseListSort(c, "knowledge_links", "createdAt")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid sort parameters", "details": err.Error()})
+		return
+	}
+
+	query := config.DB.Model(&models.KnowledgeLink{}).Where("knowledge_links.user_id = ?", userID)
+	cursor, cursorMode := c.GetQuery("cursor")
+	_, paged := c.GetQuery("limit")
+
+	if !cursorMode && !paged {
+		links := []models.KnowledgeLink{}
+		if err := sort.Order(query).Find(&links).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve knowledge links", "details": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, links)
+		return
+	}
+
+	response := PaginatedKnowledgeLinksResponse{Links: []models.KnowledgeLink{}}
+	var limit, offset int
+	var totalCount int64
+	if cursorMode {
+		if limit, err = parseCursorLimit(c); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid limit parameter", "details": err.Error()})
+			return
+		}
+	} else {
+		var errLimit, errOffset error
+		limit, errLimit = strconv.Atoi(c.Query("limit"))
+		offset, errOffset = strconv.Atoi(c.DefaultQuery("offset", "0"))
+		if errLimit != nil || errOffset != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid limit or offset parameters"})
+			return
+		}
+		if err := query.Count(&totalCount).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to count knowledge links", "details": err.Error()})
+			return
+		}
+		response.TotalCount = &totalCount
+	}
+
+	listQuery := sort.Order(query)
+	if cursorMode {
+		if listQuery, err = sort.After(listQuery, cursor); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid cursor", "details": "the cursor is malformed or belongs to another sort order"})
+			return
+		}
+		listQuery = listQuery.Limit(limit + 1) // One extra row tells whether there is a next page
+	} else {
+		listQuery = listQuery.Limit(limit).Offset(offset)
+	}
+	if err := listQuery.Find(&response.Links).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve knowledge links", "details": err.Error()})
 		return
 	}
 
-	c.JSON(http.StatusOK, links)
+	hasMore := int64(offset+len(response.Links)) < totalCount
+	if cursorMode {
+		hasMore = len(response.Links) > limit
+		if hasMore {
+			response.Links = response.Links[:limit]
+		}
+	}
+	if hasMore && len(response.Links) > 0 {
+		last := response.Links[len(response.Links)-1]
+		next := sort.Cursor(last.ID, last.CreatedAt, last.UpdatedAt, last.Title)
+		response.NextCursor = &next
+	}
+
+	c.JSON(http.StatusOK, response)
 }
 
 // CreateKnowledgeLink godoc
@@ -73,12 +148,12 @@ func CreateKnowledgeLink(c *gin.Context) {
 
 // DeleteKnowledgeLink godoc
 // @Summary Delete a knowledge link by ID
-// @Description Deletes a specific knowledge link by its ID, if it belongs to the authenticated user
+// @Description Moves a specific knowledge link to the trash, if it belongs to the authenticated user (see /trash)
 // @Tags knowledge-links
 // @Produce json
 // @Security BearerAuth
 // @Param id path int true "Knowledge Link ID"
-// @Success 200 {object} object "Knowledge link deleted successfully (e.g., {"message": "Knowledge link deleted successfully"})"
+// @Success 200 {object} object "Knowledge link moved to trash (e.g., {"message": "Knowledge link moved to trash"})"
 // @Failure 401 {object} object "Unauthorized"
 // @Failure 404 {object} object "Knowledge link not found or access denied"
 // @Failure 500 {object} object "Internal server error (e.g., {"error": "Failed to delete knowledge link"})"
@@ -94,11 +169,11 @@ func DeleteKnowledgeLink(c *gin.Context) {
 		return
 	}
 
-	// If found, delete it
+	// If found, move it to trash (soft delete)
 	if err := config.DB.Delete(&models.KnowledgeLink{}, linkID).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete knowledge link", "details": err.Error()})
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"message": "Knowledge link deleted successfully"})
+	c.JSON(http.StatusOK, gin.H{"message": "Knowledge link moved to trash"})
 }
@@ -0,0 +1,51 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"organizer-backend/models"
+	"organizer-backend/utils"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// lockoutKey identifies the account for failure counting. Unknown emails are counted too, so lockouts
+// do not reveal which emails are registered.
+func lockoutKey(userID uint, email string) string {
+	if userID != 0 {
+		return fmt.Sprintf("user:%d", userID)
+	}
+	return "email:" + strings.ToLower(email)
+}
+
+// rejectLockedAccount answers with 429 when the account is locked out. It runs before the password is
+// checked, so a locked account costs no bcrypt work.
+func rejectLockedAccount(c *gin.Context, key string) bool {
+	lockedFor := utils.AccountLockedFor(key)
+	if lockedFor <= 0 {
+		return false
+	}
+	c.Header("Retry-After", utils.RetryAfterSeconds(lockedFor))
+	c.JSON(http.StatusTooManyRequests, gin.H{"error": "Too many failed attempts, the account is temporarily locked"})
+	return true
+}
+
+// recordFailedAttempt counts a wrong password or second factor and records a lockout in the audit trail
+func recordFailedAttempt(c *gin.Context, key string, userID uint, email string) {
+	failures, lockout := utils.RecordLoginFailure(key)
+	if lockout <= 0 {
+		return
+	}
+
+	recordAuditEvent(c, models.AuditAccountLocked, userID, email, map[string]interface{}{"failures": failures, "lockedForSeconds": int(lockout.Seconds())})
+}
+
+// rejectDisabledAccount answers with 403 when an admin disabled the account
+func rejectDisabledAccount(c *gin.Context, user models.User) bool {
+	if user.DisabledAt == nil {
+		return false
+	}
+	c.JSON(http.StatusForbidden, gin.H{"error": "Account is disabled"})
+	return true
+}
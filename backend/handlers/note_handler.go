@@ -1,69 +1,262 @@
 package handlers
 
 import (
+	"errors"
+	"fmt"
 	"net/http"
 	"organizer-backend/config"
 	"organizer-backend/models"
 	"strconv"
+	"strings"
 
 	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
 )
 
 type CreateNoteInput struct {
-	Title   string `json:"title"`
-	Content string `json:"content" binding:"required"`
+	Title      string   `json:"title"`
+	Content    string   `json:"content" binding:"required"`
+	Language   string   `json:"language" binding:"omitempty,oneof=russian english simple" example:"russian"`
+	Tags       []string `json:"tags" binding:"omitempty,dive,max=64" example:"work,ideas"`
+	NotebookID *uint    `json:"notebookId" example:"1"`
 }
 
 type UpdateNoteInput struct {
-	Title   string `json:"title"`
-	Content string `json:"content"`
+	Title    string   `json:"title"`
+	Content  string   `json:"content"`
+	Language string   `json:"language" binding:"omitempty,oneof=russian english simple" example:"english"`
+	Tags     []string `json:"tags" binding:"omitempty,dive,max=64" example:"work"` // Omit to keep tags, [] to remove all
+	// On rename, rewrite [[Old Title]] references in other notes to the new title. References are kept
+	// when the new title contains [, ], | or a line break, or starts with id:
+	RewriteLinks bool `json:"rewriteLinks" example:"true"`
+}
+
+// ts_headline options for search results: <mark> around matches, short fragments for snippets
+const (
+	titleHeadlineOptions   = "StartSel=<mark>, StopSel=</mark>, HighlightAll=true"
+	contentHeadlineOptions = "StartSel=<mark>, StopSel=</mark>, MaxFragments=2, MaxWords=30, MinWords=10, FragmentDelimiter=\" ... \""
+)
+
+// errNoteVersionMismatch aborts a note transaction when If-Match does not match the stored version
+var errNoteVersionMismatch = errors.New("note version mismatch")
+
+// noteETag builds the entity tag of a note from its id and version
+func noteETag(note models.Note) string {
+	return fmt.Sprintf("\"note-%d-v%d\"", note.ID, note.Version)
+}
+
+// ifMatchSatisfied checks an If-Match header against etag (strong comparison). No header always matches.
+func ifMatchSatisfied(header, etag string) bool {
+	if header == "" {
+		return true
+	}
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || candidate == etag {
+			return true
+		}
+	}
+	return false
+}
+
+// respondNoteVersionMismatch sends 412 Precondition Failed with the current server copy of the note
+func respondNoteVersionMismatch(c *gin.Context, noteID uint) {
+	var current models.Note
+	if err := config.DB.Preload("Tags", orderTagsByName).First(&current, noteID).Error; err != nil {
+		c.JSON(http.StatusPreconditionFailed, gin.H{"error": "Note has been modified since it was loaded"})
+		return
+	}
+	c.Header("ETag", noteETag(current))
+	c.JSON(http.StatusPreconditionFailed, gin.H{"error": "Note has been modified since it was loaded", "current": current})
 }
 
 // GetNotes godoc
 // @Summary Get all notes for the authenticated user
-// @Description Retrieves a paginated list of notes for the current user
+// @Description Retrieves a paginated list of notes for the current user.
+// @Description With `q` the notes are filtered by full-text search over title and content, ranked by relevance
+// @Description and returned with highlighted `titleHighlight` and `snippet` fields (matches wrapped in <mark>).
 // @Tags notes
 // @Produce json
 // @Security BearerAuth
-// @Param limit query int false "Limit per page" default(10)
-// @Param offset query int false "Offset for pagination" default(0)
-// @Success 200 {object} handlers.PaginatedNotesResponse "A list of notes with total count"
-// @Failure 400 {object} object "Invalid limit or offset parameters (e.g., {\"error\": \"Invalid limit or offset parameters\"})"
+// @Description Pagination is either by limit/offset (with totalCount) or, when the cursor parameter is present,
+// @Description by an opaque cursor: pass an empty cursor for the first page and then the returned nextCursor.
+// @Description Cursor pages skip the total count and return tagCounts only on the first page.
+// @Param limit query int false "Limit per page (1-100 in cursor mode)" default(10)
+// @Param offset query int false "Offset for pagination, ignored in cursor mode" default(0)
+// @Param cursor query string false "Cursor from nextCursor of the previous page, empty for the first page"
+// @Param sort query string false "Sort field; defaults to relevance when searching, otherwise createdAt" Enums(createdAt, updatedAt, title)
+// @Param order query string false "Sort direction; defaults to desc for dates and asc for title" Enums(asc, desc)
+// @Param q query string false "Full-text search query (websearch syntax: phrases in quotes, OR, -word)"
+// @Param lang query string false "Text search configuration for the query; defaults to each note's own language" Enums(russian, english, simple)
+// @Param tag query []string false "Filter by tag name, can be repeated" collectionFormat(multi)
+// @Param tagMode query string false "How multiple tags are combined: and (all tags) or or (any tag)" Enums(and, or) default(and)
+// @Param notebookId query int false "Only notes of this notebook, 0 for notes outside notebooks"
+// @Param includeSubNotebooks query bool false "With notebookId, also include notes of all sub-notebooks" default(false)
+// @Success 200 {object} handlers.PaginatedNotesResponse "A list of notes with total count and tag usage counts"
+// @Failure 400 {object} object "Invalid pagination or sort parameters (e.g., {\"error\": \"Invalid limit or offset parameters\"})"
 // @Failure 401 {object} object "Unauthorized"
 // @Failure 500 {object} object "Internal server error (e.g., {\"error\": \"Failed to count notes\"})"
 // @Router /notes [get]
 func GetNotes(c *gin.Context) {
 	userID, _ := c.Get("userID")
 
-	limitQuery := c.DefaultQuery("limit", "10")
-	offsetQuery := c.DefaultQuery("offset", "0")
+	cursor, cursorMode := c.GetQuery("cursor")
+	var limit, offset int
+	if cursorMode {
+		var err error
+		if limit, err = parseCursorLimit(c); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid limit parameter", "details": err.Error()})
+			return
+		}
+	} else {
+		limitQuery := c.DefaultQuery("limit", "10")
+		offsetQuery := c.DefaultQuery("offset", "0")
 
-	limit, errLimit := strconv.Atoi(limitQuery)
-	offset, errOffset := strconv.Atoi(offsetQuery)
+		var errLimit, errOffset error
+		limit, errLimit = strconv.Atoi(limitQuery)
+		offset, errOffset = strconv.Atoi(offsetQuery)
 
-	if errLimit != nil || errOffset != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid limit or offset parameters"})
+		if errLimit != nil || errOffset != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid limit or offset parameters"})
+			return
+		}
+	}
+
+	search := strings.TrimSpace(c.Query("q"))
+	lang := c.Query("lang")
+	if lang != "" && !models.IsNoteLanguage(lang) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Unsupported search language", "supported": models.NoteLanguages})
 		return
 	}
 
+	tagNames := normalizeTagNames(c.QueryArray("tag"))
+	tagMode := c.DefaultQuery("tagMode", "and")
+	if tagMode != "and" && tagMode != "or" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "tagMode must be 'and' or 'or'"})
+		return
+	}
+
+	sort, err := parseListSort(c, "notes", "createdAt")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid sort parameters", "details": err.Error()})
+		return
+	}
+	byRelevance := search != "" && c.Query("sort") == ""
+	if byRelevance && cursorMode {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Cursor pagination of search results requires a sort parameter"})
+		return
+	}
+
+	query := config.DB.Model(&models.Note{}).Where("notes.user_id = ?", userID)
+
+	if notebookQuery := c.Query("notebookId"); notebookQuery != "" {
+		notebookID, err := strconv.ParseUint(notebookQuery, 10, 64)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid notebookId parameter"})
+			return
+		}
+		switch {
+		case notebookID == 0:
+			query = query.Where("notes.notebook_id IS NULL")
+		case c.Query("includeSubNotebooks") == "true":
+			query = query.Where("notes.notebook_id IN (?)", notebookSubtree(config.DB, userID, notebookID))
+		default:
+			query = query.Where("notes.notebook_id = ?", notebookID)
+		}
+	}
+
+	if len(tagNames) > 0 {
+		taggedNotes := config.DB.Table("note_tags").
+			Select("note_tags.note_id").
+			Joins("JOIN tags ON tags.id = note_tags.tag_id").
+			Where("tags.user_id = ? AND tags.name IN ?", userID, tagNames)
+		if tagMode == "and" {
+			taggedNotes = taggedNotes.Group("note_tags.note_id").Having("COUNT(DISTINCT tags.id) = ?", len(tagNames))
+		}
+		query = query.Where("notes.id IN (?)", taggedNotes)
+	}
+
+	var tsQuery, searchConfig interface{}
+	if search != "" {
+		// Without explicit lang the query is stemmed with the language of each note
+		searchConfig = gorm.Expr("notes.language")
+		if lang != "" {
+			searchConfig = gorm.Expr("?::regconfig", lang)
+		}
+		tsQuery = gorm.Expr("websearch_to_tsquery(?, ?)", searchConfig, search)
+		query = query.Where("notes.search_vector @@ ?", tsQuery)
+	}
+	query = query.Session(&gorm.Session{}) // Safe to reuse for count and select
+
 	var notes []models.Note
 	var totalCount int64
 
-	// Get total count first
-	if err := config.DB.Model(&models.Note{}).Where("user_id = ?", userID).Count(&totalCount).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to count notes", "details": err.Error()})
-		return
+	// Get total count first (cursor pages skip it, it gets slow for large collections)
+	if !cursorMode {
+		if err := query.Count(&totalCount).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to count notes", "details": err.Error()})
+			return
+		}
 	}
 
 	// Then get paginated notes
-	if err := config.DB.Where("user_id = ?", userID).Order("created_at DESC").Limit(limit).Offset(offset).Find(&notes).Error; err != nil {
+	listQuery := query
+	if search != "" {
+		listQuery = query.
+			Select("notes.*, ts_rank_cd(notes.search_vector, ?) AS rank, ts_headline(?, coalesce(notes.title, ''), ?, ?) AS title_highlight, ts_headline(?, notes.content, ?, ?) AS snippet",
+				tsQuery, searchConfig, tsQuery, titleHeadlineOptions, searchConfig, tsQuery, contentHeadlineOptions)
+	}
+	if byRelevance {
+		listQuery = listQuery.Order("rank DESC, notes.created_at DESC, notes.id DESC")
+	} else {
+		listQuery = sort.Order(listQuery)
+	}
+	if cursorMode {
+		if listQuery, err = sort.After(listQuery, cursor); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid cursor", "details": "the cursor is malformed or belongs to another sort order"})
+			return
+		}
+		listQuery = listQuery.Limit(limit + 1) // One extra row tells whether there is a next page
+	} else {
+		listQuery = listQuery.Limit(limit).Offset(offset)
+	}
+	if err := listQuery.Preload("Tags", orderTagsByName).Find(&notes).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve notes", "details": err.Error()})
 		return
 	}
 
 	// GORM AfterFind hook in models/note.go will set the "Date" field
 
-	c.JSON(http.StatusOK, gin.H{"notes": notes, "totalCount": totalCount})
+	hasMore := int64(offset+len(notes)) < totalCount
+	if cursorMode {
+		hasMore = len(notes) > limit
+		if hasMore {
+			notes = notes[:limit]
+		}
+	}
+	var nextCursor *string
+	if hasMore && !byRelevance && len(notes) > 0 {
+		last := notes[len(notes)-1]
+		next := sort.Cursor(last.ID, last.CreatedAt, last.UpdatedAt, last.Title)
+		nextCursor = &next
+	}
+
+	response := gin.H{"notes": notes, "nextCursor": nextCursor}
+	if !cursorMode {
+		response["totalCount"] = totalCount
+	}
+
+	// Tag usage over all notes matching the filters (not only the current page)
+	if !cursorMode || cursor == "" {
+		tagCounts := []models.TagUsage{}
+		if err := tagUsageQuery(userID, query.Select("notes.id")).Scan(&tagCounts).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to count tags", "details": err.Error()})
+			return
+		}
+		response["tagCounts"] = tagCounts
+	}
+
+	c.JSON(http.StatusOK, response)
 }
 
 // CreateNote godoc
@@ -89,18 +282,42 @@ func CreateNote(c *gin.Context) {
 	}
 
 	note := models.Note{
-		UserID:  userID.(uint),
-		Title:   input.Title,
-		Content: input.Content,
+		UserID:     userID.(uint),
+		NotebookID: input.NotebookID,
+		Title:      input.Title,
+		Content:    input.Content,
+		Language:   input.Language, // Empty means the database default
 	}
 
-	if err := config.DB.Create(&note).Error; err != nil {
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		if err := checkNotebookOwner(tx, note.UserID, note.NotebookID); err != nil {
+			return err
+		}
+		tags, err := findOrCreateTags(tx, note.UserID, input.Tags)
+		if err != nil {
+			return err
+		}
+		note.Tags = tags
+		if err := tx.Create(&note).Error; err != nil {
+			return err
+		}
+		if err := syncNoteLinks(tx, note); err != nil {
+			return err
+		}
+		return resolvePendingNoteLinks(tx, note)
+	})
+	if errors.Is(err, errNotebookNotFound) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Notebook not found or access denied"})
+		return
+	}
+	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create note", "details": err.Error()})
 		return
 	}
 
 	note.Date = note.CreatedAt.Format("2006-01-02 15:04")
 
+	c.Header("ETag", noteETag(note))
 	c.JSON(http.StatusCreated, note)
 }
 
@@ -112,6 +329,7 @@ func CreateNote(c *gin.Context) {
 // @Security BearerAuth
 // @Param id path int true "Note ID"
 // @Success 200 {object} models.Note
+// @Header 200 {string} ETag "Entity tag of the note version, send it back in If-Match"
 // @Failure 401 {object} object "Unauthorized"
 // @Failure 404 {object} object "Note not found or access denied (e.g., {\"error\": \"Note not found or access denied\"})"
 // @Failure 500 {object} object "Internal server error"
@@ -121,27 +339,33 @@ func GetNote(c *gin.Context) {
 	noteID := c.Param("id")
 
 	var note models.Note
-	if err := config.DB.Where("id = ? AND user_id = ?", noteID, userID).First(&note).Error; err != nil {
+	if err := config.DB.Preload("Tags", orderTagsByName).Where("id = ? AND user_id = ?", noteID, userID).First(&note).Error; err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Note not found or access denied"})
 		return
 	}
 	note.Date = note.CreatedAt.Format("2006-01-02 15:04")
+	c.Header("ETag", noteETag(note))
 	c.JSON(http.StatusOK, note)
 }
 
 // UpdateNote godoc
 // @Summary Update an existing note
-// @Description Updates a specific note by its ID, if it belongs to the authenticated user
+// @Description Updates a specific note by its ID, if it belongs to the authenticated user.
+// @Description The previous title and content are kept as a revision (see /notes/{id}/revisions).
+// @Description [[Note Title]] and [[id:123]] references in the content are stored as links (see /notes/{id}/backlinks).
 // @Tags notes
 // @Accept json
 // @Produce json
 // @Security BearerAuth
 // @Param id path int true "Note ID"
+// @Param If-Match header string false "ETag of the note version being edited"
 // @Param note body UpdateNoteInput true "Updated note data"
 // @Success 200 {object} models.Note
+// @Header 200 {string} ETag "Entity tag of the new note version"
 // @Failure 400 {object} object "Invalid input"
 // @Failure 401 {object} object "Unauthorized"
 // @Failure 404 {object} object "Note not found or access denied"
+// @Failure 412 {object} object "Note was modified in the meantime, the body contains the current copy (e.g., {\"error\": \"...\", \"current\": {...}})"
 // @Failure 500 {object} object "Internal server error (e.g., {\"error\": \"Failed to update note\"})"
 // @Router /notes/{id} [put]
 func UpdateNote(c *gin.Context) {
@@ -160,29 +384,92 @@ func UpdateNote(c *gin.Context) {
 		return
 	}
 
-	// note.Title = input.Title
-	// note.Content = input.Content
-	updateData := models.Note{Title: input.Title, Content: input.Content}
+	// Only non-empty fields are updated, the version is incremented in the same UPDATE
+	updateData := map[string]interface{}{"version": gorm.Expr("version + 1")}
+	if input.Title != "" {
+		updateData["title"] = input.Title
+	}
+	if input.Content != "" {
+		updateData["content"] = input.Content
+	}
+	if input.Language != "" {
+		updateData["language"] = input.Language
+	}
+	ifMatch := c.GetHeader("If-Match")
+
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		if err := lockNote(tx, note.ID, userID, &note); err != nil {
+			return err
+		}
+		if !ifMatchSatisfied(ifMatch, noteETag(note)) {
+			return errNoteVersionMismatch
+		}
+		oldTitle := note.Title
+		// Keep the previous text as a revision when title or content actually change
+		if (input.Title != "" && input.Title != note.Title) || (input.Content != "" && input.Content != note.Content) {
+			if err := saveNoteRevision(tx, note); err != nil {
+				return err
+			}
+		}
+		result := tx.Model(&note).Where("version = ?", note.Version).Updates(updateData)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return errNoteVersionMismatch
+		}
+		if input.Tags != nil {
+			tags, err := findOrCreateTags(tx, note.UserID, input.Tags)
+			if err != nil {
+				return err
+			}
+			if err := tx.Model(&note).Association("Tags").Replace(tags); err != nil {
+				return err
+			}
+		}
 
-	if err := config.DB.Model(note).Updates(updateData).Error; err != nil {
+		// Wiki links: outgoing links follow the new content, incoming ones follow the new title
+		if err := tx.First(&note, note.ID).Error; err != nil {
+			return err
+		}
+		if input.Content != "" {
+			if err := syncNoteLinks(tx, note); err != nil {
+				return err
+			}
+		}
+		if note.Title != oldTitle {
+			if err := updateLinksToRenamedNote(tx, note, oldTitle, input.RewriteLinks); err != nil {
+				return err
+			}
+		}
+		return tx.Preload("Tags", orderTagsByName).First(&note, note.ID).Error
+	})
+	if errors.Is(err, errNoteVersionMismatch) {
+		respondNoteVersionMismatch(c, note.ID)
+		return
+	}
+	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update note", "details": err.Error()})
 		return
 	}
 	note.Date = note.UpdatedAt.Format("2006-01-02 15:04")
 
+	c.Header("ETag", noteETag(note))
 	c.JSON(http.StatusOK, note)
 }
 
 // DeleteNote godoc
 // @Summary Delete a note by ID
-// @Description Deletes a specific note by its ID, if it belongs to the authenticated user
+// @Description Moves a specific note to the trash, if it belongs to the authenticated user (see /trash)
 // @Tags notes
 // @Produce json
 // @Security BearerAuth
 // @Param id path int true "Note ID"
-// @Success 200 {object} object "Note deleted successfully (e.g., {\"message\": \"Note deleted successfully\"})"
+// @Param If-Match header string false "ETag of the note version being deleted"
+// @Success 200 {object} object "Note moved to trash (e.g., {\"message\": \"Note moved to trash\"})"
 // @Failure 401 {object} object "Unauthorized"
 // @Failure 404 {object} object "Note not found or access denied"
+// @Failure 412 {object} object "Note was modified in the meantime, the body contains the current copy"
 // @Failure 500 {object} object "Internal server error (e.g., {\"error\": \"Failed to delete note\"})"
 // @Router /notes/{id} [delete]
 func DeleteNote(c *gin.Context) {
@@ -196,11 +483,25 @@ func DeleteNote(c *gin.Context) {
 		return
 	}
 
-	// If found, delete it
-	if err := config.DB.Delete(&models.Note{}, noteID).Error; err != nil { // Delete by primary key
+	// If found and not modified meanwhile, move it to trash (soft delete)
+	ifMatch := c.GetHeader("If-Match")
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		if err := lockNote(tx, note.ID, userID, &note); err != nil {
+			return err
+		}
+		if !ifMatchSatisfied(ifMatch, noteETag(note)) {
+			return errNoteVersionMismatch
+		}
+		return tx.Delete(&models.Note{}, note.ID).Error // Delete by primary key
+	})
+	if errors.Is(err, errNoteVersionMismatch) {
+		respondNoteVersionMismatch(c, note.ID)
+		return
+	}
+	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete note", "details": err.Error()})
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"message": "Note deleted successfully"})
+	c.JSON(http.StatusOK, gin.H{"message": "Note moved to trash"})
 }
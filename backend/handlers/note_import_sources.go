@@ -0,0 +1,368 @@
+package handlers
+
+import (
+	"archive/zip"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"organizer-backend/utils"
+	"path"
+	"strings"
+	"time"
+
+	"golang.org/x/net/html"
+)
+
+// enexTimeLayout is the timestamp format of Evernote exports
+const enexTimeLayout = "20060102T150405Z"
+
+// enexNote holds the fields of an Evernote <note> element that are imported
+type enexNote struct {
+	Title   string
+	Content string // ENML
+	Created string
+	Updated string
+	Tags    []string
+}
+
+// importEvernoteExport streams an .enex file note by note, so attachments are never held in memory.
+// Notes are placed in a notebook named after the file, as Evernote exports one notebook per file.
+func importEvernoteExport(importer *noteImporter, file multipart.File, header *multipart.FileHeader) error {
+	notebook := strings.TrimSuffix(header.Filename, path.Ext(header.Filename))
+	decoder := xml.NewDecoder(file)
+
+	exportFound, index := false, 0
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			if !exportFound {
+				return err
+			}
+			// Notes read so far are kept, the rest of the file is unusable
+			importer.Fail(header.Filename, fmt.Errorf("malformed XML after note %d: %w", index, err))
+			return nil
+		}
+
+		start, ok := token.(xml.StartElement)
+		if !ok {
+			continue
+		}
+		switch start.Name.Local {
+		case "en-export":
+			exportFound = true
+		case "note":
+			index++
+			source := fmt.Sprintf("%s: note %d", header.Filename, index)
+			raw, err := readEvernoteNote(decoder)
+			if err != nil {
+				importer.Fail(source, fmt.Errorf("malformed XML: %w", err))
+				return nil
+			}
+			if raw.Title != "" {
+				source = header.Filename + ": " + raw.Title
+			}
+			note, err := convertEvernoteNote(raw)
+			if err != nil {
+				importer.Fail(source, err)
+				continue
+			}
+			note.Source = source
+			if notebook != "" {
+				note.NotebookPath = []string{notebook}
+			}
+			importer.Add(note)
+		}
+	}
+
+	if !exportFound {
+		return fmt.Errorf("not an Evernote export: en-export element is missing")
+	}
+	return nil
+}
+
+// readEvernoteNote reads the children of a <note> element, skipping resources
+func readEvernoteNote(decoder *xml.Decoder) (enexNote, error) {
+	var note enexNote
+	for {
+		token, err := decoder.Token()
+		if err != nil {
+			return note, err
+		}
+		switch element := token.(type) {
+		case xml.EndElement:
+			return note, nil
+		case xml.StartElement:
+			var target *string
+			switch element.Name.Local {
+			case "title":
+				target = &note.Title
+			case "content":
+				target = &note.Content
+			case "created":
+				target = &note.Created
+			case "updated":
+				target = &note.Updated
+			case "tag":
+				var tag string
+				if err := decoder.DecodeElement(&tag, &element); err != nil {
+					return note, err
+				}
+				note.Tags = append(note.Tags, tag)
+				continue
+			default: // resource, note-attributes, ...
+				if err := decoder.Skip(); err != nil {
+					return note, err
+				}
+				continue
+			}
+			if err := decoder.DecodeElement(target, &element); err != nil {
+				return note, err
+			}
+		}
+	}
+}
+
+func convertEvernoteNote(raw enexNote) (importedNote, error) {
+	note := importedNote{Title: strings.TrimSpace(raw.Title), Tags: raw.Tags}
+	if len(raw.Content) > maxImportNoteSize {
+		return note, fmt.Errorf("note content exceeds %d MB", maxImportNoteSize>>20)
+	}
+
+	content, err := utils.HTMLToMarkdown(raw.Content)
+	if err != nil {
+		return note, fmt.Errorf("invalid ENML content: %w", err)
+	}
+	note.Content = content
+
+	if raw.Created != "" {
+		if note.CreatedAt, err = time.Parse(enexTimeLayout, raw.Created); err != nil {
+			return note, fmt.Errorf("invalid created timestamp: %w", err)
+		}
+	}
+	if raw.Updated != "" {
+		if note.UpdatedAt, err = time.Parse(enexTimeLayout, raw.Updated); err != nil {
+			return note, fmt.Errorf("invalid updated timestamp: %w", err)
+		}
+	}
+	if note.Title == "" {
+		note.Title = titleFromContent(note.Content)
+	}
+	return note, nil
+}
+
+// keepNote is the JSON file Google Takeout writes for every Keep note
+type keepNote struct {
+	Title           string `json:"title"`
+	TextContent     string `json:"textContent"`
+	TextContentHTML string `json:"textContentHtml"`
+	ListContent     []struct {
+		Text      string `json:"text"`
+		IsChecked bool   `json:"isChecked"`
+	} `json:"listContent"`
+	Labels []struct {
+		Name string `json:"name"`
+	} `json:"labels"`
+	IsTrashed               bool  `json:"isTrashed"`
+	CreatedTimestampUsec    int64 `json:"createdTimestampUsec"`
+	UserEditedTimestampUsec int64 `json:"userEditedTimestampUsec"`
+}
+
+// importKeepTakeout reads a Google Takeout zip with Keep notes. The JSON file of a note is preferred,
+// the HTML file is only used for notes without one (older Takeout archives). Trashed notes are ignored.
+func importKeepTakeout(importer *noteImporter, file multipart.File, header *multipart.FileHeader) error {
+	archive, err := zip.NewReader(file, header.Size)
+	if err != nil {
+		return err
+	}
+
+	withJSON := map[string]bool{}
+	for _, entry := range archive.File {
+		if strings.EqualFold(path.Ext(entry.Name), ".json") {
+			withJSON[strings.TrimSuffix(entry.Name, path.Ext(entry.Name))] = true
+		}
+	}
+
+	for _, entry := range archive.File {
+		if entry.FileInfo().IsDir() || hiddenArchivePath(entry.Name) {
+			continue
+		}
+		var (
+			note    importedNote
+			trashed bool
+			err     error
+		)
+		switch ext := strings.ToLower(path.Ext(entry.Name)); {
+		case ext == ".json":
+			note, trashed, err = readKeepJSONNote(entry)
+		case ext == ".html" && !withJSON[strings.TrimSuffix(entry.Name, path.Ext(entry.Name))]:
+			note, err = readKeepHTMLNote(entry)
+		default:
+			continue
+		}
+		if err != nil {
+			importer.Fail(entry.Name, err)
+			continue
+		}
+		if trashed {
+			continue
+		}
+		note.Source = entry.Name
+		importer.Add(note)
+	}
+	return nil
+}
+
+// readArchiveEntry reads a file of an uploaded archive, up to maxImportNoteSize
+func readArchiveEntry(entry *zip.File) ([]byte, error) {
+	if entry.UncompressedSize64 > maxImportNoteSize {
+		return nil, fmt.Errorf("file exceeds %d MB", maxImportNoteSize>>20)
+	}
+	reader, err := entry.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Close()
+	data, err := io.ReadAll(io.LimitReader(reader, maxImportNoteSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(data) > maxImportNoteSize {
+		return nil, fmt.Errorf("file exceeds %d MB", maxImportNoteSize>>20)
+	}
+	return data, nil
+}
+
+func readKeepJSONNote(entry *zip.File) (importedNote, bool, error) {
+	var note importedNote
+	data, err := readArchiveEntry(entry)
+	if err != nil {
+		return note, false, err
+	}
+	var keep keepNote
+	if err := json.Unmarshal(data, &keep); err != nil {
+		return note, false, fmt.Errorf("invalid Keep note: %w", err)
+	}
+	if keep.IsTrashed {
+		return note, true, nil
+	}
+
+	switch {
+	case len(keep.ListContent) > 0:
+		items := make([]string, len(keep.ListContent))
+		for i, item := range keep.ListContent {
+			mark := " "
+			if item.IsChecked {
+				mark = "x"
+			}
+			items[i] = fmt.Sprintf("- [%s] %s", mark, item.Text)
+		}
+		note.Content = strings.Join(items, "\n")
+	case keep.TextContentHTML != "":
+		if note.Content, err = utils.HTMLToMarkdown(keep.TextContentHTML); err != nil {
+			return note, false, fmt.Errorf("invalid note HTML: %w", err)
+		}
+	default:
+		note.Content = keep.TextContent
+	}
+
+	note.Title = strings.TrimSpace(keep.Title)
+	if note.Title == "" {
+		note.Title = titleFromContent(note.Content)
+	}
+	for _, label := range keep.Labels {
+		note.Tags = append(note.Tags, label.Name)
+	}
+	if keep.CreatedTimestampUsec > 0 {
+		note.CreatedAt = time.UnixMicro(keep.CreatedTimestampUsec)
+	}
+	if keep.UserEditedTimestampUsec > 0 {
+		note.UpdatedAt = time.UnixMicro(keep.UserEditedTimestampUsec)
+	}
+	return note, false, nil
+}
+
+// readKeepHTMLNote extracts title, content and labels from the HTML page Takeout writes for a note
+func readKeepHTMLNote(entry *zip.File) (importedNote, error) {
+	var note importedNote
+	data, err := readArchiveEntry(entry)
+	if err != nil {
+		return note, err
+	}
+	doc, err := html.Parse(strings.NewReader(string(data)))
+	if err != nil {
+		return note, fmt.Errorf("invalid note HTML: %w", err)
+	}
+
+	var contentHTML strings.Builder
+	var walk func(*html.Node)
+	walk = func(n *html.Node) {
+		if n.Type == html.ElementNode {
+			for _, class := range strings.Fields(htmlAttr(n, "class")) {
+				switch class {
+				case "title":
+					note.Title = strings.TrimSpace(htmlText(n))
+					return
+				case "content":
+					html.Render(&contentHTML, n)
+					return
+				case "label-name":
+					note.Tags = append(note.Tags, strings.TrimSpace(htmlText(n)))
+					return
+				}
+			}
+		}
+		for child := n.FirstChild; child != nil; child = child.NextSibling {
+			walk(child)
+		}
+	}
+	walk(doc)
+
+	if note.Content, err = utils.HTMLToMarkdown(contentHTML.String()); err != nil {
+		return note, fmt.Errorf("invalid note HTML: %w", err)
+	}
+	if note.Title == "" {
+		note.Title = titleFromContent(note.Content)
+	}
+	return note, nil
+}
+
+func htmlAttr(n *html.Node, key string) string {
+	for _, a := range n.Attr {
+		if a.Key == key {
+			return a.Val
+		}
+	}
+	return ""
+}
+
+func htmlText(n *html.Node) string {
+	if n.Type == html.TextNode {
+		return n.Data
+	}
+	var sb strings.Builder
+	for child := n.FirstChild; child != nil; child = child.NextSibling {
+		sb.WriteString(htmlText(child))
+	}
+	return sb.String()
+}
+
+// titleFromContent uses the first non-empty line as title for untitled notes
+func titleFromContent(content string) string {
+	for _, line := range strings.Split(content, "\n") {
+		line = strings.TrimLeft(line, "#>-* ")
+		line = strings.TrimSpace(strings.TrimPrefix(strings.TrimPrefix(line, "[ ] "), "[x] "))
+		if line == "" {
+			continue
+		}
+		if runes := []rune(line); len(runes) > 80 {
+			line = string(runes[:80]) + "…"
+		}
+		return line
+	}
+	return "Untitled"
+}
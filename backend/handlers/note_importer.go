@@ -0,0 +1,239 @@
+package handlers
+
+import (
+	"fmt"
+	"organizer-backend/config"
+	"organizer-backend/models"
+	"sort"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// importBatchSize is the number of notes stored per transaction during imports
+const importBatchSize = 50
+
+// NoteImportError describes a note that could not be imported
+type NoteImportError struct {
+	Source string `json:"source" example:"Work/Meeting.md"` // File or entry the note came from
+	Error  string `json:"error" example:"invalid front matter"`
+}
+
+// NoteImportResponse summarises an import run
+type NoteImportResponse struct {
+	Created int               `json:"created" example:"10"`
+	Updated int               `json:"updated" example:"2"`
+	Skipped int               `json:"skipped" example:"1"` // Notes identical to the stored version
+	Failed  int               `json:"failed" example:"0"`
+	Errors  []NoteImportError `json:"errors"`
+}
+
+// importedNote is a note read from an import source, before it is stored
+type importedNote struct {
+	Source       string // File or entry name, used in error reports
+	ID           uint   // Id from a previous export, 0 if unknown
+	Title        string
+	Content      string
+	CreatedAt    time.Time
+	UpdatedAt    time.Time
+	Tags         []string
+	NotebookPath []string // Folder path, stored as nested notebooks
+}
+
+// noteImporter upserts imported notes in batches. Every batch runs in its own transaction and every
+// note in a savepoint, so a broken note is reported without losing the rest of the batch and a failed
+// batch can simply be imported again.
+type noteImporter struct {
+	userID uint
+	batch  []importedNote
+	result NoteImportResponse
+}
+
+func newNoteImporter(userID uint) *noteImporter {
+	return &noteImporter{userID: userID, result: NoteImportResponse{Errors: []NoteImportError{}}}
+}
+
+// Add queues a parsed note, storing the batch when it is full
+func (im *noteImporter) Add(note importedNote) {
+	im.batch = append(im.batch, note)
+	if len(im.batch) >= importBatchSize {
+		im.Flush()
+	}
+}
+
+// Fail records a source entry that could not be parsed
+func (im *noteImporter) Fail(source string, err error) {
+	im.result.Failed++
+	im.result.Errors = append(im.result.Errors, NoteImportError{Source: source, Error: err.Error()})
+}
+
+// Flush stores the queued notes in one transaction
+func (im *noteImporter) Flush() {
+	if len(im.batch) == 0 {
+		return
+	}
+	batch := im.batch
+	im.batch = nil
+
+	var counts NoteImportResponse
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		for _, note := range batch {
+			var outcome string
+			err := tx.Transaction(func(sp *gorm.DB) error {
+				var err error
+				outcome, err = upsertImportedNote(sp, im.userID, note)
+				return err
+			})
+			switch {
+			case err != nil:
+				counts.Failed++
+				counts.Errors = append(counts.Errors, NoteImportError{Source: note.Source, Error: err.Error()})
+			case outcome == "created":
+				counts.Created++
+			case outcome == "updated":
+				counts.Updated++
+			default:
+				counts.Skipped++
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		for _, note := range batch {
+			im.Fail(note.Source, fmt.Errorf("batch rolled back: %w", err))
+		}
+		return
+	}
+
+	im.result.Created += counts.Created
+	im.result.Updated += counts.Updated
+	im.result.Skipped += counts.Skipped
+	im.result.Failed += counts.Failed
+	im.result.Errors = append(im.result.Errors, counts.Errors...)
+}
+
+// Result flushes the last batch and returns the totals
+func (im *noteImporter) Result() NoteImportResponse {
+	im.Flush()
+	return im.result
+}
+
+// upsertImportedNote creates the note or updates the matching existing note (same id, or same title
+// in the same notebook and, when known, the same creation time). Returns "created", "updated" or "skipped".
+func upsertImportedNote(tx *gorm.DB, userID uint, in importedNote) (string, error) {
+	if strings.TrimSpace(in.Title) == "" && strings.TrimSpace(in.Content) == "" {
+		return "", fmt.Errorf("note has neither title nor content")
+	}
+
+	notebookID, err := findOrCreateNotebookPath(tx, userID, in.NotebookPath)
+	if err != nil {
+		return "", err
+	}
+	tags, err := findOrCreateTags(tx, userID, in.Tags)
+	if err != nil {
+		return "", err
+	}
+
+	var existing []models.Note
+	if in.ID != 0 {
+		if err := tx.Preload("Tags").Where("id = ? AND user_id = ?", in.ID, userID).Limit(1).Find(&existing).Error; err != nil {
+			return "", err
+		}
+	}
+	if len(existing) == 0 && in.Title != "" {
+		query := tx.Preload("Tags").Where("user_id = ? AND title = ?", userID, in.Title)
+		if notebookID == nil {
+			query = query.Where("notebook_id IS NULL")
+		} else {
+			query = query.Where("notebook_id = ?", *notebookID)
+		}
+		if !in.CreatedAt.IsZero() { // Evernote and Keep often have several notes with the same title
+			query = query.Where("created_at = ?", in.CreatedAt)
+		}
+		if err := query.Order("id").Limit(1).Find(&existing).Error; err != nil {
+			return "", err
+		}
+	}
+
+	if len(existing) == 0 {
+		note := models.Note{
+			UserID:     userID,
+			NotebookID: notebookID,
+			Title:      in.Title,
+			Content:    in.Content,
+			CreatedAt:  in.CreatedAt, // Zero values are filled by GORM
+			UpdatedAt:  in.UpdatedAt,
+			Tags:       tags,
+		}
+		if err := tx.Create(&note).Error; err != nil {
+			return "", err
+		}
+		if err := syncNoteLinks(tx, note); err != nil {
+			return "", err
+		}
+		return "created", resolvePendingNoteLinks(tx, note)
+	}
+
+	note := existing[0]
+	if note.Title == in.Title && note.Content == in.Content && sameNotebook(note.NotebookID, notebookID) && sameTagNames(note.Tags, tags) {
+		return "skipped", nil
+	}
+
+	if err := lockNote(tx, note.ID, userID, &note); err != nil {
+		return "", err
+	}
+	oldTitle := note.Title
+	if err := saveNoteRevision(tx, note); err != nil {
+		return "", err
+	}
+	if err := tx.Model(&note).Updates(map[string]interface{}{
+		"title":       in.Title,
+		"content":     in.Content,
+		"notebook_id": notebookID,
+		"version":     gorm.Expr("version + 1"),
+	}).Error; err != nil {
+		return "", err
+	}
+	if err := tx.Model(&note).Association("Tags").Replace(tags); err != nil {
+		return "", err
+	}
+	note.Title, note.Content = in.Title, in.Content
+	if err := syncNoteLinks(tx, note); err != nil {
+		return "", err
+	}
+	if note.Title != oldTitle {
+		if err := updateLinksToRenamedNote(tx, note, oldTitle, false); err != nil {
+			return "", err
+		}
+	}
+	return "updated", nil
+}
+
+func sameNotebook(a, b *uint) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+	return *a == *b
+}
+
+func sameTagNames(a, b []models.Tag) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	names := func(tags []models.Tag) []string {
+		result := make([]string, len(tags))
+		for i, tag := range tags {
+			result[i] = tag.Name
+		}
+		sort.Strings(result)
+		return result
+	}
+	namesA, namesB := names(a), names(b)
+	for i := range namesA {
+		if namesA[i] != namesB[i] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,219 @@
+package handlers
+
+import (
+	"net/http"
+	"organizer-backend/config"
+	"organizer-backend/models"
+	"organizer-backend/utils"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// NoteReference is a short description of a note used in link listings
+type NoteReference struct {
+	ID        uint      `json:"id" example:"7"`
+	Title     string    `json:"title" example:"Project ideas"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+// UnresolvedNoteLink is a [[...]] reference that does not point to an existing note
+type UnresolvedNoteLink struct {
+	SourceNoteID uint   `json:"sourceNoteId" example:"3"`
+	SourceTitle  string `json:"sourceTitle" example:"Daily log"`
+	Target       string `json:"target" example:"Meeting notes"`
+}
+
+type NoteGraphNode struct {
+	ID    uint   `json:"id" example:"3"`
+	Title string `json:"title" example:"Daily log"`
+}
+
+type NoteGraphEdge struct {
+	Source uint `json:"source" example:"3"`
+	Target uint `json:"target" example:"7"`
+}
+
+// NoteGraphResponse is the link graph of all notes of the user
+type NoteGraphResponse struct {
+	Nodes []NoteGraphNode `json:"nodes"`
+	Edges []NoteGraphEdge `json:"edges"`
+}
+
+// syncNoteLinks re-parses the note content and replaces its outgoing links
+func syncNoteLinks(tx *gorm.DB, note models.Note) error {
+	if err := tx.Where("source_note_id = ?", note.ID).Delete(&models.NoteLink{}).Error; err != nil {
+		return err
+	}
+
+	for _, ref := range utils.ParseWikiLinks(note.Content) {
+		link := models.NoteLink{UserID: note.UserID, SourceNoteID: note.ID, Target: ref.Target}
+
+		var targets []models.Note
+		query := tx.Select("id").Where("user_id = ?", note.UserID)
+		if ref.NoteID != 0 {
+			query = query.Where("id = ?", ref.NoteID)
+		} else {
+			query = query.Where("lower(title) = lower(?)", ref.Title).Order("id")
+		}
+		if err := query.Limit(1).Find(&targets).Error; err != nil {
+			return err
+		}
+		if len(targets) > 0 {
+			link.TargetNoteID = &targets[0].ID
+		}
+
+		if err := tx.Create(&link).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// resolvePendingNoteLinks points unresolved links with the note's title to the note
+func resolvePendingNoteLinks(tx *gorm.DB, note models.Note) error {
+	return tx.Model(&models.NoteLink{}).
+		Where("user_id = ? AND target_note_id IS NULL AND target NOT LIKE 'id:%' AND lower(target) = lower(?)", note.UserID, note.Title).
+		Update("target_note_id", note.ID).Error
+}
+
+// updateLinksToRenamedNote keeps title links consistent after a rename: with rewrite the [[Old Title]]
+// references in linking notes are replaced by the new title, otherwise they become unresolved.
+func updateLinksToRenamedNote(tx *gorm.DB, note models.Note, oldTitle string, rewrite bool) error {
+	if rewrite {
+		var sourceIDs []uint
+		if err := tx.Model(&models.NoteLink{}).
+			Where("target_note_id = ? AND target NOT LIKE 'id:%'", note.ID).
+			Distinct().Pluck("source_note_id", &sourceIDs).Error; err != nil {
+			return err
+		}
+
+		for _, sourceID := range sourceIDs {
+			var source models.Note
+			if err := lockNote(tx, sourceID, note.UserID, &source); err != nil {
+				if err == gorm.ErrRecordNotFound { // Source is in trash
+					continue
+				}
+				return err
+			}
+			content := utils.RewriteWikiLinks(source.Content, oldTitle, note.Title)
+			if content == source.Content {
+				continue
+			}
+			if err := saveNoteRevision(tx, source); err != nil {
+				return err
+			}
+			if err := tx.Model(&source).Updates(map[string]interface{}{"content": content, "version": gorm.Expr("version + 1")}).Error; err != nil {
+				return err
+			}
+			source.Content = content
+			if err := syncNoteLinks(tx, source); err != nil {
+				return err
+			}
+		}
+	}
+
+	// Links still written with another title no longer point to this note
+	if err := tx.Model(&models.NoteLink{}).
+		Where("target_note_id = ? AND target NOT LIKE 'id:%' AND lower(target) <> lower(?)", note.ID, note.Title).
+		Update("target_note_id", nil).Error; err != nil {
+		return err
+	}
+	return resolvePendingNoteLinks(tx, note)
+}
+
+// GetNoteBacklinks godoc
+// @Summary Get notes linking to a note
+// @Description Lists notes of the authenticated user that contain a [[...]] reference to the note
+// @Tags notes
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Note ID"
+// @Success 200 {array} handlers.NoteReference
+// @Failure 401 {object} object "Unauthorized"
+// @Failure 404 {object} object "Note not found or access denied"
+// @Failure 500 {object} object "Internal server error (e.g., {\"error\": \"Failed to retrieve backlinks\"})"
+// @Router /notes/{id}/backlinks [get]
+func GetNoteBacklinks(c *gin.Context) {
+	userID, _ := c.Get("userID")
+	noteID := c.Param("id")
+
+	var note models.Note
+	if err := config.DB.Where("id = ? AND user_id = ?", noteID, userID).First(&note).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Note not found or access denied"})
+		return
+	}
+
+	backlinks := []NoteReference{}
+	sources := config.DB.Model(&models.NoteLink{}).Select("source_note_id").Where("target_note_id = ?", note.ID)
+	if err := config.DB.Model(&models.Note{}).
+		Select("id, title, updated_at").
+		Where("user_id = ? AND id IN (?)", userID, sources).
+		Order("updated_at DESC").
+		Scan(&backlinks).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve backlinks", "details": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, backlinks)
+}
+
+// GetUnresolvedNoteLinks godoc
+// @Summary Get unresolved note links
+// @Description Lists [[...]] references in the user's notes that do not point to an existing note
+// @Tags notes
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {array} handlers.UnresolvedNoteLink
+// @Failure 401 {object} object "Unauthorized"
+// @Failure 500 {object} object "Internal server error (e.g., {\"error\": \"Failed to retrieve unresolved links\"})"
+// @Router /notes/unresolved-links [get]
+func GetUnresolvedNoteLinks(c *gin.Context) {
+	userID, _ := c.Get("userID")
+
+	links := []UnresolvedNoteLink{}
+	if err := config.DB.Table("note_links").
+		Select("note_links.source_note_id, notes.title AS source_title, note_links.target").
+		Joins("JOIN notes ON notes.id = note_links.source_note_id AND notes.deleted_at IS NULL").
+		Where("note_links.user_id = ? AND note_links.target_note_id IS NULL", userID).
+		Order("note_links.target, note_links.source_note_id").
+		Scan(&links).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve unresolved links", "details": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, links)
+}
+
+// GetNoteGraph godoc
+// @Summary Get the note link graph
+// @Description Returns all notes of the authenticated user as nodes and resolved [[...]] links between them as edges
+// @Tags notes
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} handlers.NoteGraphResponse
+// @Failure 401 {object} object "Unauthorized"
+// @Failure 500 {object} object "Internal server error (e.g., {\"error\": \"Failed to build note graph\"})"
+// @Router /notes/graph [get]
+func GetNoteGraph(c *gin.Context) {
+	userID, _ := c.Get("userID")
+
+	graph := NoteGraphResponse{Nodes: []NoteGraphNode{}, Edges: []NoteGraphEdge{}}
+	if err := config.DB.Model(&models.Note{}).Select("id, title").Where("user_id = ?", userID).Order("id").Scan(&graph.Nodes).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to build note graph", "details": err.Error()})
+		return
+	}
+
+	if err := config.DB.Table("note_links").
+		Select("DISTINCT note_links.source_note_id AS source, note_links.target_note_id AS target").
+		Joins("JOIN notes AS sources ON sources.id = note_links.source_note_id AND sources.deleted_at IS NULL").
+		Joins("JOIN notes AS targets ON targets.id = note_links.target_note_id AND targets.deleted_at IS NULL").
+		Where("note_links.user_id = ?", userID).
+		Scan(&graph.Edges).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to build note graph", "details": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, graph)
+}
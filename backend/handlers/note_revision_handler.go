@@ -0,0 +1,244 @@
+package handlers
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"organizer-backend/config"
+	"organizer-backend/models"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pmezard/go-difflib/difflib"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// NoteRevisionDiffResponse is a unified diff between a revision and the current note
+type NoteRevisionDiffResponse struct {
+	NoteID       uint   `json:"noteId" example:"1"`
+	Revision     int    `json:"revision" example:"3"`
+	TitleFrom    string `json:"titleFrom" example:"Old title"`
+	TitleTo      string `json:"titleTo" example:"New title"`
+	TitleChanged bool   `json:"titleChanged" example:"true"`
+	Diff         string `json:"diff" example:"--- revision 3\n+++ current\n@@ -1 +1 @@\n-old line\n+new line\n"`
+}
+
+// lockNote reloads the user's note inside tx with a row lock (SELECT ... FOR UPDATE)
+func lockNote(tx *gorm.DB, noteID interface{}, userID interface{}, note *models.Note) error {
+	return tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("id = ? AND user_id = ?", noteID, userID).First(note).Error
+}
+
+// saveNoteRevision stores the current title/content of the note as its next revision.
+// Must run in the transaction that changes the note, after lockNote.
+func saveNoteRevision(tx *gorm.DB, note models.Note) error {
+	var last int
+	if err := tx.Model(&models.NoteRevision{}).Select("COALESCE(MAX(revision), 0)").Where("note_id = ?", note.ID).Scan(&last).Error; err != nil {
+		return err
+	}
+
+	revision := models.NoteRevision{NoteID: note.ID, Revision: last + 1, Title: note.Title, Content: note.Content}
+	if err := tx.Create(&revision).Error; err != nil {
+		return err
+	}
+	return pruneNoteRevisions(tx, note.UserID, note.ID)
+}
+
+// pruneNoteRevisions applies the user's retention policy to one note (noteID) or to all of their notes (noteID = 0)
+func pruneNoteRevisions(tx *gorm.DB, userID uint, noteID uint) error {
+	var user models.User
+	if err := tx.Select("id, revision_limit, revision_max_age_days").First(&user, userID).Error; err != nil {
+		return err
+	}
+
+	notes := tx.Unscoped().Model(&models.Note{}).Select("id").Where("user_id = ?", userID) // Including notes in trash
+	if noteID != 0 {
+		notes = notes.Where("id = ?", noteID)
+	}
+
+	if user.RevisionMaxAgeDays > 0 {
+		cutoff := time.Now().AddDate(0, 0, -user.RevisionMaxAgeDays)
+		if err := tx.Where("note_id IN (?) AND created_at < ?", notes, cutoff).Delete(&models.NoteRevision{}).Error; err != nil {
+			return err
+		}
+	}
+
+	if user.RevisionLimit > 0 {
+		// Keep only the newest RevisionLimit revisions of every note
+		excess := tx.Table("(?) AS ranked", tx.Model(&models.NoteRevision{}).
+			Select("id, ROW_NUMBER() OVER (PARTITION BY note_id ORDER BY revision DESC) AS position").
+			Where("note_id IN (?)", notes)).
+			Select("id").Where("position > ?", user.RevisionLimit)
+		if err := tx.Where("id IN (?)", excess).Delete(&models.NoteRevision{}).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// StartRevisionPurger runs a background job that applies the max age of all retention policies, so
+// revisions of notes that are no longer edited expire too
+func StartRevisionPurger() {
+	go func() {
+		ticker := time.NewTicker(time.Hour)
+		defer ticker.Stop()
+		for ; true; <-ticker.C {
+			purgeExpiredNoteRevisions()
+		}
+	}()
+}
+
+func purgeExpiredNoteRevisions() {
+	expired := config.DB.Table("note_revisions").Select("note_revisions.id").
+		Joins("JOIN notes ON notes.id = note_revisions.note_id").
+		Joins("JOIN users ON users.id = notes.user_id").
+		Where("users.revision_max_age_days > 0 AND note_revisions.created_at < NOW() - make_interval(days => users.revision_max_age_days)")
+	result := config.DB.Where("id IN (?)", expired).Delete(&models.NoteRevision{})
+	if result.Error != nil {
+		log.Printf("Revision purger: failed to purge expired note revisions: %v", result.Error)
+		return
+	}
+	if result.RowsAffected > 0 {
+		log.Printf("Revision purger: deleted %d expired note revisions", result.RowsAffected)
+	}
+}
+
+// GetNoteRevisions godoc
+// @Summary Get the revision history of a note
+// @Description Lists saved revisions of the note, newest first. Content is omitted, use the diff endpoint to inspect changes.
+// @Tags notes
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Note ID"
+// @Success 200 {array} models.NoteRevision
+// @Failure 401 {object} object "Unauthorized"
+// @Failure 404 {object} object "Note not found or access denied"
+// @Failure 500 {object} object "Internal server error (e.g., {\"error\": \"Failed to retrieve revisions\"})"
+// @Router /notes/{id}/revisions [get]
+func GetNoteRevisions(c *gin.Context) {
+	userID, _ := c.Get("userID")
+	noteID := c.Param("id")
+
+	var note models.Note
+	if err := config.DB.Where("id = ? AND user_id = ?", noteID, userID).First(&note).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Note not found or access denied"})
+		return
+	}
+
+	revisions := []models.NoteRevision{}
+	if err := config.DB.Select("id, note_id, revision, title, created_at").Where("note_id = ?", note.ID).Order("revision DESC").Find(&revisions).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve revisions", "details": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, revisions)
+}
+
+// GetNoteRevisionDiff godoc
+// @Summary Diff a revision against the current note
+// @Description Returns a line-level unified diff from the given revision to the current content of the note
+// @Tags notes
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Note ID"
+// @Param rev path int true "Revision number"
+// @Success 200 {object} handlers.NoteRevisionDiffResponse
+// @Failure 401 {object} object "Unauthorized"
+// @Failure 404 {object} object "Note or revision not found (e.g., {\"error\": \"Revision not found\"})"
+// @Failure 500 {object} object "Internal server error (e.g., {\"error\": \"Failed to build diff\"})"
+// @Router /notes/{id}/revisions/{rev}/diff [get]
+func GetNoteRevisionDiff(c *gin.Context) {
+	userID, _ := c.Get("userID")
+	noteID := c.Param("id")
+
+	var note models.Note
+	if err := config.DB.Where("id = ? AND user_id = ?", noteID, userID).First(&note).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Note not found or access denied"})
+		return
+	}
+
+	var revision models.NoteRevision
+	if err := config.DB.Where("note_id = ? AND revision = ?", note.ID, c.Param("rev")).First(&revision).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Revision not found"})
+		return
+	}
+
+	diff, err := difflib.GetUnifiedDiffString(difflib.UnifiedDiff{
+		A:        difflib.SplitLines(revision.Content),
+		B:        difflib.SplitLines(note.Content),
+		FromFile: fmt.Sprintf("revision %d", revision.Revision),
+		ToFile:   "current",
+		Context:  3,
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to build diff", "details": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, NoteRevisionDiffResponse{
+		NoteID:       note.ID,
+		Revision:     revision.Revision,
+		TitleFrom:    revision.Title,
+		TitleTo:      note.Title,
+		TitleChanged: revision.Title != note.Title,
+		Diff:         diff,
+	})
+}
+
+// RestoreNoteRevision godoc
+// @Summary Restore a note to a previous revision
+// @Description Replaces the title and content of the note with the revision. The current version is saved as a new revision first.
+// @Tags notes
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Note ID"
+// @Param rev path int true "Revision number"
+// @Success 200 {object} models.Note
+// @Failure 401 {object} object "Unauthorized"
+// @Failure 404 {object} object "Note or revision not found"
+// @Failure 500 {object} object "Internal server error (e.g., {\"error\": \"Failed to restore revision\"})"
+// @Router /notes/{id}/revisions/{rev}/restore [post]
+func RestoreNoteRevision(c *gin.Context) {
+	userID, _ := c.Get("userID")
+	noteID := c.Param("id")
+
+	var note models.Note
+	if err := config.DB.Where("id = ? AND user_id = ?", noteID, userID).First(&note).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Note not found or access denied"})
+		return
+	}
+
+	var revision models.NoteRevision
+	if err := config.DB.Where("note_id = ? AND revision = ?", note.ID, c.Param("rev")).First(&revision).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Revision not found"})
+		return
+	}
+
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		if err := lockNote(tx, note.ID, userID, &note); err != nil {
+			return err
+		}
+		oldTitle := note.Title
+		if err := saveNoteRevision(tx, note); err != nil {
+			return err
+		}
+		if err := tx.Model(&note).Updates(map[string]interface{}{"title": revision.Title, "content": revision.Content, "version": gorm.Expr("version + 1")}).Error; err != nil {
+			return err
+		}
+		if err := syncNoteLinks(tx, note); err != nil {
+			return err
+		}
+		if note.Title != oldTitle {
+			if err := updateLinksToRenamedNote(tx, note, oldTitle, false); err != nil {
+				return err
+			}
+		}
+		return tx.Preload("Tags", orderTagsByName).First(&note, note.ID).Error
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to restore revision", "details": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, note)
+}
@@ -0,0 +1,314 @@
+package handlers
+
+import (
+	"archive/zip"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"mime/multipart"
+	"net/http"
+	"organizer-backend/config"
+	"organizer-backend/models"
+	"organizer-backend/utils"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gopkg.in/yaml.v3"
+	"gorm.io/gorm"
+)
+
+const (
+	maxImportFileSize     = 512 << 20 // 512 MB, Evernote exports include attachments
+	maxImportNoteSize     = 5 << 20   // 5 MB per note
+	maxImportFormOverhead = 1 << 20   // Multipart boundaries and part headers around the file
+)
+
+// markdownFrontMatter is the YAML header of an imported Markdown file
+type markdownFrontMatter struct {
+	ID        uint      `yaml:"id"`
+	Title     string    `yaml:"title"`
+	CreatedAt time.Time `yaml:"createdAt"`
+	UpdatedAt time.Time `yaml:"updatedAt"`
+	Tags      yaml.Node `yaml:"tags"` // List or comma separated string (Obsidian accepts both)
+	Tag       yaml.Node `yaml:"tag"`
+}
+
+// sanitizeFileName makes a note title or notebook name safe to use as a path element in the archive
+func sanitizeFileName(name string) string {
+	name = strings.Map(func(r rune) rune {
+		switch {
+		case r < 32, strings.ContainsRune(`/\:*?"<>|`, r):
+			return '_'
+		}
+		return r
+	}, strings.TrimSpace(name))
+	name = strings.Trim(name, ". ")
+	if len([]rune(name)) > 100 {
+		name = string([]rune(name)[:100])
+	}
+	return name
+}
+
+// notebookPaths maps every notebook of the user to its folder path in the archive
+func notebookPaths(userID interface{}) (map[uint]string, error) {
+	var notebooks []models.Notebook
+	if err := config.DB.Where("user_id = ?", userID).Find(&notebooks).Error; err != nil {
+		return nil, err
+	}
+	byID := make(map[uint]models.Notebook, len(notebooks))
+	for _, notebook := range notebooks {
+		byID[notebook.ID] = notebook
+	}
+
+	paths := make(map[uint]string, len(notebooks))
+	var resolve func(id uint, depth int) string
+	resolve = func(id uint, depth int) string {
+		if p, ok := paths[id]; ok {
+			return p
+		}
+		notebook := byID[id]
+		name := sanitizeFileName(notebook.Name)
+		if name == "" {
+			name = fmt.Sprintf("Notebook %d", notebook.ID)
+		}
+		p := name
+		if notebook.ParentID != nil && depth < len(byID) {
+			p = resolve(*notebook.ParentID, depth+1) + "/" + name
+		}
+		paths[id] = p
+		return p
+	}
+	for id := range byID {
+		resolve(id, 0)
+	}
+	return paths, nil
+}
+
+// ExportNotes godoc
+// @Summary Export all notes
+// @Description Streams a zip archive with one Markdown file per note. Files are placed in folders named after notebooks and start with YAML front matter (id, title, createdAt, updatedAt, tags).
+// @Tags notes
+// @Produce application/zip
+// @Security BearerAuth
+// @Param format query string false "Export format" Enums(markdown) default(markdown)
+// @Success 200 {file} file "Zip archive"
+// @Failure 400 {object} object "Unsupported export format"
+// @Failure 401 {object} object "Unauthorized"
+// @Failure 500 {object} object "Internal server error (e.g., {\"error\": \"Failed to export notes\"})"
+// @Router /notes/export [get]
+func ExportNotes(c *gin.Context) {
+	userID, _ := c.Get("userID")
+
+	if format := c.DefaultQuery("format", "markdown"); format != "markdown" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Unsupported export format", "details": "supported formats: markdown"})
+		return
+	}
+
+	folders, err := notebookPaths(userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to export notes", "details": err.Error()})
+		return
+	}
+
+	c.Header("Content-Type", "application/zip")
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="organizer-notes-%s.zip"`, time.Now().Format("20060102")))
+	c.Status(http.StatusOK)
+
+	// The response is already streaming, errors from here on can only abort the archive
+	archive := zip.NewWriter(c.Writer)
+	usedNames := map[string]bool{}
+	var notes []models.Note
+	result := config.DB.Preload("Tags", orderTagsByName).Where("user_id = ?", userID).Order("id").
+		FindInBatches(&notes, 100, func(tx *gorm.DB, batch int) error {
+			for _, note := range notes {
+				tags := make([]string, len(note.Tags))
+				for i, tag := range note.Tags {
+					tags[i] = tag.Name
+				}
+				meta := struct {
+					ID        uint      `yaml:"id"`
+					Title     string    `yaml:"title"`
+					CreatedAt time.Time `yaml:"createdAt"`
+					UpdatedAt time.Time `yaml:"updatedAt"`
+					Tags      []string  `yaml:"tags"`
+				}{note.ID, note.Title, note.CreatedAt, note.UpdatedAt, tags}
+				document, err := utils.RenderFrontMatter(meta, note.Content)
+				if err != nil {
+					return err
+				}
+
+				name := sanitizeFileName(note.Title)
+				if name == "" {
+					name = "Untitled"
+				}
+				if note.NotebookID != nil {
+					name = folders[*note.NotebookID] + "/" + name
+				}
+				if usedNames[strings.ToLower(name)] {
+					name = fmt.Sprintf("%s (%d)", name, note.ID)
+				}
+				usedNames[strings.ToLower(name)] = true
+
+				file, err := archive.CreateHeader(&zip.FileHeader{Name: name + ".md", Method: zip.Deflate, Modified: note.UpdatedAt})
+				if err != nil {
+					return err
+				}
+				if _, err := io.WriteString(file, document); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+	if result.Error != nil {
+		log.Printf("Note export for user %v aborted: %v", userID, result.Error)
+		return
+	}
+	if err := archive.Close(); err != nil {
+		log.Printf("Note export for user %v aborted: %v", userID, err)
+	}
+}
+
+// noteImportReaders parse an uploaded file of the given import format and feed its notes to the importer.
+// An error is returned only when the file cannot be read at all.
+var noteImportReaders = map[string]func(importer *noteImporter, file multipart.File, header *multipart.FileHeader) error{
+	"markdown": importMarkdownArchive,
+	"enex":     importEvernoteExport,
+	"keep":     importKeepTakeout,
+}
+
+// ImportNotes godoc
+// @Summary Import notes
+// @Description Imports notes from a Markdown zip (created by the export endpoint or a zipped Obsidian-style vault), an Evernote .enex file or a Google Keep Takeout zip. Folders and Evernote notebooks become notebooks, front matter, Evernote tags and Keep labels become tags, titles and timestamps are preserved. Notes with the same id, or the same title in the same notebook, are updated; unchanged notes are skipped. Notes are stored in batches, each in its own transaction, so a failed import can be retried. Problems with single notes are listed in errors.
+// @Tags notes
+// @Accept multipart/form-data
+// @Produce json
+// @Security BearerAuth
+// @Param format query string false "Import format" Enums(markdown, enex, keep) default(markdown)
+// @Param file formData file true "Zip archive with .md files, .enex file or Keep Takeout zip"
+// @Success 200 {object} handlers.NoteImportResponse
+// @Failure 400 {object} object "Missing or invalid file, or unsupported format"
+// @Failure 401 {object} object "Unauthorized"
+// @Failure 413 {object} object "File too large"
+// @Router /notes/import [post]
+func ImportNotes(c *gin.Context) {
+	userID, _ := c.Get("userID")
+
+	format := c.DefaultQuery("format", "markdown")
+	readNotes, ok := noteImportReaders[format]
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Unsupported import format", "details": "supported formats: markdown, enex, keep"})
+		return
+	}
+
+	// Limit the body before parsing: FormFile spools the whole upload to disk before header.Size is known
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxImportFileSize+maxImportFormOverhead)
+	header, err := c.FormFile("file")
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) || err == nil && header.Size > maxImportFileSize {
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": "File too large", "details": fmt.Sprintf("maximum size is %d MB", maxImportFileSize>>20)})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "File is required in the file field", "details": err.Error()})
+		return
+	}
+	file, err := header.Open()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to read file", "details": err.Error()})
+		return
+	}
+	defer file.Close()
+
+	importer := newNoteImporter(userID.(uint))
+	if err := readNotes(importer, file, header); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid import file", "details": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, importer.Result())
+}
+
+// importMarkdownArchive reads .md files of a zip, folders become notebooks
+func importMarkdownArchive(importer *noteImporter, file multipart.File, header *multipart.FileHeader) error {
+	archive, err := zip.NewReader(file, header.Size)
+	if err != nil {
+		return err
+	}
+	for _, entry := range archive.File {
+		if entry.FileInfo().IsDir() || hiddenArchivePath(entry.Name) || !strings.EqualFold(path.Ext(entry.Name), ".md") {
+			continue
+		}
+		note, err := readMarkdownNote(entry)
+		if err != nil {
+			importer.Fail(entry.Name, err)
+			continue
+		}
+		importer.Add(note)
+	}
+	return nil
+}
+
+// hiddenArchivePath reports files in hidden or tool folders (.obsidian, .trash, __MACOSX)
+func hiddenArchivePath(name string) bool {
+	for _, part := range strings.Split(name, "/") {
+		if strings.HasPrefix(part, ".") || part == "__MACOSX" {
+			return true
+		}
+	}
+	return false
+}
+
+// readMarkdownNote parses one Markdown file of an archive
+func readMarkdownNote(entry *zip.File) (importedNote, error) {
+	note := importedNote{Source: entry.Name}
+	data, err := readArchiveEntry(entry)
+	if err != nil {
+		return note, err
+	}
+	frontMatter, body := utils.SplitFrontMatter(string(data))
+	var meta markdownFrontMatter
+	if frontMatter != "" {
+		if err := yaml.Unmarshal([]byte(frontMatter), &meta); err != nil {
+			return note, fmt.Errorf("invalid front matter: %w", err)
+		}
+	}
+
+	dir, file := path.Split(entry.Name)
+	note.ID = meta.ID
+	note.Title = strings.TrimSpace(meta.Title)
+	if note.Title == "" {
+		note.Title = strings.TrimSuffix(file, path.Ext(file))
+	}
+	note.Content = body
+	note.CreatedAt = meta.CreatedAt
+	note.UpdatedAt = meta.UpdatedAt
+	note.Tags = append(frontMatterTags(meta.Tags), frontMatterTags(meta.Tag)...)
+	if dir = strings.Trim(dir, "/"); dir != "" {
+		note.NotebookPath = strings.Split(dir, "/")
+	}
+	return note, nil
+}
+
+// frontMatterTags accepts tags as a YAML list or a comma/space separated string, with or without #
+func frontMatterTags(node yaml.Node) []string {
+	var values []string
+	switch node.Kind {
+	case yaml.SequenceNode:
+		for _, item := range node.Content {
+			values = append(values, item.Value)
+		}
+	case yaml.ScalarNode:
+		values = strings.FieldsFunc(node.Value, func(r rune) bool { return r == ',' || r == ' ' })
+	}
+
+	tags := make([]string, 0, len(values))
+	for _, value := range values {
+		if value = strings.TrimPrefix(strings.TrimSpace(value), "#"); value != "" {
+			tags = append(tags, value)
+		}
+	}
+	return tags
+}
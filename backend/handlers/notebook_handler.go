@@ -0,0 +1,324 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"organizer-backend/config"
+	"organizer-backend/models"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type NotebookInput struct {
+	Name     string `json:"name" binding:"required,max=255" example:"Work"`
+	ParentID *uint  `json:"parentId" example:"1"` // null for a top-level notebook
+}
+
+type MoveNotesInput struct {
+	NoteIDs    []uint `json:"noteIds" binding:"required,min=1" example:"1,2,3"`
+	NotebookID *uint  `json:"notebookId" example:"4"` // null moves the notes out of any notebook
+}
+
+var errNotebookNotFound = errors.New("notebook not found")
+
+// notebookSubtree selects the ids of the notebook and all its descendants that are not in the trash
+func notebookSubtree(db *gorm.DB, userID interface{}, notebookID interface{}) *gorm.DB {
+	return db.Raw(`WITH RECURSIVE subtree AS (
+		SELECT id FROM notebooks WHERE id = ? AND user_id = ? AND deleted_at IS NULL
+		UNION
+		SELECT notebooks.id FROM notebooks JOIN subtree ON notebooks.parent_id = subtree.id WHERE notebooks.deleted_at IS NULL
+	) SELECT id FROM subtree`, notebookID, userID)
+}
+
+// restoreNotebookPath takes the notebook of a restored note and its ancestors out of the trash
+func restoreNotebookPath(tx *gorm.DB, userID interface{}, noteID interface{}) error {
+	return tx.Exec(`WITH RECURSIVE path AS (
+		SELECT notebook_id AS id FROM notes WHERE id = ? AND user_id = ? AND notebook_id IS NOT NULL
+		UNION
+		SELECT notebooks.parent_id FROM notebooks JOIN path ON notebooks.id = path.id WHERE notebooks.parent_id IS NOT NULL
+	) UPDATE notebooks SET deleted_at = NULL WHERE id IN (SELECT id FROM path) AND deleted_at IS NOT NULL`, noteID, userID).Error
+}
+
+// checkNotebookOwner makes sure the notebook (if set) exists and belongs to the user
+func checkNotebookOwner(db *gorm.DB, userID interface{}, notebookID *uint) error {
+	if notebookID == nil {
+		return nil
+	}
+	var count int64
+	if err := db.Model(&models.Notebook{}).Where("id = ? AND user_id = ?", *notebookID, userID).Count(&count).Error; err != nil {
+		return err
+	}
+	if count == 0 {
+		return errNotebookNotFound
+	}
+	return nil
+}
+
+// GetNotebooks godoc
+// @Summary Get all notebooks of the authenticated user
+// @Description Retrieves a flat list of notebooks with parent ids and the number of notes directly in each notebook
+// @Tags notebooks
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {array} models.Notebook
+// @Failure 401 {object} object "Unauthorized"
+// @Failure 500 {object} object "Internal server error (e.g., {\"error\": \"Failed to retrieve notebooks\"})"
+// @Router /notebooks [get]
+func GetNotebooks(c *gin.Context) {
+	userID, _ := c.Get("userID")
+
+	notebooks := []models.Notebook{}
+	if err := config.DB.
+		Select("notebooks.*, (SELECT COUNT(*) FROM notes WHERE notes.notebook_id = notebooks.id AND notes.deleted_at IS NULL) AS note_count").
+		Where("user_id = ?", userID).
+		Order("name").
+		Find(&notebooks).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve notebooks", "details": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, notebooks)
+}
+
+// CreateNotebook godoc
+// @Summary Create a new notebook
+// @Description Add a new notebook for the authenticated user, optionally inside another notebook
+// @Tags notebooks
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param notebook body NotebookInput true "Notebook data"
+// @Success 201 {object} models.Notebook
+// @Failure 400 {object} object "Invalid input or parent notebook not found"
+// @Failure 401 {object} object "Unauthorized"
+// @Failure 500 {object} object "Internal server error (e.g., {\"error\": \"Failed to create notebook\"})"
+// @Router /notebooks [post]
+func CreateNotebook(c *gin.Context) {
+	userID, _ := c.Get("userID")
+
+	var input NotebookInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	name := strings.TrimSpace(input.Name)
+	if name == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Notebook name must not be empty"})
+		return
+	}
+
+	if err := checkNotebookOwner(config.DB, userID, input.ParentID); err != nil {
+		if errors.Is(err, errNotebookNotFound) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Parent notebook not found or access denied"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create notebook", "details": err.Error()})
+		return
+	}
+
+	notebook := models.Notebook{UserID: userID.(uint), ParentID: input.ParentID, Name: name}
+	if err := config.DB.Create(&notebook).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create notebook", "details": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, notebook)
+}
+
+// UpdateNotebook godoc
+// @Summary Rename or move a notebook
+// @Description Updates the name and parent of a notebook. A notebook cannot be moved into itself or its sub-notebooks.
+// @Tags notebooks
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Notebook ID"
+// @Param notebook body NotebookInput true "Notebook data"
+// @Success 200 {object} models.Notebook
+// @Failure 400 {object} object "Invalid input or move would create a cycle (e.g., {\"error\": \"Cannot move a notebook into itself or its sub-notebooks\"})"
+// @Failure 401 {object} object "Unauthorized"
+// @Failure 404 {object} object "Notebook not found or access denied"
+// @Failure 500 {object} object "Internal server error (e.g., {\"error\": \"Failed to update notebook\"})"
+// @Router /notebooks/{id} [put]
+func UpdateNotebook(c *gin.Context) {
+	userID, _ := c.Get("userID")
+	notebookID := c.Param("id")
+
+	var notebook models.Notebook
+	if err := config.DB.Where("id = ? AND user_id = ?", notebookID, userID).First(&notebook).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Notebook not found or access denied"})
+		return
+	}
+
+	var input NotebookInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	name := strings.TrimSpace(input.Name)
+	if name == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Notebook name must not be empty"})
+		return
+	}
+
+	errCycle := errors.New("notebook cycle")
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		// Lock the user so concurrent moves wait for each other: moving A under B and B under A at the same
+		// time would both pass the cycle check
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Select("id").First(&models.User{}, userID).Error; err != nil {
+			return err
+		}
+		if err := checkNotebookOwner(tx, userID, input.ParentID); err != nil {
+			return err
+		}
+		if input.ParentID != nil {
+			// The new parent must not be the notebook itself or one of its descendants
+			var count int64
+			if err := tx.Model(&models.Notebook{}).Where("id = ? AND id IN (?)", *input.ParentID, notebookSubtree(tx, userID, notebook.ID)).Count(&count).Error; err != nil {
+				return err
+			}
+			if count > 0 {
+				return errCycle
+			}
+		}
+		return tx.Model(&notebook).Updates(map[string]interface{}{"name": name, "parent_id": input.ParentID}).Error
+	})
+	switch {
+	case errors.Is(err, errNotebookNotFound):
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Parent notebook not found or access denied"})
+		return
+	case errors.Is(err, errCycle):
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Cannot move a notebook into itself or its sub-notebooks"})
+		return
+	case err != nil:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update notebook", "details": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, notebook)
+}
+
+// DeleteNotebook godoc
+// @Summary Delete a notebook
+// @Description Deletes a notebook. With strategy=trash the notebook, its sub-notebooks and all their notes go to the trash; restoring a note brings its notebooks back.
+// @Description With strategy=move its notes and sub-notebooks are moved to the parent notebook (or to the top level).
+// @Tags notebooks
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Notebook ID"
+// @Param strategy query string false "What happens to the content" Enums(move, trash) default(move)
+// @Success 200 {object} object "Notebook deleted successfully (e.g., {\"message\": \"Notebook deleted successfully\"})"
+// @Failure 400 {object} object "Unknown strategy"
+// @Failure 401 {object} object "Unauthorized"
+// @Failure 404 {object} object "Notebook not found or access denied"
+// @Failure 500 {object} object "Internal server error (e.g., {\"error\": \"Failed to delete notebook\"})"
+// @Router /notebooks/{id} [delete]
+func DeleteNotebook(c *gin.Context) {
+	userID, _ := c.Get("userID")
+	notebookID := c.Param("id")
+
+	strategy := c.DefaultQuery("strategy", "move")
+	if strategy != "move" && strategy != "trash" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "strategy must be 'move' or 'trash'"})
+		return
+	}
+
+	var notebook models.Notebook
+	if err := config.DB.Where("id = ? AND user_id = ?", notebookID, userID).First(&notebook).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Notebook not found or access denied"})
+		return
+	}
+
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		if strategy == "trash" {
+			// Notes and notebooks go to the trash together, notes keep their notebook for a restore
+			if err := tx.Where("user_id = ? AND notebook_id IN (?)", userID, notebookSubtree(tx, userID, notebook.ID)).Delete(&models.Note{}).Error; err != nil {
+				return err
+			}
+			return tx.Where("user_id = ? AND id IN (?)", userID, notebookSubtree(tx, userID, notebook.ID)).Delete(&models.Notebook{}).Error
+		}
+		// Notes and sub-notebooks (including those in trash) move one level up
+		if err := tx.Unscoped().Model(&models.Note{}).Where("notebook_id = ?", notebook.ID).Update("notebook_id", notebook.ParentID).Error; err != nil {
+			return err
+		}
+		if err := tx.Unscoped().Model(&models.Notebook{}).Where("parent_id = ?", notebook.ID).Update("parent_id", notebook.ParentID).Error; err != nil {
+			return err
+		}
+		return tx.Unscoped().Delete(&notebook).Error
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete notebook", "details": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Notebook deleted successfully"})
+}
+
+// MoveNotes godoc
+// @Summary Move notes to a notebook
+// @Description Moves several notes of the authenticated user to a notebook, or out of any notebook when notebookId is null
+// @Tags notes
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param move body MoveNotesInput true "Notes and target notebook"
+// @Success 200 {object} object "Notes moved (e.g., {\"moved\": 3})"
+// @Failure 400 {object} object "Invalid input or notebook not found"
+// @Failure 401 {object} object "Unauthorized"
+// @Failure 500 {object} object "Internal server error (e.g., {\"error\": \"Failed to move notes\"})"
+// @Router /notes/move [post]
+func MoveNotes(c *gin.Context) {
+	userID, _ := c.Get("userID")
+
+	var input MoveNotesInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := checkNotebookOwner(config.DB, userID, input.NotebookID); err != nil {
+		if errors.Is(err, errNotebookNotFound) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Notebook not found or access denied"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to move notes", "details": err.Error()})
+		return
+	}
+
+	result := config.DB.Model(&models.Note{}).
+		Where("user_id = ? AND id IN ?", userID, input.NoteIDs).
+		Updates(map[string]interface{}{"notebook_id": input.NotebookID, "version": gorm.Expr("version + 1")})
+	if result.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to move notes", "details": result.Error.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"moved": result.RowsAffected})
+}
+
+// findOrCreateNotebookPath returns the id of the notebook at the given path of names (e.g. folders of an
+// imported vault), creating missing notebooks. An empty path means no notebook.
+func findOrCreateNotebookPath(tx *gorm.DB, userID uint, path []string) (*uint, error) {
+	var parentID *uint
+	for _, name := range path {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+		notebook := models.Notebook{UserID: userID, ParentID: parentID, Name: name}
+		query := tx.Where("user_id = ? AND name = ?", userID, name)
+		if parentID == nil {
+			query = query.Where("parent_id IS NULL")
+		} else {
+			query = query.Where("parent_id = ?", *parentID)
+		}
+		if err := query.FirstOrCreate(&notebook).Error; err != nil {
+			return nil, err
+		}
+		parentID = &notebook.ID
+	}
+	return parentID, nil
+}
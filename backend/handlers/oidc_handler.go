@@ -0,0 +1,392 @@
+package handlers
+
+import (
+	"crypto/subtle"
+	"errors"
+	"log"
+	"net/http"
+	"net/url"
+	"organizer-backend/config"
+	"organizer-backend/models"
+	"organizer-backend/utils"
+	"strings"
+	"time"
+
+	"github.com/coreos/go-oidc/v3/oidc"
+	"github.com/gin-gonic/gin"
+	"golang.org/x/oauth2"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	oidcStateTTL     = 10 * time.Minute // Time to sign in at the provider
+	oidcLoginCodeTTL = 2 * time.Minute  // Time for the frontend to exchange the login code
+	oidcStateCookie  = "oidc_state"     // Binds the state to the browser that started the login
+)
+
+var (
+	errOIDCEmailNotVerified = errors.New("the provider did not verify the email address")
+	errOIDCEmailMissing     = errors.New("the provider did not return an email address")
+)
+
+// OIDCProviderResponse describes a provider for the login page
+type OIDCProviderResponse struct {
+	Name        string `json:"name" example:"company"`
+	DisplayName string `json:"displayName" example:"Company SSO"`
+	LoginURL    string `json:"loginUrl" example:"/api/auth/oidc/company/login"`
+}
+
+type OIDCExchangeInput struct {
+	Code string `json:"code" binding:"required" example:"q5Jx0mVb2n8Yk1s9cW4tZr7LhP3dG6fA0eU2iO8yTnM"`
+}
+
+// oidcClaims are the ID token claims used to find or create the user
+type oidcClaims struct {
+	Email         string      `json:"email"`
+	EmailVerified interface{} `json:"email_verified"` // Some providers send "true" as a string
+	Name          string      `json:"name"`
+}
+
+func (c oidcClaims) emailVerified() bool {
+	switch value := c.EmailVerified.(type) {
+	case bool:
+		return value
+	case string:
+		return value == "true"
+	}
+	return false
+}
+
+func oidcRedirectURL(provider string) string {
+	return utils.APIURL("/api/auth/oidc/" + provider + "/callback")
+}
+
+// redirectToFrontend finishes the provider callback at the frontend page /oidc/callback
+func redirectToFrontend(c *gin.Context, params url.Values) {
+	c.Redirect(http.StatusFound, utils.AppURL("/oidc/callback?"+params.Encode()))
+}
+
+// setOIDCStateCookie stores the state in the browser that started the login, so a callback URL of
+// another browser's login cannot sign this one in (login CSRF). An empty state clears the cookie.
+func setOIDCStateCookie(c *gin.Context, state string) {
+	maxAge := int(oidcStateTTL / time.Second)
+	if state == "" {
+		maxAge = -1
+	}
+	http.SetCookie(c.Writer, &http.Cookie{
+		Name:     oidcStateCookie,
+		Value:    state,
+		Path:     "/api/auth/oidc/",
+		MaxAge:   maxAge,
+		HttpOnly: true,
+		Secure:   !strings.HasPrefix(utils.APIURL("/"), "http://"), // Plain HTTP only for local development
+		SameSite: http.SameSiteLaxMode,                             // Sent on the top-level redirect back from the provider
+	})
+}
+
+// safeRedirectPath accepts only local paths, so the login cannot be abused as an open redirect
+func safeRedirectPath(path string) string {
+	if !strings.HasPrefix(path, "/") || strings.HasPrefix(path, "//") || strings.HasPrefix(path, "/\\") || len(path) > 512 {
+		return ""
+	}
+	return path
+}
+
+// GetOIDCProviders godoc
+// @Summary Get OpenID Connect providers
+// @Description Lists the identity providers users can sign in with (configured by OIDC_PROVIDERS)
+// @Tags auth
+// @Produce json
+// @Success 200 {array} handlers.OIDCProviderResponse
+// @Router /auth/oidc/providers [get]
+func GetOIDCProviders(c *gin.Context) {
+	providers := []OIDCProviderResponse{}
+	for _, provider := range utils.OIDCProviders() {
+		providers = append(providers, OIDCProviderResponse{
+			Name:        provider.Name,
+			DisplayName: provider.DisplayName,
+			LoginURL:    "/api/auth/oidc/" + provider.Name + "/login",
+		})
+	}
+	c.JSON(http.StatusOK, providers)
+}
+
+// StartOIDCLogin godoc
+// @Summary Start an OpenID Connect login
+// @Description Redirects the browser to the identity provider (authorization code flow with PKCE). The provider redirects back to
+// @Description /auth/oidc/{provider}/callback, which continues at the frontend page /oidc/callback with a one-time code for /auth/oidc/exchange.
+// @Tags auth
+// @Param provider path string true "Provider name"
+// @Param redirect query string false "Local frontend path to continue at after the login, e.g. /notes"
+// @Success 302 "Redirect to the identity provider"
+// @Failure 404 {object} object "Unknown provider"
+// @Failure 502 {object} object "Provider discovery failed"
+// @Failure 500 {object} object "Internal server error"
+// @Router /auth/oidc/{provider}/login [get]
+func StartOIDCLogin(c *gin.Context) {
+	provider, ok := utils.GetOIDCProvider(c.Param("provider"))
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Unknown identity provider"})
+		return
+	}
+
+	oauthConfig, err := provider.OAuth2Config(c.Request.Context(), oidcRedirectURL(provider.Name))
+	if err != nil {
+		c.JSON(http.StatusBadGateway, gin.H{"error": "Identity provider is not available", "details": err.Error()})
+		return
+	}
+
+	state, err := utils.GenerateOpaqueToken()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to start login", "details": err.Error()})
+		return
+	}
+	nonce, err := utils.GenerateOpaqueToken()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to start login", "details": err.Error()})
+		return
+	}
+	verifier := oauth2.GenerateVerifier()
+
+	loginState := models.OIDCLoginState{
+		StateHash:    utils.HashToken(state),
+		Provider:     provider.Name,
+		CodeVerifier: verifier,
+		Nonce:        nonce,
+		RedirectPath: safeRedirectPath(c.Query("redirect")),
+		ExpiresAt:    time.Now().Add(oidcStateTTL),
+	}
+	if err := config.DB.Create(&loginState).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to start login", "details": err.Error()})
+		return
+	}
+	if err := config.DB.Where("expires_at < ?", time.Now()).Delete(&models.OIDCLoginState{}).Error; err != nil {
+		log.Printf("Failed to clean up expired OIDC login states: %v", err)
+	}
+
+	setOIDCStateCookie(c, state)
+	c.Redirect(http.StatusFound, oauthConfig.AuthCodeURL(state, oidc.Nonce(nonce), oauth2.S256ChallengeOption(verifier)))
+}
+
+// OIDCCallback godoc
+// @Summary Finish an OpenID Connect login
+// @Description Called by the identity provider. Exchanges the authorization code, validates the ID token and finds the user by the stored
+// @Description provider subject or, for a verified email, by email. Unknown users are registered. Continues at the frontend page /oidc/callback
+// @Description with a one-time code (query parameter code) or an error (query parameter error). The state must match the cookie set by the login start.
+// @Tags auth
+// @Param provider path string true "Provider name"
+// @Param code query string false "Authorization code"
+// @Param state query string true "State of the login"
+// @Success 302 "Redirect to the frontend"
+// @Router /auth/oidc/{provider}/callback [get]
+func OIDCCallback(c *gin.Context) {
+	providerName := c.Param("provider")
+	fail := func(reason string, err error) {
+		if err != nil {
+			log.Printf("OIDC login with %s failed: %v", providerName, err)
+		}
+		redirectToFrontend(c, url.Values{"error": {reason}})
+	}
+
+	// Only the browser that started the login may finish it
+	stateCookie, _ := c.Cookie(oidcStateCookie)
+	setOIDCStateCookie(c, "")
+	if stateCookie == "" || subtle.ConstantTimeCompare([]byte(stateCookie), []byte(c.Query("state"))) != 1 {
+		fail("invalid_state", errors.New("state does not match the cookie of the browser"))
+		return
+	}
+
+	// The state is single-use: it is deleted whether the login succeeds or not
+	var loginState models.OIDCLoginState
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("state_hash = ?", utils.HashToken(c.Query("state"))).First(&loginState).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&loginState).Error
+	})
+	if err != nil || loginState.Provider != providerName || time.Now().After(loginState.ExpiresAt) {
+		fail("invalid_state", err)
+		return
+	}
+	if providerError := c.Query("error"); providerError != "" {
+		fail(providerError, nil)
+		return
+	}
+
+	provider, ok := utils.GetOIDCProvider(providerName)
+	if !ok {
+		fail("unknown_provider", nil)
+		return
+	}
+	ctx := c.Request.Context()
+	oauthConfig, err := provider.OAuth2Config(ctx, oidcRedirectURL(provider.Name))
+	if err != nil {
+		fail("provider_unavailable", err)
+		return
+	}
+	verifier, err := provider.Verifier(ctx)
+	if err != nil {
+		fail("provider_unavailable", err)
+		return
+	}
+
+	token, err := oauthConfig.Exchange(ctx, c.Query("code"), oauth2.VerifierOption(loginState.CodeVerifier))
+	if err != nil {
+		fail("login_failed", err)
+		return
+	}
+	rawIDToken, ok := token.Extra("id_token").(string)
+	if !ok {
+		fail("login_failed", errors.New("token response has no id_token"))
+		return
+	}
+	idToken, err := verifier.Verify(ctx, rawIDToken)
+	if err != nil {
+		fail("login_failed", err)
+		return
+	}
+	if idToken.Nonce != loginState.Nonce {
+		fail("login_failed", errors.New("ID token nonce does not match"))
+		return
+	}
+	var claims oidcClaims
+	if err := idToken.Claims(&claims); err != nil {
+		fail("login_failed", err)
+		return
+	}
+
+	var code string
+	err = config.DB.Transaction(func(tx *gorm.DB) error {
+		user, err := linkOIDCIdentity(tx, c, provider.Name, idToken.Subject, claims)
+		if err != nil {
+			return err
+		}
+		code, err = issueUserToken(tx, user.ID, models.TokenPurposeOIDCLogin, oidcLoginCodeTTL)
+		return err
+	})
+	switch err {
+	case nil:
+	case errOIDCEmailNotVerified:
+		fail("email_not_verified", err)
+		return
+	case errOIDCEmailMissing:
+		fail("email_missing", err)
+		return
+	default:
+		fail("login_failed", err)
+		return
+	}
+
+	params := url.Values{"code": {code}}
+	if loginState.RedirectPath != "" {
+		params.Set("redirect", loginState.RedirectPath)
+	}
+	redirectToFrontend(c, params)
+}
+
+// linkOIDCIdentity finds the user of a provider account. A new account is linked to an existing user with
+// the same email only when the provider verified the email, otherwise anyone could take over accounts by
+// entering a foreign email at a provider. Users without a match are registered without a password.
+func linkOIDCIdentity(tx *gorm.DB, c *gin.Context, provider, subject string, claims oidcClaims) (models.User, error) {
+	var user models.User
+	var identity models.UserIdentity
+	err := tx.Where("provider = ? AND subject = ?", provider, subject).First(&identity).Error
+	if err == nil {
+		if err := tx.First(&user, identity.UserID).Error; err != nil {
+			return user, err
+		}
+		return user, tx.Model(&identity).Updates(map[string]interface{}{"email": claims.Email, "last_login_at": time.Now()}).Error
+	} else if err != gorm.ErrRecordNotFound {
+		return user, err
+	}
+
+	if claims.Email == "" {
+		return user, errOIDCEmailMissing
+	}
+	if !claims.emailVerified() {
+		return user, errOIDCEmailNotVerified
+	}
+
+	err = tx.Where("LOWER(email) = LOWER(?)", claims.Email).First(&user).Error
+	if err == gorm.ErrRecordNotFound {
+		locale := utils.NormalizeLocale(c.GetHeader("Accept-Language"))
+		if locale == "" {
+			locale = utils.DefaultLocale
+		}
+		user = models.User{Email: claims.Email, EmailVerified: true, Fullname: claims.Name, Locale: locale, Role: models.RoleUser}
+		if err := tx.Create(&user).Error; err != nil {
+			return user, err
+		}
+	} else if err != nil {
+		return user, err
+	} else if !user.EmailVerified {
+		if err := tx.Model(&user).Update("email_verified", true).Error; err != nil {
+			return user, err
+		}
+	}
+
+	identity = models.UserIdentity{UserID: user.ID, Provider: provider, Subject: subject, Email: claims.Email, LastLoginAt: time.Now()}
+	return user, tx.Create(&identity).Error
+}
+
+// ExchangeOIDCCode godoc
+// @Summary Get tokens after an OpenID Connect login
+// @Description Exchanges the one-time code from the frontend page /oidc/callback for an access and refresh token. The code is valid for two minutes.
+// @Description For users with two-factor authentication a TwoFactorChallengeResponse is returned instead; finish the login with /auth/login/2fa.
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param code body OIDCExchangeInput true "One-time login code"
+// @Success 200 {object} UserAuthResponse "Successfully logged in (or TwoFactorChallengeResponse when 2FA is enabled)"
+// @Failure 400 {object} object "Invalid, used or expired code"
+// @Failure 403 {object} object "Account is disabled"
+// @Failure 429 {object} object "Too many requests, see the Retry-After header"
+// @Failure 500 {object} object "Internal server error"
+// @Router /auth/oidc/exchange [post]
+func ExchangeOIDCCode(c *gin.Context) {
+	var input OIDCExchangeInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input: " + err.Error()})
+		return
+	}
+
+	var user models.User
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		record, err := consumeUserToken(tx, input.Code, models.TokenPurposeOIDCLogin)
+		if err != nil {
+			return err
+		}
+		return tx.First(&user, record.UserID).Error
+	})
+	if err == errInvalidUserToken {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid or expired code"})
+		return
+	} else if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to log in", "details": err.Error()})
+		return
+	}
+	if rejectDisabledAccount(c, user) {
+		return
+	}
+
+	if user.TwoFactorEnabled {
+		challenge, err := utils.GenerateChallengeToken(user.ID, user.Email)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate token"})
+			return
+		}
+		c.JSON(http.StatusOK, TwoFactorChallengeResponse{TwoFactorRequired: true, ChallengeToken: challenge, ExpiresIn: int(utils.ChallengeTTL.Seconds())})
+		return
+	}
+
+	tokens, err := startSession(c, user)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate token"})
+		return
+	}
+	user.PasswordHash = ""
+
+	c.JSON(http.StatusOK, UserAuthResponse{TokenPairResponse: tokens, User: user})
+}
@@ -0,0 +1,135 @@
+package handlers
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+const maxCursorPageSize = 100
+
+// listSortColumns maps the sort query values to columns usable for keyset pagination
+var listSortColumns = map[string]string{
+	"createdAt": "created_at",
+	"updatedAt": "updated_at",
+	"title":     "title",
+}
+
+var errInvalidCursor = errors.New("invalid cursor")
+
+// listSort is the ordering of a listing, always made unique by the id as tie breaker
+type listSort struct {
+	Table string // Table the columns belong to, e.g. "notes"
+	Field string // createdAt, updatedAt or title
+	Desc  bool
+}
+
+// listCursor is the position after the last item of a page, encoded as opaque base64 JSON
+type listCursor struct {
+	Sort  string `json:"s"` // Sort the cursor was created for
+	Value string `json:"v"`
+	ID    uint   `json:"id"`
+}
+
+// parseListSort reads the sort and order query parameters
+func parseListSort(c *gin.Context, table string, defaultField string) (listSort, error) {
+	sort := listSort{Table: table, Field: c.DefaultQuery("sort", defaultField)}
+	if _, ok := listSortColumns[sort.Field]; !ok {
+		return sort, fmt.Errorf("sort must be one of createdAt, updatedAt, title")
+	}
+
+	defaultOrder := "desc"
+	if sort.Field == "title" {
+		defaultOrder = "asc"
+	}
+	switch c.DefaultQuery("order", defaultOrder) {
+	case "asc":
+	case "desc":
+		sort.Desc = true
+	default:
+		return sort, fmt.Errorf("order must be asc or desc")
+	}
+	return sort, nil
+}
+
+// parseCursorLimit reads the page size for cursor pagination
+func parseCursorLimit(c *gin.Context) (int, error) {
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "10"))
+	if err != nil || limit < 1 || limit > maxCursorPageSize {
+		return 0, fmt.Errorf("limit must be between 1 and %d", maxCursorPageSize)
+	}
+	return limit, nil
+}
+
+func (s listSort) key() string {
+	if s.Desc {
+		return s.Field + ":desc"
+	}
+	return s.Field + ":asc"
+}
+
+func (s listSort) column() string {
+	if s.Field == "title" {
+		return fmt.Sprintf("COALESCE(%s.title, '')", s.Table) // NULL would break the row comparison in After
+	}
+	return s.Table + "." + listSortColumns[s.Field]
+}
+
+// Order applies the sort to the query
+func (s listSort) Order(query *gorm.DB) *gorm.DB {
+	direction := "ASC"
+	if s.Desc {
+		direction = "DESC"
+	}
+	return query.Order(fmt.Sprintf("%s %s, %s.id %s", s.column(), direction, s.Table, direction))
+}
+
+// After restricts the query to items following the cursor. An empty cursor starts at the first page.
+func (s listSort) After(query *gorm.DB, encoded string) (*gorm.DB, error) {
+	if encoded == "" {
+		return query, nil
+	}
+
+	raw, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, errInvalidCursor
+	}
+	var cursor listCursor
+	if err := json.Unmarshal(raw, &cursor); err != nil || cursor.Sort != s.key() {
+		return nil, errInvalidCursor // Cursors are only valid for the sort they were created with
+	}
+
+	var value interface{} = cursor.Value
+	if s.Field != "title" {
+		if value, err = time.Parse(time.RFC3339Nano, cursor.Value); err != nil {
+			return nil, errInvalidCursor
+		}
+	}
+
+	operator := ">"
+	if s.Desc {
+		operator = "<"
+	}
+	return query.Where(fmt.Sprintf("(%s, %s.id) %s (?, ?)", s.column(), s.Table, operator), value, cursor.ID), nil
+}
+
+// Cursor encodes the position of an item for the next page request
+func (s listSort) Cursor(id uint, createdAt, updatedAt time.Time, title string) string {
+	cursor := listCursor{Sort: s.key(), ID: id}
+	switch s.Field {
+	case "createdAt":
+		cursor.Value = createdAt.Format(time.RFC3339Nano)
+	case "updatedAt":
+		cursor.Value = updatedAt.Format(time.RFC3339Nano)
+	default:
+		cursor.Value = title
+	}
+	raw, _ := json.Marshal(cursor)
+	return base64.RawURLEncoding.EncodeToString(raw)
+}
@@ -0,0 +1,269 @@
+package handlers
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"organizer-backend/config"
+	"organizer-backend/models"
+	"organizer-backend/utils"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// TokenPairResponse holds a short-lived access token and the refresh token to renew it
+type TokenPairResponse struct {
+	Token        string `json:"token" example:"eyJhbGciOiJIUzI1NiIsInR5cCI6IkpXVCJ9..."`
+	RefreshToken string `json:"refreshToken" example:"q3J9mD0cX2k..."` // Opaque, single use: every refresh returns a new one
+	ExpiresIn    int    `json:"expiresIn" example:"900"`               // Access token lifetime in seconds
+}
+
+type RefreshTokenInput struct {
+	RefreshToken string `json:"refreshToken" binding:"required" example:"q3J9mD0cX2k..."`
+}
+
+var (
+	errRefreshTokenInvalid = errors.New("refresh token is invalid or expired")
+	errRefreshTokenReused  = errors.New("refresh token was already used")
+)
+
+// issueTokens signs an access token for the session and pairs it with the refresh token
+func issueTokens(user models.User, sessionID uint, refreshToken string) (TokenPairResponse, error) {
+	token, err := utils.GenerateJWT(user.ID, user.Email, user.Role, sessionID)
+	if err != nil {
+		return TokenPairResponse{}, err
+	}
+	return TokenPairResponse{Token: token, RefreshToken: refreshToken, ExpiresIn: int(utils.AccessTokenTTL.Seconds())}, nil
+}
+
+// startSession creates a session for a successful login and returns its first token pair
+func startSession(c *gin.Context, user models.User) (TokenPairResponse, error) {
+	refreshToken, err := utils.GenerateOpaqueToken()
+	if err != nil {
+		return TokenPairResponse{}, err
+	}
+
+	session := models.Session{
+		UserID:           user.ID,
+		RefreshTokenHash: utils.HashToken(refreshToken),
+		ExpiresAt:        time.Now().Add(utils.RefreshTokenTTL),
+		UserAgent:        truncate(c.Request.UserAgent(), 512),
+		IP:               c.ClientIP(),
+		LastSeenAt:       time.Now(),
+	}
+	if err := config.DB.Create(&session).Error; err != nil {
+		return TokenPairResponse{}, err
+	}
+
+	// Sessions that ended more than a refresh token lifetime ago are of no use anymore
+	cutoff := time.Now().Add(-utils.RefreshTokenTTL)
+	if err := config.DB.Where("user_id = ? AND (expires_at < ? OR revoked_at < ?)", user.ID, cutoff, cutoff).Delete(&models.Session{}).Error; err != nil {
+		log.Printf("Failed to clean up old sessions of user %d: %v", user.ID, err)
+	}
+
+	return issueTokens(user, session.ID, refreshToken)
+}
+
+func truncate(value string, max int) string {
+	if len(value) <= max {
+		return value
+	}
+	return strings.ToValidUTF8(value[:max], "")
+}
+
+// revokeSessions marks the matching active sessions as revoked
+func revokeSessions(db *gorm.DB, reason string) (int64, error) {
+	result := db.Model(&models.Session{}).Where("revoked_at IS NULL").
+		Updates(map[string]interface{}{"revoked_at": time.Now(), "revoked_reason": reason})
+	return result.RowsAffected, result.Error
+}
+
+// RefreshAccessToken godoc
+// @Summary Refresh the access token
+// @Description Exchanges a refresh token for a new access token and a new refresh token. Each refresh token can be used once;
+// @Description presenting an already used token revokes the whole session, since it means the token was copied.
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param token body RefreshTokenInput true "Refresh token"
+// @Success 200 {object} handlers.TokenPairResponse
+// @Failure 400 {object} object "Invalid input"
+// @Failure 401 {object} object "Invalid, expired or reused refresh token (e.g., {\"error\": \"Invalid or expired refresh token\"})"
+// @Failure 500 {object} object "Internal server error (e.g., {\"error\": \"Failed to refresh token\"})"
+// @Router /auth/refresh [post]
+func RefreshAccessToken(c *gin.Context) {
+	var input RefreshTokenInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input: " + err.Error()})
+		return
+	}
+	presentedHash := utils.HashToken(input.RefreshToken)
+
+	var tokens TokenPairResponse
+	var reusedSessionID uint
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		var session models.Session
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("refresh_token_hash = ?", presentedHash).First(&session).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			var rotated models.RotatedRefreshToken
+			if err := tx.Where("token_hash = ?", presentedHash).First(&rotated).Error; err != nil {
+				if errors.Is(err, gorm.ErrRecordNotFound) {
+					return errRefreshTokenInvalid
+				}
+				return err
+			}
+			// Token reuse: either the client or an attacker holds a stale copy, end the whole family
+			if _, err := revokeSessions(tx.Where("id = ?", rotated.SessionID), "token-reuse"); err != nil {
+				return err
+			}
+			reusedSessionID = rotated.SessionID
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if !session.IsActive() {
+			return errRefreshTokenInvalid
+		}
+
+		var user models.User
+		if err := tx.Select("id, email, role, disabled_at").First(&user, session.UserID).Error; err != nil {
+			return err
+		}
+		if user.DisabledAt != nil {
+			return errRefreshTokenInvalid
+		}
+		refreshToken, err := utils.GenerateOpaqueToken()
+		if err != nil {
+			return err
+		}
+		if err := tx.Create(&models.RotatedRefreshToken{SessionID: session.ID, TokenHash: presentedHash}).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&session).Updates(map[string]interface{}{
+			"refresh_token_hash": utils.HashToken(refreshToken),
+			"expires_at":         time.Now().Add(utils.RefreshTokenTTL),
+			"ip":                 c.ClientIP(),
+			"last_seen_at":       time.Now(),
+		}).Error; err != nil {
+			return err
+		}
+		tokens, err = issueTokens(user, session.ID, refreshToken)
+		return err
+	})
+
+	switch {
+	case errors.Is(err, errRefreshTokenInvalid):
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid or expired refresh token"})
+	case err != nil:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to refresh token", "details": err.Error()})
+	case reusedSessionID != 0:
+		log.Printf("Refresh token reuse detected, session %d revoked", reusedSessionID)
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Refresh token reuse detected, session revoked", "details": errRefreshTokenReused.Error()})
+	default:
+		c.JSON(http.StatusOK, tokens)
+	}
+}
+
+// Logout godoc
+// @Summary Log out
+// @Description Revokes the session of the access token, its refresh token can no longer be used
+// @Tags auth
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} handlers.MessageResponse "Logged out"
+// @Failure 401 {object} object "Unauthorized"
+// @Failure 500 {object} object "Internal server error (e.g., {\"error\": \"Failed to log out\"})"
+// @Router /auth/logout [post]
+func Logout(c *gin.Context) {
+	userID, _ := c.Get("userID")
+	sessionID, _ := c.Get("sessionID")
+
+	if _, err := revokeSessions(config.DB.Where("id = ? AND user_id = ?", sessionID, userID), "logout"); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to log out", "details": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Logged out"})
+}
+
+// LogoutAll godoc
+// @Summary Log out everywhere
+// @Description Revokes all sessions of the authenticated user, including the current one
+// @Tags auth
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} object "Sessions revoked (e.g., {\"message\": \"Logged out from all sessions\", \"revoked\": 3})"
+// @Failure 401 {object} object "Unauthorized"
+// @Failure 500 {object} object "Internal server error (e.g., {\"error\": \"Failed to log out\"})"
+// @Router /auth/logout-all [post]
+func LogoutAll(c *gin.Context) {
+	userID, _ := c.Get("userID")
+
+	revoked, err := revokeSessions(config.DB.Where("user_id = ?", userID), "logout-all")
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to log out", "details": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Logged out from all sessions", "revoked": revoked})
+}
+
+// GetSessions godoc
+// @Summary Get active sessions of the current user
+// @Description Lists devices the user is signed in on, most recently active first. The session of the request is marked as current.
+// @Tags users
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {array} models.Session
+// @Failure 401 {object} object "Unauthorized"
+// @Failure 500 {object} object "Internal server error (e.g., {\"error\": \"Failed to retrieve sessions\"})"
+// @Router /users/me/sessions [get]
+func GetSessions(c *gin.Context) {
+	userID, _ := c.Get("userID")
+	sessionID, _ := c.Get("sessionID")
+
+	sessions := []models.Session{}
+	if err := config.DB.Where("user_id = ? AND revoked_at IS NULL AND expires_at > ?", userID, time.Now()).
+		Order("last_seen_at DESC").Find(&sessions).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve sessions", "details": err.Error()})
+		return
+	}
+	for i := range sessions {
+		sessions[i].Current = sessions[i].ID == sessionID
+	}
+
+	c.JSON(http.StatusOK, sessions)
+}
+
+// RevokeSession godoc
+// @Summary Sign out a session
+// @Description Revokes one session of the current user, e.g. a lost device. Its tokens stop working immediately.
+// @Tags users
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Session ID"
+// @Success 200 {object} handlers.MessageResponse "Session revoked"
+// @Failure 401 {object} object "Unauthorized"
+// @Failure 404 {object} object "Session not found or already revoked"
+// @Failure 500 {object} object "Internal server error (e.g., {\"error\": \"Failed to revoke session\"})"
+// @Router /users/me/sessions/{id} [delete]
+func RevokeSession(c *gin.Context) {
+	userID, _ := c.Get("userID")
+
+	revoked, err := revokeSessions(config.DB.Where("id = ? AND user_id = ?", c.Param("id"), userID), "remote-sign-out")
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to revoke session", "details": err.Error()})
+		return
+	}
+	if revoked == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Session not found or already revoked"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Session revoked"})
+}
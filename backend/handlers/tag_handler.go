@@ -0,0 +1,286 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"organizer-backend/config"
+	"organizer-backend/models"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type TagInput struct {
+	Name string `json:"name" binding:"required,max=64" example:"work"`
+}
+
+type MergeTagsInput struct {
+	TargetID uint `json:"targetId" binding:"required" example:"2"`
+}
+
+// normalizeTagNames trims tag names and drops empty values and duplicates
+func normalizeTagNames(names []string) []string {
+	seen := make(map[string]bool, len(names))
+	result := make([]string, 0, len(names))
+	for _, name := range names {
+		name = strings.TrimSpace(name)
+		if name == "" || seen[name] {
+			continue
+		}
+		seen[name] = true
+		result = append(result, name)
+	}
+	return result
+}
+
+// findOrCreateTags returns the user's tags with the given names, creating the missing ones
+func findOrCreateTags(tx *gorm.DB, userID uint, names []string) ([]models.Tag, error) {
+	names = normalizeTagNames(names)
+	tags := make([]models.Tag, 0, len(names))
+	for _, name := range names {
+		var tag models.Tag
+		if err := tx.Where(models.Tag{UserID: userID, Name: name}).FirstOrCreate(&tag).Error; err != nil {
+			return nil, err
+		}
+		tags = append(tags, tag)
+	}
+	return tags, nil
+}
+
+// orderTagsByName is used with Preload("Tags", ...) to return note tags sorted
+func orderTagsByName(db *gorm.DB) *gorm.DB {
+	return db.Order("tags.name")
+}
+
+// isUniqueViolation reports whether err is a PostgreSQL unique_violation, e.g. of a name taken by a
+// concurrent request after it was checked
+func isUniqueViolation(err error) bool {
+	var pgErr interface{ SQLState() string }
+	return errors.As(err, &pgErr) && pgErr.SQLState() == "23505"
+}
+
+// tagUsageQuery counts tag usage over the notes selected by noteIDs (a subquery returning note ids)
+func tagUsageQuery(userID interface{}, noteIDs *gorm.DB) *gorm.DB {
+	query := config.DB.Table("tags").
+		Select("tags.id, tags.name, COUNT(notes.id) AS note_count").
+		Joins("LEFT JOIN note_tags ON note_tags.tag_id = tags.id").
+		Joins("LEFT JOIN notes ON notes.id = note_tags.note_id AND notes.deleted_at IS NULL"). // Notes in trash are not counted
+		Where("tags.user_id = ?", userID).
+		Group("tags.id, tags.name").
+		Order("note_count DESC, tags.name")
+	if noteIDs != nil {
+		query = query.Where("notes.id IN (?)", noteIDs)
+	}
+	return query
+}
+
+// GetTags godoc
+// @Summary Get all tags of the authenticated user
+// @Description Retrieves the user's tags with the number of notes each one is attached to
+// @Tags tags
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {array} models.TagUsage
+// @Failure 401 {object} object "Unauthorized"
+// @Failure 500 {object} object "Internal server error (e.g., {\"error\": \"Failed to retrieve tags\"})"
+// @Router /tags [get]
+func GetTags(c *gin.Context) {
+	userID, _ := c.Get("userID")
+
+	tags := []models.TagUsage{}
+	if err := tagUsageQuery(userID, nil).Scan(&tags).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve tags", "details": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, tags)
+}
+
+// CreateTag godoc
+// @Summary Create a new tag
+// @Description Add a new tag for the authenticated user
+// @Tags tags
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param tag body TagInput true "Tag data"
+// @Success 201 {object} models.Tag
+// @Failure 400 {object} object "Invalid input"
+// @Failure 401 {object} object "Unauthorized"
+// @Failure 409 {object} object "Tag already exists (e.g., {\"error\": \"Tag with this name already exists\"})"
+// @Failure 500 {object} object "Internal server error (e.g., {\"error\": \"Failed to create tag\"})"
+// @Router /tags [post]
+func CreateTag(c *gin.Context) {
+	userID, _ := c.Get("userID")
+
+	var input TagInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	name := strings.TrimSpace(input.Name)
+	if name == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Tag name must not be empty"})
+		return
+	}
+
+	var existing models.Tag
+	if err := config.DB.Where("user_id = ? AND name = ?", userID, name).First(&existing).Error; err == nil {
+		c.JSON(http.StatusConflict, gin.H{"error": "Tag with this name already exists", "tag": existing})
+		return
+	}
+
+	tag := models.Tag{UserID: userID.(uint), Name: name}
+	if err := config.DB.Create(&tag).Error; err != nil {
+		if isUniqueViolation(err) {
+			config.DB.Where("user_id = ? AND name = ?", userID, name).First(&existing)
+			c.JSON(http.StatusConflict, gin.H{"error": "Tag with this name already exists", "tag": existing})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create tag", "details": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, tag)
+}
+
+// RenameTag godoc
+// @Summary Rename a tag
+// @Description Renames a tag of the authenticated user. Use merge to combine it with an existing tag.
+// @Tags tags
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Tag ID"
+// @Param tag body TagInput true "New tag name"
+// @Success 200 {object} models.Tag
+// @Failure 400 {object} object "Invalid input"
+// @Failure 401 {object} object "Unauthorized"
+// @Failure 404 {object} object "Tag not found or access denied"
+// @Failure 409 {object} object "Another tag already has this name"
+// @Failure 500 {object} object "Internal server error (e.g., {\"error\": \"Failed to rename tag\"})"
+// @Router /tags/{id} [put]
+func RenameTag(c *gin.Context) {
+	userID, _ := c.Get("userID")
+	tagID := c.Param("id")
+
+	var tag models.Tag
+	if err := config.DB.Where("id = ? AND user_id = ?", tagID, userID).First(&tag).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Tag not found or access denied"})
+		return
+	}
+
+	var input TagInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	name := strings.TrimSpace(input.Name)
+	if name == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Tag name must not be empty"})
+		return
+	}
+
+	var existing models.Tag
+	if err := config.DB.Where("user_id = ? AND name = ? AND id <> ?", userID, name, tag.ID).First(&existing).Error; err == nil {
+		c.JSON(http.StatusConflict, gin.H{"error": "Another tag already has this name, merge them instead", "tag": existing})
+		return
+	}
+
+	if err := config.DB.Model(&tag).Update("name", name).Error; err != nil {
+		if isUniqueViolation(err) {
+			config.DB.Where("user_id = ? AND name = ? AND id <> ?", userID, name, tag.ID).First(&existing)
+			c.JSON(http.StatusConflict, gin.H{"error": "Another tag already has this name, merge them instead", "tag": existing})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to rename tag", "details": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, tag)
+}
+
+// MergeTag godoc
+// @Summary Merge a tag into another tag
+// @Description Moves all notes of the tag to the target tag and deletes the source tag
+// @Tags tags
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Source tag ID"
+// @Param merge body MergeTagsInput true "Target tag"
+// @Success 200 {object} models.Tag "The target tag"
+// @Failure 400 {object} object "Invalid input (e.g., {\"error\": \"Cannot merge a tag into itself\"})"
+// @Failure 401 {object} object "Unauthorized"
+// @Failure 404 {object} object "Tag not found or access denied"
+// @Failure 500 {object} object "Internal server error (e.g., {\"error\": \"Failed to merge tags\"})"
+// @Router /tags/{id}/merge [post]
+func MergeTag(c *gin.Context) {
+	userID, _ := c.Get("userID")
+	tagID := c.Param("id")
+
+	var input MergeTagsInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var source, target models.Tag
+	if err := config.DB.Where("id = ? AND user_id = ?", tagID, userID).First(&source).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Tag not found or access denied"})
+		return
+	}
+	if err := config.DB.Where("id = ? AND user_id = ?", input.TargetID, userID).First(&target).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Target tag not found or access denied"})
+		return
+	}
+	if source.ID == target.ID {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Cannot merge a tag into itself"})
+		return
+	}
+
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Exec("INSERT INTO note_tags (note_id, tag_id) SELECT note_id, ? FROM note_tags WHERE tag_id = ? ON CONFLICT DO NOTHING",
+			target.ID, source.ID).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&source).Error // note_tags rows of the source are removed by cascade
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to merge tags", "details": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, target)
+}
+
+// DeleteTag godoc
+// @Summary Delete a tag by ID
+// @Description Deletes a tag of the authenticated user. Notes keep existing, only the tag is detached.
+// @Tags tags
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Tag ID"
+// @Success 200 {object} object "Tag deleted successfully (e.g., {\"message\": \"Tag deleted successfully\"})"
+// @Failure 401 {object} object "Unauthorized"
+// @Failure 404 {object} object "Tag not found or access denied"
+// @Failure 500 {object} object "Internal server error (e.g., {\"error\": \"Failed to delete tag\"})"
+// @Router /tags/{id} [delete]
+func DeleteTag(c *gin.Context) {
+	userID, _ := c.Get("userID")
+	tagID := c.Param("id")
+
+	var tag models.Tag
+	if err := config.DB.Where("id = ? AND user_id = ?", tagID, userID).First(&tag).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Tag not found or access denied"})
+		return
+	}
+
+	if err := config.DB.Delete(&tag).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete tag", "details": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Tag deleted successfully"})
+}
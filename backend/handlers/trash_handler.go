@@ -0,0 +1,275 @@
+package handlers
+
+import (
+	"log"
+	"net/http"
+	"organizer-backend/config"
+	"organizer-backend/models"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// TrashResponse lists soft-deleted items of the user
+type TrashResponse struct {
+	Notes          []models.Note          `json:"notes"`
+	KnowledgeLinks []models.KnowledgeLink `json:"knowledgeLinks"`
+	RetentionDays  int                    `json:"retentionDays" example:"30"` // Items are purged automatically after this many days
+}
+
+// trashKind describes a soft-deletable model reachable through /trash/:kind, with the scopes personal access
+// tokens need to list it (read) and to restore or purge it (write)
+type trashKind struct {
+	newModel   func() interface{}
+	name       string
+	readScope  string
+	writeScope string
+	restored   func(tx *gorm.DB, userID, id interface{}) error // Optional, runs after an item was restored
+}
+
+var trashKinds = map[string]trashKind{
+	"notes":           {newModel: func() interface{} { return &models.Note{} }, name: "Note", readScope: models.ScopeNotesRead, writeScope: models.ScopeNotesWrite, restored: restoreNotebookPath},
+	"knowledge-links": {newModel: func() interface{} { return &models.KnowledgeLink{} }, name: "Knowledge link", readScope: models.ScopeLinksRead, writeScope: models.ScopeLinksWrite},
+}
+
+// tokenAllows reports whether the request may use the scope: login sessions always may, personal access
+// tokens only when they were granted it. The trash serves several kinds, so it cannot use RequireScopes.
+func tokenAllows(c *gin.Context, scope string) bool {
+	value, exists := c.Get("accessToken")
+	return !exists || value.(models.PersonalAccessToken).HasScope(scope)
+}
+
+// rejectMissingScope answers with 403 like RequireScopes when the token was not granted the scope
+func rejectMissingScope(c *gin.Context, scope string) bool {
+	if tokenAllows(c, scope) {
+		return false
+	}
+	c.JSON(http.StatusForbidden, gin.H{"error": "Token does not have the required scope", "requiredScope": scope})
+	return true
+}
+
+var trashRetentionDays = 30
+
+// trashedByUser selects soft-deleted rows of the model owned by the user
+func trashedByUser(model interface{}, userID interface{}) *gorm.DB {
+	return config.DB.Unscoped().Model(model).Where("user_id = ? AND deleted_at IS NOT NULL", userID)
+}
+
+// StartTrashPurger runs a background job that permanently deletes items older than TRASH_RETENTION_DAYS
+func StartTrashPurger() {
+	config.LoadEnv()
+	if days, err := strconv.Atoi(config.GetEnv("TRASH_RETENTION_DAYS", "30")); err == nil && days > 0 {
+		trashRetentionDays = days
+	} else {
+		log.Println("Warning: invalid TRASH_RETENTION_DAYS, using default of 30 days")
+	}
+	interval, err := time.ParseDuration(config.GetEnv("TRASH_PURGE_INTERVAL", "1h"))
+	if err != nil || interval <= 0 {
+		log.Println("Warning: invalid TRASH_PURGE_INTERVAL, using default of 1h")
+		interval = time.Hour
+	}
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for ; true; <-ticker.C {
+			purgeExpiredTrash()
+		}
+	}()
+}
+
+func purgeExpiredTrash() {
+	cutoff := time.Now().AddDate(0, 0, -trashRetentionDays)
+	for kindName, kind := range trashKinds {
+		result := config.DB.Unscoped().Where("deleted_at < ?", cutoff).Delete(kind.newModel())
+		if result.Error != nil {
+			log.Printf("Trash purger: failed to purge %s: %v", kindName, result.Error)
+			continue
+		}
+		if result.RowsAffected > 0 {
+			log.Printf("Trash purger: permanently deleted %d %s", result.RowsAffected, kindName)
+		}
+	}
+	// Notebooks deleted with strategy=trash, their notes expired with them
+	if err := config.DB.Unscoped().Where("deleted_at < ?", cutoff).Delete(&models.Notebook{}).Error; err != nil {
+		log.Printf("Trash purger: failed to purge notebooks: %v", err)
+	}
+}
+
+// GetTrash godoc
+// @Summary Get items in the trash
+// @Description Lists deleted notes and knowledge links of the authenticated user, most recently deleted first. Personal access tokens see only the kinds they have the read scope of (notes:read, links:read).
+// @Tags trash
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} handlers.TrashResponse
+// @Failure 401 {object} object "Unauthorized"
+// @Failure 403 {object} object "Token has neither notes:read nor links:read"
+// @Failure 500 {object} object "Internal server error (e.g., {\"error\": \"Failed to retrieve trash\"})"
+// @Router /trash [get]
+func GetTrash(c *gin.Context) {
+	userID, _ := c.Get("userID")
+
+	notes, links := tokenAllows(c, models.ScopeNotesRead), tokenAllows(c, models.ScopeLinksRead)
+	if !notes && !links {
+		rejectMissingScope(c, models.ScopeNotesRead)
+		return
+	}
+
+	response := TrashResponse{Notes: []models.Note{}, KnowledgeLinks: []models.KnowledgeLink{}, RetentionDays: trashRetentionDays}
+	if notes {
+		if err := trashedByUser(&models.Note{}, userID).Order("deleted_at DESC").Find(&response.Notes).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve trash", "details": err.Error()})
+			return
+		}
+	}
+	if links {
+		if err := trashedByUser(&models.KnowledgeLink{}, userID).Order("deleted_at DESC").Find(&response.KnowledgeLinks).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve trash", "details": err.Error()})
+			return
+		}
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// RestoreTrashItem godoc
+// @Summary Restore an item from the trash
+// @Description Restores a deleted note or knowledge link of the authenticated user. A note deleted together with its notebook brings the notebook and its parents back.
+// @Tags trash
+// @Produce json
+// @Security BearerAuth
+// @Param kind path string true "Item kind" Enums(notes, knowledge-links)
+// @Param id path int true "Item ID"
+// @Success 200 {object} object "Item restored (e.g., {\"message\": \"Note restored successfully\"})"
+// @Failure 400 {object} object "Unknown item kind"
+// @Failure 401 {object} object "Unauthorized"
+// @Failure 403 {object} object "Token does not have the write scope of the kind (notes:write, links:write)"
+// @Failure 404 {object} object "Item not found in trash"
+// @Failure 500 {object} object "Internal server error (e.g., {\"error\": \"Failed to restore item\"})"
+// @Router /trash/{kind}/{id}/restore [post]
+func RestoreTrashItem(c *gin.Context) {
+	userID, _ := c.Get("userID")
+
+	kind, ok := trashKinds[c.Param("kind")]
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Unknown item kind"})
+		return
+	}
+	if rejectMissingScope(c, kind.writeScope) {
+		return
+	}
+
+	var restored int64
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		result := tx.Unscoped().Model(kind.newModel()).Where("id = ? AND user_id = ? AND deleted_at IS NOT NULL", c.Param("id"), userID).Update("deleted_at", nil)
+		if result.Error != nil {
+			return result.Error
+		}
+		restored = result.RowsAffected
+		if restored == 0 || kind.restored == nil {
+			return nil
+		}
+		return kind.restored(tx, userID, c.Param("id"))
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to restore item", "details": err.Error()})
+		return
+	}
+	if restored == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": kind.name + " not found in trash"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": kind.name + " restored successfully"})
+}
+
+// PurgeTrashItem godoc
+// @Summary Permanently delete an item from the trash
+// @Description Permanently deletes a note or knowledge link that is already in the trash
+// @Tags trash
+// @Produce json
+// @Security BearerAuth
+// @Param kind path string true "Item kind" Enums(notes, knowledge-links)
+// @Param id path int true "Item ID"
+// @Success 200 {object} object "Item deleted permanently (e.g., {\"message\": \"Note deleted permanently\"})"
+// @Failure 400 {object} object "Unknown item kind"
+// @Failure 401 {object} object "Unauthorized"
+// @Failure 403 {object} object "Token does not have the write scope of the kind (notes:write, links:write)"
+// @Failure 404 {object} object "Item not found in trash"
+// @Failure 500 {object} object "Internal server error (e.g., {\"error\": \"Failed to delete item\"})"
+// @Router /trash/{kind}/{id} [delete]
+func PurgeTrashItem(c *gin.Context) {
+	userID, _ := c.Get("userID")
+
+	kind, ok := trashKinds[c.Param("kind")]
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Unknown item kind"})
+		return
+	}
+	if rejectMissingScope(c, kind.writeScope) {
+		return
+	}
+
+	result := trashedByUser(kind.newModel(), userID).Where("id = ?", c.Param("id")).Delete(kind.newModel())
+	if result.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete item", "details": result.Error.Error()})
+		return
+	}
+	if result.RowsAffected == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": kind.name + " not found in trash"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": kind.name + " deleted permanently"})
+}
+
+// EmptyTrash godoc
+// @Summary Empty the trash
+// @Description Permanently deletes all notes and knowledge links in the trash of the authenticated user. Personal access tokens delete only the kinds they have the write scope of (notes:write, links:write).
+// @Tags trash
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} object "Trash emptied (e.g., {\"message\": \"Trash emptied\", \"deleted\": 5})"
+// @Failure 401 {object} object "Unauthorized"
+// @Failure 403 {object} object "Token has neither notes:write nor links:write"
+// @Failure 500 {object} object "Internal server error (e.g., {\"error\": \"Failed to empty trash\"})"
+// @Router /trash [delete]
+func EmptyTrash(c *gin.Context) {
+	userID, _ := c.Get("userID")
+
+	var kinds []trashKind
+	for _, kind := range trashKinds {
+		if tokenAllows(c, kind.writeScope) {
+			kinds = append(kinds, kind)
+		}
+	}
+	if len(kinds) == 0 {
+		rejectMissingScope(c, models.ScopeNotesWrite)
+		return
+	}
+
+	var deleted int64
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		for _, kind := range kinds {
+			result := tx.Unscoped().Where("user_id = ? AND deleted_at IS NOT NULL", userID).Delete(kind.newModel())
+			if result.Error != nil {
+				return result.Error
+			}
+			deleted += result.RowsAffected
+		}
+		if !tokenAllows(c, models.ScopeNotesWrite) {
+			return nil
+		}
+		// Notebooks deleted with strategy=trash have no notes left to restore into them
+		return tx.Unscoped().Where("user_id = ? AND deleted_at IS NOT NULL", userID).Delete(&models.Notebook{}).Error
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to empty trash", "details": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Trash emptied", "deleted": deleted})
+}
@@ -0,0 +1,380 @@
+package handlers
+
+import (
+	"net/http"
+	"organizer-backend/config"
+	"organizer-backend/models"
+	"organizer-backend/utils"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const recoveryCodeCount = 10
+
+// TwoFactorSetupResponse contains the new TOTP secret for the authenticator app
+type TwoFactorSetupResponse struct {
+	Secret     string `json:"secret" example:"JBSWY3DPEHPK3PXP"`
+	OtpauthURI string `json:"otpauthUri" example:"otpauth://totp/Organizer:user@example.com?issuer=Organizer&secret=JBSWY3DPEHPK3PXP"`
+	QRCode     string `json:"qrCode" example:"data:image/png;base64,iVBORw0KGgo..."` // PNG with the otpauth URI
+}
+
+// RecoveryCodesResponse lists one-time recovery codes. They are shown only once.
+type RecoveryCodesResponse struct {
+	RecoveryCodes []string `json:"recoveryCodes" example:"k3j9d-x7q2m,p0w8e-r5t1y"`
+}
+
+// TwoFactorChallengeResponse is returned by login instead of tokens when the user has 2FA enabled
+type TwoFactorChallengeResponse struct {
+	TwoFactorRequired bool   `json:"twoFactorRequired" example:"true"`
+	ChallengeToken    string `json:"challengeToken" example:"eyJhbGciOiJIUzI1NiIsInR5cCI6IkpXVCJ9..."` // Pass to /auth/login/2fa
+	ExpiresIn         int    `json:"expiresIn" example:"300"`                                          // Challenge lifetime in seconds
+}
+
+type TwoFactorCodeInput struct {
+	Code string `json:"code" binding:"required" example:"123456"`
+}
+
+type DisableTwoFactorInput struct {
+	Password string `json:"password" binding:"required" example:"password123"`
+	Code     string `json:"code" binding:"required" example:"123456"` // TOTP or recovery code
+}
+
+type TwoFactorLoginInput struct {
+	ChallengeToken string `json:"challengeToken" binding:"required"`
+	Code           string `json:"code" binding:"required" example:"123456"` // TOTP or recovery code
+}
+
+// verifySecondFactor accepts a TOTP code or an unused recovery code of the user.
+// Must run in a transaction, the user row is locked so a code cannot be used twice concurrently.
+func verifySecondFactor(tx *gorm.DB, userID uint, code string) (bool, error) {
+	var user models.User
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Select("id, totp_secret, totp_last_step").First(&user, userID).Error; err != nil {
+		return false, err
+	}
+	if user.TOTPSecret == "" {
+		return false, nil
+	}
+
+	if step, ok := utils.ValidateTOTP(user.TOTPSecret, code, user.TOTPLastStep); ok {
+		return true, tx.Model(&user).UpdateColumn("totp_last_step", step).Error
+	}
+
+	result := tx.Model(&models.RecoveryCode{}).
+		Where("user_id = ? AND code_hash = ? AND used_at IS NULL", userID, utils.HashToken(utils.NormalizeRecoveryCode(code))).
+		Update("used_at", time.Now())
+	return result.RowsAffected > 0, result.Error
+}
+
+// replaceRecoveryCodes generates a new set of recovery codes, invalidating the old ones
+func replaceRecoveryCodes(tx *gorm.DB, userID uint) ([]string, error) {
+	codes, err := utils.GenerateRecoveryCodes(recoveryCodeCount)
+	if err != nil {
+		return nil, err
+	}
+	if err := tx.Where("user_id = ?", userID).Delete(&models.RecoveryCode{}).Error; err != nil {
+		return nil, err
+	}
+	records := make([]models.RecoveryCode, len(codes))
+	for i, code := range codes {
+		records[i] = models.RecoveryCode{UserID: userID, CodeHash: utils.HashToken(utils.NormalizeRecoveryCode(code))}
+	}
+	return codes, tx.Create(&records).Error
+}
+
+// SetupTwoFactor godoc
+// @Summary Start two-factor enrollment
+// @Description Generates a TOTP secret (RFC 6238) and returns it as otpauth URI and QR code. 2FA is enabled only after /users/me/2fa/confirm.
+// @Tags users
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} handlers.TwoFactorSetupResponse
+// @Failure 401 {object} object "Unauthorized"
+// @Failure 409 {object} object "Two-factor authentication is already enabled"
+// @Failure 500 {object} object "Internal server error (e.g., {\"error\": \"Failed to set up two-factor authentication\"})"
+// @Router /users/me/2fa/setup [post]
+func SetupTwoFactor(c *gin.Context) {
+	userID, _ := c.Get("userID")
+
+	var user models.User
+	if err := config.DB.First(&user, userID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return
+	}
+	if user.TwoFactorEnabled {
+		c.JSON(http.StatusConflict, gin.H{"error": "Two-factor authentication is already enabled"})
+		return
+	}
+
+	enrollment, err := utils.NewTOTPEnrollment(user.Email)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to set up two-factor authentication", "details": err.Error()})
+		return
+	}
+	if err := config.DB.Model(&user).Updates(map[string]interface{}{"totp_secret": enrollment.Secret, "totp_last_step": 0}).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to set up two-factor authentication", "details": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, TwoFactorSetupResponse{Secret: enrollment.Secret, OtpauthURI: enrollment.OtpauthURI, QRCode: enrollment.QRCodePNG})
+}
+
+// ConfirmTwoFactor godoc
+// @Summary Confirm two-factor enrollment
+// @Description Enables 2FA after checking the first code from the authenticator app and returns one-time recovery codes
+// @Tags users
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param code body TwoFactorCodeInput true "Code from the authenticator app"
+// @Success 200 {object} handlers.RecoveryCodesResponse
+// @Failure 400 {object} object "Invalid input or no enrollment in progress"
+// @Failure 401 {object} object "Unauthorized or invalid code (e.g., {\"error\": \"Invalid two-factor code\"})"
+// @Failure 409 {object} object "Two-factor authentication is already enabled"
+// @Failure 429 {object} object "Too many failed attempts, see the Retry-After header"
+// @Failure 500 {object} object "Internal server error (e.g., {\"error\": \"Failed to enable two-factor authentication\"})"
+// @Router /users/me/2fa/confirm [post]
+func ConfirmTwoFactor(c *gin.Context) {
+	userID, _ := c.Get("userID")
+
+	var input TwoFactorCodeInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var user models.User
+	if err := config.DB.First(&user, userID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return
+	}
+	if user.TwoFactorEnabled {
+		c.JSON(http.StatusConflict, gin.H{"error": "Two-factor authentication is already enabled"})
+		return
+	}
+	if user.TOTPSecret == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Start the enrollment with /users/me/2fa/setup first"})
+		return
+	}
+	lockKey := lockoutKey(user.ID, user.Email)
+	if rejectLockedAccount(c, lockKey) {
+		return
+	}
+
+	step, ok := utils.ValidateTOTP(user.TOTPSecret, input.Code, user.TOTPLastStep)
+	if !ok {
+		recordFailedAttempt(c, lockKey, user.ID, user.Email)
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid two-factor code"})
+		return
+	}
+
+	var codes []string
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&user).Updates(map[string]interface{}{"two_factor_enabled": true, "totp_last_step": step}).Error; err != nil {
+			return err
+		}
+		var err error
+		codes, err = replaceRecoveryCodes(tx, user.ID)
+		return err
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to enable two-factor authentication", "details": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, RecoveryCodesResponse{RecoveryCodes: codes})
+}
+
+// DisableTwoFactor godoc
+// @Summary Disable two-factor authentication
+// @Description Turns 2FA off. Requires the password and a TOTP or recovery code; remaining recovery codes are deleted.
+// @Tags users
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param credentials body DisableTwoFactorInput true "Password and code"
+// @Success 200 {object} handlers.MessageResponse "Two-factor authentication disabled"
+// @Failure 400 {object} object "Invalid input or 2FA not enabled"
+// @Failure 401 {object} object "Unauthorized, incorrect password or invalid code"
+// @Failure 429 {object} object "Too many failed attempts, see the Retry-After header"
+// @Failure 500 {object} object "Internal server error (e.g., {\"error\": \"Failed to disable two-factor authentication\"})"
+// @Router /users/me/2fa/disable [post]
+func DisableTwoFactor(c *gin.Context) {
+	userID, _ := c.Get("userID")
+
+	var input DisableTwoFactorInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var user models.User
+	if err := config.DB.First(&user, userID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return
+	}
+	if !user.TwoFactorEnabled {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Two-factor authentication is not enabled"})
+		return
+	}
+	lockKey := lockoutKey(user.ID, user.Email)
+	if rejectLockedAccount(c, lockKey) {
+		return
+	}
+	if !utils.CheckPasswordHash(input.Password, user.PasswordHash) {
+		recordFailedAttempt(c, lockKey, user.ID, user.Email)
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Incorrect password"})
+		return
+	}
+
+	valid := false
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		var err error
+		if valid, err = verifySecondFactor(tx, user.ID, input.Code); err != nil || !valid {
+			return err
+		}
+		if err := tx.Where("user_id = ?", user.ID).Delete(&models.RecoveryCode{}).Error; err != nil {
+			return err
+		}
+		return tx.Model(&user).Updates(map[string]interface{}{"two_factor_enabled": false, "totp_secret": "", "totp_last_step": 0}).Error
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to disable two-factor authentication", "details": err.Error()})
+		return
+	}
+	if !valid {
+		recordFailedAttempt(c, lockKey, user.ID, user.Email)
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid two-factor code"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Two-factor authentication disabled"})
+}
+
+// RegenerateRecoveryCodes godoc
+// @Summary Regenerate recovery codes
+// @Description Replaces all recovery codes with a new set. Requires a current TOTP or recovery code.
+// @Tags users
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param code body TwoFactorCodeInput true "TOTP or recovery code"
+// @Success 200 {object} handlers.RecoveryCodesResponse
+// @Failure 400 {object} object "Invalid input or 2FA not enabled"
+// @Failure 401 {object} object "Unauthorized or invalid code"
+// @Failure 429 {object} object "Too many failed attempts, see the Retry-After header"
+// @Failure 500 {object} object "Internal server error (e.g., {\"error\": \"Failed to regenerate recovery codes\"})"
+// @Router /users/me/2fa/recovery-codes [post]
+func RegenerateRecoveryCodes(c *gin.Context) {
+	userID, _ := c.Get("userID")
+
+	var input TwoFactorCodeInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var user models.User
+	if err := config.DB.Select("id, email, two_factor_enabled").First(&user, userID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return
+	}
+	if !user.TwoFactorEnabled {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Two-factor authentication is not enabled"})
+		return
+	}
+	lockKey := lockoutKey(user.ID, user.Email)
+	if rejectLockedAccount(c, lockKey) {
+		return
+	}
+
+	valid := false
+	var codes []string
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		var err error
+		if valid, err = verifySecondFactor(tx, user.ID, input.Code); err != nil || !valid {
+			return err
+		}
+		codes, err = replaceRecoveryCodes(tx, user.ID)
+		return err
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to regenerate recovery codes", "details": err.Error()})
+		return
+	}
+	if !valid {
+		recordFailedAttempt(c, lockKey, user.ID, user.Email)
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid two-factor code"})
+		return
+	}
+
+	c.JSON(http.StatusOK, RecoveryCodesResponse{RecoveryCodes: codes})
+}
+
+// LoginTwoFactor godoc
+// @Summary Finish a two-factor login
+// @Description Exchanges the challenge token from /auth/login and a TOTP or recovery code for an access and refresh token
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param credentials body TwoFactorLoginInput true "Challenge token and code"
+// @Success 200 {object} UserAuthResponse "Successfully logged in"
+// @Failure 400 {object} object "Invalid input"
+// @Failure 401 {object} object "Invalid or expired challenge, or invalid code (e.g., {\"error\": \"Invalid two-factor code\"})"
+// @Failure 403 {object} object "Account is disabled"
+// @Failure 429 {object} object "Too many requests or failed attempts, see the Retry-After header"
+// @Failure 500 {object} object "Internal server error (e.g., {\"error\": \"Failed to generate token\"})"
+// @Router /auth/login/2fa [post]
+func LoginTwoFactor(c *gin.Context) {
+	var input TwoFactorLoginInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input: " + err.Error()})
+		return
+	}
+
+	claims, err := utils.ValidateChallengeToken(input.ChallengeToken)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid or expired challenge token, log in again"})
+		return
+	}
+
+	lockKey := lockoutKey(claims.UserID, claims.Email)
+	if rejectLockedAccount(c, lockKey) {
+		return
+	}
+
+	valid := false
+	err = config.DB.Transaction(func(tx *gorm.DB) error {
+		valid, err = verifySecondFactor(tx, claims.UserID, input.Code)
+		return err
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to verify two-factor code", "details": err.Error()})
+		return
+	}
+	if !valid {
+		recordFailedAttempt(c, lockKey, claims.UserID, claims.Email)
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid two-factor code"})
+		return
+	}
+	utils.ResetLoginFailures(lockKey)
+
+	var user models.User
+	if err := config.DB.First(&user, claims.UserID).Error; err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid or expired challenge token, log in again"})
+		return
+	}
+	if rejectDisabledAccount(c, user) {
+		return
+	}
+	tokens, err := startSession(c, user)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate token"})
+		return
+	}
+	user.PasswordHash = ""
+
+	c.JSON(http.StatusOK, UserAuthResponse{TokenPairResponse: tokens, User: user})
+}
@@ -8,6 +8,7 @@ import (
 	"organizer-backend/utils"
 
 	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
 )
 
 // GetUserProfile godoc
@@ -44,6 +45,7 @@ type UpdateUserProfileInput struct {
 	Fullname string `json:"fullname"`
 	Age      int    `json:"age"`
 	Contacts string `json:"contacts"`
+	Locale   string `json:"locale" binding:"omitempty,oneof=ru en" example:"en"` // Language of emails, unchanged when omitted
 	// Email is generally not updated this way directly due to uniqueness and verification needs.
 	// TelegramHash might be updated via a different mechanism (e.g., bot interaction).
 }
@@ -86,6 +88,9 @@ func UpdateUserProfile(c *gin.Context) {
 	user.Fullname = input.Fullname
 	user.Age = input.Age
 	user.Contacts = input.Contacts
+	if input.Locale != "" {
+		user.Locale = input.Locale
+	}
 
 	if err := config.DB.Save(&user).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update profile", "details": err.Error()})
@@ -97,21 +102,23 @@ func UpdateUserProfile(c *gin.Context) {
 }
 
 type ChangePasswordInput struct {
-	CurrentPassword string `json:"currentPassword" binding:"required"`
-	NewPassword     string `json:"newPassword" binding:"required,min=6"`
+	CurrentPassword     string `json:"currentPassword" binding:"required"`
+	NewPassword         string `json:"newPassword" binding:"required,min=6"`
+	RevokeOtherSessions *bool  `json:"revokeOtherSessions" example:"true"` // Sign out all other devices, true when omitted
 }
 
 // ChangeUserPassword godoc
 // @Summary Change current user's password
-// @Description Change password for the authenticated user
+// @Description Change password for the authenticated user. By default all other sessions are revoked, pass revokeOtherSessions=false to keep them.
 // @Tags users
 // @Accept json
 // @Produce json
 // @Security BearerAuth
 // @Param passwords body ChangePasswordInput true "Password Change Data"
-// @Success 200 {object} object "Password changed successfully (e.g., {\"message\": \"Password changed successfully\"})"
+// @Success 200 {object} object "Password changed successfully (e.g., {\"message\": \"Password changed successfully\", \"revokedSessions\": 2})"
 // @Failure 400 {object} object "Invalid input (e.g., {\"error\": \"Новые пароли не совпадают\"})"
 // @Failure 401 {object} object "Unauthorized or incorrect current password (e.g., {\"error\": \"Incorrect current password\"})"
+// @Failure 429 {object} object "Too many failed attempts, see the Retry-After header"
 // @Failure 404 {object} object "User not found"
 // @Failure 500 {object} object "Internal server error (e.g., {\"error\": \"Failed to update password\"})"
 // @Router /users/me/password [post]
@@ -134,7 +141,12 @@ func ChangeUserPassword(c *gin.Context) {
 		return
 	}
 
+	lockKey := lockoutKey(user.ID, user.Email)
+	if rejectLockedAccount(c, lockKey) {
+		return
+	}
 	if !utils.CheckPasswordHash(input.CurrentPassword, user.PasswordHash) {
+		recordFailedAttempt(c, lockKey, user.ID, user.Email)
 		c.JSON(http.StatusUnauthorized, gin.H{"error": "Incorrect current password"})
 		return
 	}
@@ -146,10 +158,121 @@ func ChangeUserPassword(c *gin.Context) {
 	}
 
 	user.PasswordHash = newHashedPassword
-	if err := config.DB.Save(&user).Error; err != nil {
+	var revokedSessions int64
+	err = config.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Save(&user).Error; err != nil {
+			return err
+		}
+		if input.RevokeOtherSessions != nil && !*input.RevokeOtherSessions {
+			return nil
+		}
+		sessionID, _ := c.Get("sessionID")
+		revokedSessions, err = revokeSessions(tx.Where("user_id = ? AND id <> ?", user.ID, sessionID), "password-change")
+		return err
+	})
+	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update password", "details": err.Error()})
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"message": "Password changed successfully"})
+	c.JSON(http.StatusOK, gin.H{"message": "Password changed successfully", "revokedSessions": revokedSessions})
+}
+
+type RevisionPolicyInput struct {
+	RevisionLimit      *int `json:"revisionLimit" binding:"required,min=0" example:"50"`      // Max revisions kept per note, 0 = unlimited
+	RevisionMaxAgeDays *int `json:"revisionMaxAgeDays" binding:"required,min=0" example:"90"` // Max age of revisions in days, 0 = unlimited
+}
+
+// UpdateRevisionPolicy godoc
+// @Summary Update note revision retention policy
+// @Description Sets how many note revisions are kept and how old they can get. Existing revisions are pruned immediately.
+// @Tags users
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param policy body RevisionPolicyInput true "Retention policy"
+// @Success 200 {object} models.User
+// @Failure 400 {object} object "Invalid input"
+// @Failure 401 {object} object "Unauthorized"
+// @Failure 404 {object} object "User not found"
+// @Failure 500 {object} object "Internal server error (e.g., {\"error\": \"Failed to update revision policy\"})"
+// @Router /users/me/revision-policy [put]
+func UpdateRevisionPolicy(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User ID not found in token"})
+		return
+	}
+
+	var input RevisionPolicyInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var user models.User
+	if err := config.DB.First(&user, userID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return
+	}
+
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&user).Updates(map[string]interface{}{
+			"revision_limit":        *input.RevisionLimit,
+			"revision_max_age_days": *input.RevisionMaxAgeDays,
+		}).Error; err != nil {
+			return err
+		}
+		return pruneNoteRevisions(tx, user.ID, 0)
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update revision policy", "details": err.Error()})
+		return
+	}
+
+	user.PasswordHash = ""
+	c.JSON(http.StatusOK, user)
+}
+
+type WeatherPreferencesInput struct {
+	Units    string `json:"units" binding:"required,oneof=metric imperial" example:"metric"`
+	Language string `json:"language" binding:"required,oneof=ru en" example:"ru"`
+}
+
+// UpdateWeatherPreferences godoc
+// @Summary Update weather preferences
+// @Description Sets the units (metric: °C, imperial: °F) and the language of the personal weather dashboard
+// @Tags users
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param preferences body WeatherPreferencesInput true "Units and language"
+// @Success 200 {object} models.User
+// @Failure 400 {object} object "Invalid input"
+// @Failure 401 {object} object "Unauthorized"
+// @Failure 404 {object} object "User not found"
+// @Failure 500 {object} object "Internal server error (e.g., {\"error\": \"Failed to update weather preferences\"})"
+// @Router /users/me/weather-preferences [put]
+func UpdateWeatherPreferences(c *gin.Context) {
+	userID, _ := c.Get("userID")
+
+	var input WeatherPreferencesInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var user models.User
+	if err := config.DB.First(&user, userID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return
+	}
+
+	if err := config.DB.Model(&user).Updates(map[string]interface{}{"weather_units": input.Units, "weather_language": input.Language}).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update weather preferences", "details": err.Error()})
+		return
+	}
+
+	user.PasswordHash = ""
+	c.JSON(http.StatusOK, user)
 }
@@ -1,53 +1,35 @@
 package handlers
 
 import (
-	"encoding/json"
+	"context"
 	"fmt"
 	"log"
 	"math"
 	"net/http"
-	"net/url"
-	"organizer-backend/config"
 	"organizer-backend/models"
-	"sync"
-	"time"
+	"organizer-backend/utils"
+	"sort"
+	"strconv"
+	"strings"
 
 	"github.com/gin-gonic/gin"
 )
 
-const (
-	openWeatherMapURL   = "https://api.openweathermap.org/data/2.5/weather"
-	weatherAPIURL       = "http://api.weatherapi.com/v1/current.json"
-	openMeteoGeoURL     = "https://geocoding-api.open-meteo.com/v1/search"
-	openMeteoWeatherURL = "https://api.open-meteo.com/v1/forecast"
-)
-
-var (
-	openWeatherMapAPIKey string
-	weatherAPI_APIKey    string
-	httpClient           = &http.Client{Timeout: 10 * time.Second}
-)
-
-// InitializeWeatherKeys загружает ключи API при старте
-func InitializeWeatherKeys() {
-	config.LoadEnv()
-	openWeatherMapAPIKey = config.GetEnv("OPENWEATHERMAP_API_KEY", "")
-	weatherAPI_APIKey = config.GetEnv("WEATHERAPI_API_KEY", "")
-
-	if openWeatherMapAPIKey == "" || weatherAPI_APIKey == "" {
-		log.Println("Warning: One or more weather API keys are not set in .env file.")
-	}
-}
-
 // GetWeatherByCity godoc
 // @Summary Get weather data for a city from multiple sources
-// @Description Fetches current weather information from OpenWeatherMap, WeatherAPI.com, and Open-Meteo.
+// @Description Fetches current weather information from all enabled providers (OpenWeatherMap, WeatherAPI.com and Open-Meteo by default, see WEATHER_PROVIDERS) in parallel.
+// @Description Answers are cached per city and provider for WEATHER_CACHE_TTL; fetchedAt of each source tells its age, the X-Cache header (HIT, MISS, PARTIAL) whether the sources came from the cache.
+// @Description The temperatures are combined with the aggregation strategy (WEATHER_AGGREGATION by default). With three or more sources, those further than WEATHER_OUTLIER_THRESHOLD °C from the median are flagged as outliers and left out. spread and confidence tell how well the sources agree, failures lists the providers that did not answer and why.
 // @Tags weather
 // @Produce json
 // @Param city query string true "City name to fetch weather for" example("London")
+// @Param units query string false "metric (°C) or imperial (°F)" Enums(metric, imperial) default(metric)
+// @Param lang query string false "Language of the descriptions" Enums(ru, en) default(ru)
+// @Param aggregation query string false "How to combine the temperatures of the sources, WEATHER_AGGREGATION (median) by default" Enums(mean, median, trimmed, weighted)
 // @Success 200 {object} models.WeatherResponse
-// @Failure 400 {object} object "City parameter is missing (e.g., {\"error\": \"City parameter is required\"})"
-// @Failure 500 {object} object "Failed to fetch weather data or all sources failed (e.g., {\"error\": \"Failed to fetch weather data from any source\"})"
+// @Header 200 {string} X-Cache "HIT, MISS or PARTIAL"
+// @Failure 400 {object} object "City parameter is missing or units/lang/aggregation are invalid (e.g., {\"error\": \"City parameter is required\"})"
+// @Failure 500 {object} object "All sources failed (e.g., {\"error\": \"Failed to fetch weather data from any source\", \"failures\": [...]})"
 // @Router /weather [get]
 func GetWeatherByCity(c *gin.Context) {
 	cityName := c.Query("city")
@@ -56,246 +38,254 @@ func GetWeatherByCity(c *gin.Context) {
 		return
 	}
 
-	// Канал для сбора результатов от горутин
-	resultsChannel := make(chan models.WeatherSource, 3) // 3 источника
-	var wg sync.WaitGroup
-
-	// --- OpenWeatherMap ---
-	if openWeatherMapAPIKey != "" {
-		wg.Add(1)
-		go func(city string) {
-			defer wg.Done()
-			params := url.Values{}
-			params.Add("q", city)
-			params.Add("appid", openWeatherMapAPIKey)
-			params.Add("units", "metric") // Получать температуру в Цельсиях
-			params.Add("lang", "ru")      // По возможности на русском
-
-			resp, err := httpClient.Get(openWeatherMapURL + "?" + params.Encode())
-			if err != nil {
-				log.Printf("OpenWeatherMap request error for %s: %v", city, err)
-				return
-			}
-			defer resp.Body.Close()
-
-			if resp.StatusCode != http.StatusOK {
-				log.Printf("OpenWeatherMap API error for %s: Status %d", city, resp.StatusCode)
-				// Можно прочитать тело ошибки, если API его предоставляет
-				// var errResp interface{}
-				// json.NewDecoder(resp.Body).Decode(&errResp)
-				// log.Printf("OpenWeatherMap error body: %v", errResp)
-				return
-			}
-
-			var owmResp models.OpenWeatherMapResponse
-			if err := json.NewDecoder(resp.Body).Decode(&owmResp); err != nil {
-				log.Printf("OpenWeatherMap JSON decode error for %s: %v", city, err)
-				return
-			}
-
-			desc := "N/A"
-			if len(owmResp.Weather) > 0 {
-				desc = owmResp.Weather[0].Description
-			}
-			windSpeed := fmt.Sprintf("%.1f m/s", owmResp.Wind.Speed)
-			fullDesc := fmt.Sprintf("%s, ветер %s", desc, windSpeed)
-
-			resultsChannel <- models.WeatherSource{
-				Name:        "OpenWeatherMap",
-				Temp:        owmResp.Main.Temp,
-				Description: fullDesc,
-			}
-		}(cityName)
+	query, ok := weatherQueryFromRequest(c, cityName)
+	if !ok {
+		return
+	}
+	strategy, ok := aggregationFromRequest(c)
+	if !ok {
+		return
 	}
 
-	// --- WeatherAPI.com ---
-	if weatherAPI_APIKey != "" {
-		wg.Add(1)
-		go func(city string) {
-			defer wg.Done()
-			params := url.Values{}
-			params.Add("key", weatherAPI_APIKey)
-			params.Add("q", city)
-			params.Add("lang", "ru")
-
-			resp, err := httpClient.Get(weatherAPIURL + "?" + params.Encode())
-			if err != nil {
-				log.Printf("WeatherAPI.com request error for %s: %v", city, err)
-				return
-			}
-			defer resp.Body.Close()
-
-			if resp.StatusCode != http.StatusOK {
-				log.Printf("WeatherAPI.com API error for %s: Status %d", city, resp.StatusCode)
-				return
-			}
+	response, cache := currentWeather(c.Request.Context(), query, strategy)
+	c.Header("X-Cache", cache)
+	if len(response.Sources) == 0 {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch weather data from any source", "failures": response.Failures})
+		return
+	}
 
-			var wapiResp models.WeatherAPIResponse
-			if err := json.NewDecoder(resp.Body).Decode(&wapiResp); err != nil {
-				log.Printf("WeatherAPI.com JSON decode error for %s: %v", city, err)
-				return
-			}
-			windSpeed := fmt.Sprintf("%.1f km/h", wapiResp.Current.WindKph)
-			fullDesc := fmt.Sprintf("%s, ветер %s", wapiResp.Current.Condition.Text, windSpeed)
+	c.JSON(http.StatusOK, response)
+}
 
-			resultsChannel <- models.WeatherSource{
-				Name:        "WeatherAPI.com",
-				Temp:        wapiResp.Current.TempC,
-				Description: fullDesc,
-			}
-		}(cityName)
+// weatherQueryFromRequest reads the units and lang query parameters. When they are invalid it responds
+// with 400 and returns false.
+func weatherQueryFromRequest(c *gin.Context, city string) (utils.WeatherQuery, bool) {
+	query := utils.WeatherQuery{
+		City:     city,
+		Units:    c.DefaultQuery("units", models.WeatherUnitsMetric),
+		Language: c.DefaultQuery("lang", models.WeatherLanguageRussian),
+	}
+	if query.Units != models.WeatherUnitsMetric && query.Units != models.WeatherUnitsImperial {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "units must be metric or imperial"})
+		return query, false
 	}
+	if query.Language != models.WeatherLanguageRussian && query.Language != models.WeatherLanguageEnglish {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "lang must be ru or en"})
+		return query, false
+	}
+	return query, true
+}
 
-	// --- Open-Meteo ---
-	wg.Add(1)
-	go func(city string) {
-		defer wg.Done()
-		// 1. Geocode city to get latitude and longitude
-		geoParams := url.Values{}
-		geoParams.Add("name", city)
-		geoParams.Add("count", "1")
-		geoParams.Add("language", "ru") // или en
-		geoParams.Add("format", "json")
+// aggregationFromRequest reads the aggregation query parameter. When it is invalid it responds with 400
+// and returns false.
+func aggregationFromRequest(c *gin.Context) (string, bool) {
+	strategy := c.DefaultQuery("aggregation", utils.DefaultAggregation())
+	if !utils.IsAggregationStrategy(strategy) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "aggregation must be one of " + strings.Join(utils.AggregationStrategies, ", ")})
+		return strategy, false
+	}
+	return strategy, true
+}
 
-		geoResp, err := httpClient.Get(openMeteoGeoURL + "?" + geoParams.Encode())
-		if err != nil {
-			log.Printf("Open-Meteo geocoding request error for %s: %v", city, err)
-			return
-		}
-		defer geoResp.Body.Close()
+// currentWeather asks all providers and combines their answers with the strategy. The response has no
+// sources when none answered. The second result is the cache status of the sources.
+func currentWeather(ctx context.Context, query utils.WeatherQuery, strategy string) (models.WeatherResponse, string) {
+	sources, failures, cache := utils.FetchCurrentWeather(ctx, query)
+	for _, err := range failures {
+		log.Printf("Weather provider error for %s: %v", query.City, err)
+	}
+	response := models.WeatherResponse{
+		City:        query.City, // Можно использовать имя города из одного из API для консистентности, если они отличаются
+		Units:       query.Units,
+		Aggregation: strategy,
+		Sources:     sources,
+		Failures:    utils.WeatherFailures(failures),
+	}
+	if len(sources) == 0 {
+		// Если ни один источник не вернул данные (или ни один провайдер не включён)
+		log.Printf("No weather data could be fetched for city: %s", query.City)
+		return response, cache
+	}
 
-		if geoResp.StatusCode != http.StatusOK {
-			log.Printf("Open-Meteo geocoding API error for %s: Status %d", city, geoResp.StatusCode)
-			return
-		}
+	providers := make([]string, len(sources))
+	temps := make([]float64, len(sources))
+	for i, source := range sources {
+		providers[i], temps[i] = source.Provider, source.Temp
+	}
+	aggregate := utils.AggregateTemperatures(strategy, query.Units, providers, temps, len(sources)+len(failures))
+	for i := range response.Sources {
+		response.Sources[i].Outlier = aggregate.Outliers[i]
+	}
+	response.AverageTemp = aggregate.Value
+	response.Spread = aggregate.Spread
+	response.Confidence = aggregate.Confidence
+	return response, cache
+}
 
-		var geoData models.OpenMeteoGeocodingResponse
-		if err := json.NewDecoder(geoResp.Body).Decode(&geoData); err != nil {
-			log.Printf("Open-Meteo geocoding JSON decode error for %s: %v", city, err)
-			return
-		}
+// GetWeatherForecast godoc
+// @Summary Get a weather forecast for a city
+// @Description Fetches a daily forecast (min/max temperature, precipitation probability) and optionally hourly temperatures from all enabled providers that support forecasts, and combines them per date and per point in time with the aggregation strategy, leaving out outliers like for current weather. OpenWeatherMap forecasts up to 5 days in 3-hour steps, WeatherAPI.com up to 14 days (3 on the free plan), Open-Meteo up to 16 days. Forecasts are cached like current weather.
+// @Tags weather
+// @Produce json
+// @Param city query string true "City name to fetch the forecast for" example("London")
+// @Param days query int false "Number of days, starting today (1-16)" default(3)
+// @Param hourly query bool false "Include hourly temperatures" default(false)
+// @Param units query string false "metric (°C) or imperial (°F)" Enums(metric, imperial) default(metric)
+// @Param lang query string false "Language of the place lookup" Enums(ru, en) default(ru)
+// @Param aggregation query string false "How to combine the temperatures of the sources, WEATHER_AGGREGATION (median) by default" Enums(mean, median, trimmed, weighted)
+// @Success 200 {object} models.ForecastResponse
+// @Header 200 {string} X-Cache "HIT, MISS or PARTIAL"
+// @Failure 400 {object} object "Invalid parameters (e.g., {\"error\": \"City parameter is required\"})"
+// @Failure 500 {object} object "All sources failed (e.g., {\"error\": \"Failed to fetch forecast from any source\", \"failures\": [...]})"
+// @Router /weather/forecast [get]
+func GetWeatherForecast(c *gin.Context) {
+	cityName := c.Query("city")
+	if cityName == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "City parameter is required"})
+		return
+	}
+	days, err := strconv.Atoi(c.DefaultQuery("days", "3"))
+	if err != nil || days < 1 || days > maxForecastDays {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("days must be between 1 and %d", maxForecastDays)})
+		return
+	}
+	hourly, err := strconv.ParseBool(c.DefaultQuery("hourly", "false"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "hourly must be true or false"})
+		return
+	}
 
-		if len(geoData.Results) == 0 {
-			log.Printf("Open-Meteo: No geocoding results found for %s", city)
-			return
-		}
-		location := geoData.Results[0]
+	query, ok := weatherQueryFromRequest(c, cityName)
+	if !ok {
+		return
+	}
+	strategy, ok := aggregationFromRequest(c)
+	if !ok {
+		return
+	}
 
-		// 2. Get current weather using lat/lon
-		weatherParams := url.Values{}
-		weatherParams.Add("latitude", fmt.Sprintf("%.2f", location.Latitude))
-		weatherParams.Add("longitude", fmt.Sprintf("%.2f", location.Longitude))
-		weatherParams.Add("current", "temperature_2m,weather_code,wind_speed_10m") // Запрашиваем нужные поля
-		weatherParams.Add("timezone", "auto")                                      // Автоматическое определение таймзоны
+	forecasts, failures, cache := utils.FetchForecast(c.Request.Context(), query, days, hourly)
+	c.Header("X-Cache", cache)
+	for _, err := range failures {
+		log.Printf("Weather provider forecast error for %s: %v", cityName, err)
+	}
+	if len(forecasts) == 0 {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch forecast from any source", "failures": utils.WeatherFailures(failures)})
+		return
+	}
 
-		weatherResp, err := httpClient.Get(openMeteoWeatherURL + "?" + weatherParams.Encode())
-		if err != nil {
-			log.Printf("Open-Meteo weather request error for %s: %v", city, err)
-			return
-		}
-		defer weatherResp.Body.Close()
+	response := models.ForecastResponse{
+		City:        cityName,
+		Units:       query.Units,
+		Aggregation: strategy,
+		Daily:       aggregateDailyForecasts(forecasts, strategy, query.Units),
+		Sources:     forecasts,
+		Failures:    utils.WeatherFailures(failures),
+	}
+	response.Days = len(response.Daily)
+	if hourly {
+		response.Hourly = aggregateHourlyForecasts(forecasts, strategy, query.Units)
+	}
+	c.JSON(http.StatusOK, response)
+}
 
-		if weatherResp.StatusCode != http.StatusOK {
-			log.Printf("Open-Meteo weather API error for %s: Status %d", city, weatherResp.StatusCode)
-			return
-		}
+const maxForecastDays = 16 // The longest forecast of the built-in providers (Open-Meteo)
 
-		var omWeatherData models.OpenMeteoWeatherResponse
-		if err := json.NewDecoder(weatherResp.Body).Decode(&omWeatherData); err != nil {
-			log.Printf("Open-Meteo weather JSON decode error for %s: %v", city, err)
-			return
+// aggregateDailyForecasts combines the forecasts of all sources per date with the strategy, sorted by date.
+// Precipitation probabilities are averaged.
+func aggregateDailyForecasts(forecasts []models.SourceForecast, strategy, units string) []models.AggregatedDailyForecast {
+	type sourceDay struct {
+		provider string
+		day      models.DailyForecast
+	}
+	byDate := map[string][]sourceDay{}
+	for _, forecast := range forecasts {
+		for _, day := range forecast.Daily {
+			byDate[day.Date] = append(byDate[day.Date], sourceDay{forecast.Provider, day})
 		}
+	}
 
-		// Map WMO weather code to description (simplified)
-		// Полный список: https://www.nodc.noaa.gov/archive/arc0021/0002199/1.1/data/0-data/HTML/WMO-CODE/WMO4677.HTM
-		// или https://gist.github.com/stellasphere/9490c195ed2b53c707087c8c2db4ec0c
-		// Open-Meteo docs: https://open-meteo.com/en/docs#weathervariables
-		weatherDesc := mapWMOCodeToDescription(omWeatherData.CurrentWeather.WeatherCode)
-		windSpeed := fmt.Sprintf("%.1f km/h", omWeatherData.CurrentWeather.WindSpeed)
-		fullDesc := fmt.Sprintf("%s, ветер %s", weatherDesc, windSpeed)
-
-		resultsChannel <- models.WeatherSource{
-			Name:        "Open-Meteo",
-			Temp:        omWeatherData.CurrentWeather.Temperature,
-			Description: fullDesc,
+	aggregated := make([]models.AggregatedDailyForecast, 0, len(byDate))
+	for date, days := range byDate {
+		var providers []string
+		var tempMin, tempMax []float64
+		var probabilities []*float64
+		for _, day := range days {
+			providers = append(providers, day.provider)
+			tempMin = append(tempMin, day.day.TempMin)
+			tempMax = append(tempMax, day.day.TempMax)
+			probabilities = append(probabilities, day.day.PrecipitationProbability)
 		}
-
-	}(cityName)
-
-	// Ожидаем завершения всех горутин
-	go func() {
-		wg.Wait()
-		close(resultsChannel) // Закрываем канал, когда все горутины завершены
-	}()
-
-	// Собираем результаты
-	var sources []models.WeatherSource
-	var totalTemp float64
-	var validSourcesCount int
-
-	for result := range resultsChannel {
-		sources = append(sources, result)
-		totalTemp += result.Temp
-		validSourcesCount++
+		aggregated = append(aggregated, models.AggregatedDailyForecast{
+			DailyForecast: models.DailyForecast{
+				Date:                     date,
+				TempMin:                  utils.AggregateTemperatures(strategy, units, providers, tempMin, len(days)).Value,
+				TempMax:                  utils.AggregateTemperatures(strategy, units, providers, tempMax, len(days)).Value,
+				PrecipitationProbability: knownMean(probabilities),
+			},
+			Sources: len(days),
+		})
 	}
+	sort.Slice(aggregated, func(i, j int) bool { return aggregated[i].Date < aggregated[j].Date })
+	return aggregated
+}
 
-	if validSourcesCount == 0 {
-		// Если ни один источник не вернул данные (или ключи не установлены)
-		log.Printf("No weather data could be fetched for city: %s", cityName)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch weather data from any source"})
-		return
+// aggregateHourlyForecasts combines the forecasts of all sources per point in time, sorted by time
+func aggregateHourlyForecasts(forecasts []models.SourceForecast, strategy, units string) []models.AggregatedHourlyForecast {
+	type sourceHour struct {
+		provider string
+		hour     models.HourlyForecast
 	}
-
-	averageTemp := 0.0
-	if validSourcesCount > 0 {
-		averageTemp = totalTemp / float64(validSourcesCount)
+	byTime := map[int64][]sourceHour{}
+	for _, forecast := range forecasts {
+		for _, hour := range forecast.Hourly {
+			byTime[hour.Time.Unix()] = append(byTime[hour.Time.Unix()], sourceHour{forecast.Provider, hour})
+		}
 	}
-	averageTemp = math.Round(averageTemp*100) / 100
 
-	response := models.WeatherResponse{
-		City:        cityName, // Можно использовать имя города из одного из API для консистентности, если они отличаются
-		AverageTemp: averageTemp,
-		Sources:     sources,
+	aggregated := make([]models.AggregatedHourlyForecast, 0, len(byTime))
+	for _, hours := range byTime {
+		var providers []string
+		var temps []float64
+		var probabilities []*float64
+		for _, hour := range hours {
+			providers = append(providers, hour.provider)
+			temps = append(temps, hour.hour.Temp)
+			probabilities = append(probabilities, hour.hour.PrecipitationProbability)
+		}
+		aggregated = append(aggregated, models.AggregatedHourlyForecast{
+			HourlyForecast: models.HourlyForecast{
+				Time:                     hours[0].hour.Time,
+				Temp:                     utils.AggregateTemperatures(strategy, units, providers, temps, len(hours)).Value,
+				PrecipitationProbability: knownMean(probabilities),
+			},
+			Sources: len(hours),
+		})
 	}
+	sort.Slice(aggregated, func(i, j int) bool { return aggregated[i].Time.Before(aggregated[j].Time) })
+	return aggregated
+}
 
-	c.JSON(http.StatusOK, response)
+// roundedMean returns the mean rounded to two decimals
+func roundedMean(values []float64) float64 {
+	var total float64
+	for _, value := range values {
+		total += value
+	}
+	return math.Round(total/float64(len(values))*100) / 100
 }
 
-// mapWMOCodeToDescription - упрощенная функция для маппинга WMO кодов погоды Open-Meteo
-func mapWMOCodeToDescription(code int) string {
-	// Источник: https://open-meteo.com/en/docs WMO Weather interpretation codes (WW)
-	switch code {
-	case 0:
-		return "Ясно"
-	case 1:
-		return "В основном ясно"
-	case 2:
-		return "Переменная облачность"
-	case 3:
-		return "Пасмурно"
-	case 45, 48:
-		return "Туман" // и изморозь (иней)
-	case 51, 53, 55:
-		return "Морось" // легкая, умеренная, сильная
-	case 56, 57:
-		return "Ледяная морось" // легкая, сильная
-	case 61, 63, 65:
-		return "Дождь" // слабый, умеренный, сильный
-	case 66, 67:
-		return "Ледяной дождь" // слабый, сильный
-	case 71, 73, 75:
-		return "Снег" // слабый, умеренный, сильный
-	case 77:
-		return "Снежные зерна"
-	case 80, 81, 82:
-		return "Ливень" // слабый, умеренный, сильный
-	case 85, 86:
-		return "Снежный ливень" // слабый, сильный
-	case 95:
-		return "Гроза" // слабая или умеренная
-	case 96, 99:
-		return "Гроза с градом" // слабая, сильная
-	default:
-		return fmt.Sprintf("Код погоды: %d", code)
+// knownMean averages the values that are set, nil when none is
+func knownMean(values []*float64) *float64 {
+	var known []float64
+	for _, value := range values {
+		if value != nil {
+			known = append(known, *value)
+		}
+	}
+	if len(known) == 0 {
+		return nil
 	}
+	mean := roundedMean(known)
+	return &mean
 }
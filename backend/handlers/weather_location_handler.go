@@ -0,0 +1,309 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"organizer-backend/config"
+	"organizer-backend/models"
+	"organizer-backend/utils"
+	"strings"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const maxWeatherLocations = 20 // Per user, each one is a set of provider requests on the dashboard
+
+type WeatherLocationInput struct {
+	Name      string   `json:"name" binding:"required,max=100" example:"Home"`
+	Latitude  *float64 `json:"latitude" binding:"required,min=-90,max=90" example:"55.7558"`
+	Longitude *float64 `json:"longitude" binding:"required,min=-180,max=180" example:"37.6173"`
+	IsDefault bool     `json:"isDefault"` // The first location is always the default
+}
+
+// LocationWeather is the current weather of a saved location. Error is set when no provider answered,
+// weather.failures tells why.
+type LocationWeather struct {
+	Location models.WeatherLocation `json:"location"`
+	Weather  models.WeatherResponse `json:"weather"`
+	Error    string                 `json:"error,omitempty" example:"Failed to fetch weather data from any source"`
+}
+
+// PersonalWeatherResponse is the weather dashboard of the user
+type PersonalWeatherResponse struct {
+	Units     string            `json:"units" example:"metric"`
+	Language  string            `json:"language" example:"ru"`
+	Locations []LocationWeather `json:"locations"` // The default location first, then the oldest first
+}
+
+var errWeatherLocationLimit = errors.New("weather location limit reached")
+
+// GetPersonalWeather godoc
+// @Summary Get the current weather of all saved locations
+// @Description Fetches the current weather of all saved locations of the user in parallel, in the units and language of the user's weather preferences.
+// @Description Locations whose weather could not be fetched have an error. The temperatures are combined with the WEATHER_AGGREGATION strategy. The X-Cache header is HIT when all sources of all locations came from the cache, MISS when none did.
+// @Tags weather
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} handlers.PersonalWeatherResponse
+// @Header 200 {string} X-Cache "HIT, MISS or PARTIAL"
+// @Failure 401 {object} object "Unauthorized"
+// @Failure 404 {object} object "User not found"
+// @Failure 500 {object} object "Internal server error (e.g., {\"error\": \"Failed to retrieve locations\"})"
+// @Router /weather/me [get]
+func GetPersonalWeather(c *gin.Context) {
+	userID, _ := c.Get("userID")
+
+	var user models.User
+	if err := config.DB.First(&user, userID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return
+	}
+	locations := []models.WeatherLocation{}
+	if err := config.DB.Where("user_id = ?", userID).Order("is_default DESC, created_at, id").Find(&locations).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve locations", "details": err.Error()})
+		return
+	}
+
+	response := PersonalWeatherResponse{Units: user.WeatherUnits, Language: user.WeatherLanguage, Locations: make([]LocationWeather, len(locations))}
+	statuses := make([]string, len(locations))
+	var wg sync.WaitGroup
+	for i, location := range locations {
+		wg.Add(1)
+		go func(i int, location models.WeatherLocation) {
+			defer wg.Done()
+			query := utils.WeatherQuery{City: location.Name, Latitude: &location.Latitude, Longitude: &location.Longitude, Units: user.WeatherUnits, Language: user.WeatherLanguage}
+			weather, cache := currentWeather(c.Request.Context(), query, utils.DefaultAggregation())
+			response.Locations[i] = LocationWeather{Location: location, Weather: weather}
+			if len(weather.Sources) == 0 {
+				response.Locations[i].Error = "Failed to fetch weather data from any source"
+			}
+			statuses[i] = cache
+		}(i, location)
+	}
+	wg.Wait()
+
+	c.Header("X-Cache", utils.CombineCacheStatus(statuses...))
+	c.JSON(http.StatusOK, response)
+}
+
+// GetWeatherLocations godoc
+// @Summary Get saved weather locations
+// @Description Lists the saved locations of the user, the default location first
+// @Tags weather
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {array} models.WeatherLocation
+// @Failure 401 {object} object "Unauthorized"
+// @Failure 500 {object} object "Internal server error (e.g., {\"error\": \"Failed to retrieve locations\"})"
+// @Router /weather/locations [get]
+func GetWeatherLocations(c *gin.Context) {
+	userID, _ := c.Get("userID")
+
+	locations := []models.WeatherLocation{}
+	if err := config.DB.Where("user_id = ?", userID).Order("is_default DESC, created_at, id").Find(&locations).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve locations", "details": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, locations)
+}
+
+// CreateWeatherLocation godoc
+// @Summary Save a weather location
+// @Description Saves a named place for the weather dashboard, up to 20 per user. The first location, or one saved with isDefault, becomes the default.
+// @Tags weather
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param location body WeatherLocationInput true "Name and coordinates"
+// @Success 201 {object} models.WeatherLocation
+// @Failure 400 {object} object "Invalid input"
+// @Failure 401 {object} object "Unauthorized"
+// @Failure 409 {object} object "The user already has 20 locations"
+// @Failure 500 {object} object "Internal server error (e.g., {\"error\": \"Failed to save location\"})"
+// @Router /weather/locations [post]
+func CreateWeatherLocation(c *gin.Context) {
+	userID, _ := c.Get("userID")
+
+	var input WeatherLocationInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	name := strings.TrimSpace(input.Name)
+	if name == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Location name must not be empty"})
+		return
+	}
+
+	location := models.WeatherLocation{UserID: userID.(uint), Name: name, Latitude: *input.Latitude, Longitude: *input.Longitude, IsDefault: input.IsDefault}
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		// Concurrent creates would both see no locations and set a default, or both pass the limit
+		if err := lockWeatherLocations(tx, userID); err != nil {
+			return err
+		}
+		var count int64
+		if err := tx.Model(&models.WeatherLocation{}).Where("user_id = ?", userID).Count(&count).Error; err != nil {
+			return err
+		}
+		if count >= maxWeatherLocations {
+			return errWeatherLocationLimit
+		}
+		if count == 0 {
+			location.IsDefault = true
+		}
+		if location.IsDefault {
+			if err := unsetDefaultWeatherLocation(tx, userID); err != nil {
+				return err
+			}
+		}
+		return tx.Create(&location).Error
+	})
+	switch {
+	case errors.Is(err, errWeatherLocationLimit):
+		c.JSON(http.StatusConflict, gin.H{"error": "Location limit reached", "limit": maxWeatherLocations})
+		return
+	case err != nil:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save location", "details": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, location)
+}
+
+// UpdateWeatherLocation godoc
+// @Summary Update a saved weather location
+// @Description Changes the name and coordinates of a location. Setting isDefault makes it the default location; the default cannot be unset, make another location the default instead.
+// @Tags weather
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Location ID"
+// @Param location body WeatherLocationInput true "Name and coordinates"
+// @Success 200 {object} models.WeatherLocation
+// @Failure 400 {object} object "Invalid input"
+// @Failure 401 {object} object "Unauthorized"
+// @Failure 404 {object} object "Location not found or access denied"
+// @Failure 500 {object} object "Internal server error (e.g., {\"error\": \"Failed to update location\"})"
+// @Router /weather/locations/{id} [put]
+func UpdateWeatherLocation(c *gin.Context) {
+	userID, _ := c.Get("userID")
+
+	var location models.WeatherLocation
+	if err := config.DB.Where("id = ? AND user_id = ?", c.Param("id"), userID).First(&location).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Location not found or access denied"})
+		return
+	}
+
+	var input WeatherLocationInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	name := strings.TrimSpace(input.Name)
+	if name == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Location name must not be empty"})
+		return
+	}
+
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		if err := lockWeatherLocations(tx, userID); err != nil {
+			return err
+		}
+		if err := tx.First(&location, location.ID).Error; err != nil { // The default may have changed meanwhile
+			return err
+		}
+		if input.IsDefault && !location.IsDefault {
+			if err := unsetDefaultWeatherLocation(tx, userID); err != nil {
+				return err
+			}
+			location.IsDefault = true
+		}
+		return tx.Model(&location).Updates(map[string]interface{}{
+			"name":       name,
+			"latitude":   *input.Latitude,
+			"longitude":  *input.Longitude,
+			"is_default": location.IsDefault,
+		}).Error
+	})
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Location not found or access denied"})
+		return
+	case err != nil:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update location", "details": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, location)
+}
+
+// DeleteWeatherLocation godoc
+// @Summary Delete a saved weather location
+// @Description Deletes the location. When it was the default, the oldest remaining location becomes the default.
+// @Tags weather
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Location ID"
+// @Success 200 {object} handlers.MessageResponse "Location deleted"
+// @Failure 401 {object} object "Unauthorized"
+// @Failure 404 {object} object "Location not found or access denied"
+// @Failure 500 {object} object "Internal server error (e.g., {\"error\": \"Failed to delete location\"})"
+// @Router /weather/locations/{id} [delete]
+func DeleteWeatherLocation(c *gin.Context) {
+	userID, _ := c.Get("userID")
+
+	var location models.WeatherLocation
+	if err := config.DB.Where("id = ? AND user_id = ?", c.Param("id"), userID).First(&location).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Location not found or access denied"})
+		return
+	}
+
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		if err := lockWeatherLocations(tx, userID); err != nil {
+			return err
+		}
+		if err := tx.First(&location, location.ID).Error; err != nil { // The default may have changed meanwhile
+			return err
+		}
+		if err := tx.Delete(&location).Error; err != nil {
+			return err
+		}
+		if !location.IsDefault {
+			return nil
+		}
+		var next models.WeatherLocation
+		err := tx.Where("user_id = ?", userID).Order("created_at, id").First(&next).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		return tx.Model(&next).Update("is_default", true).Error
+	})
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Location not found or access denied"})
+		return
+	case err != nil:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete location", "details": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Location deleted"})
+}
+
+// lockWeatherLocations locks the user, so changes of the user's locations and of the default location run
+// one after another
+func lockWeatherLocations(tx *gorm.DB, userID interface{}) error {
+	return tx.Clauses(clause.Locking{Strength: "UPDATE"}).Select("id").First(&models.User{}, userID).Error
+}
+
+// unsetDefaultWeatherLocation clears the default flag of the user's locations, before another one is set
+func unsetDefaultWeatherLocation(tx *gorm.DB, userID interface{}) error {
+	return tx.Model(&models.WeatherLocation{}).Where("user_id = ? AND is_default", userID).Update("is_default", false).Error
+}
@@ -35,10 +35,17 @@ import (
 // @schemes http https
 
 func main() {
-	config.LoadEnv()                 // Load .env first
-	config.ConnectDatabase()         // Connect to Postgres
-	utils.InitJWT()                  // Initialize JWT secret
-	handlers.InitializeWeatherKeys() // Initialize Weather Keys (API)
+	config.LoadEnv()               // Load .env first
+	config.ConnectDatabase()       // Connect to Postgres
+	utils.InitJWT()                // Initialize JWT secret
+	utils.InitMailer()             // Select mail delivery (SMTP, file or log)
+	utils.InitPasswordHashing()    // bcrypt cost of password hashes
+	utils.InitRateLimits()         // Login rate limits and lockout
+	utils.InitOIDC()               // OpenID Connect providers for single sign-on
+	utils.InitWeatherProviders()   // Enabled weather providers and their API keys
+	handlers.StartRevisionPurger() // Expire old note revisions in background
+	handlers.StartTrashPurger()    // Purge old items from trash in background
+	handlers.StartAccountPurger()  // Delete accounts after their deletion grace period
 
 	router := routes.SetupRouter()
 	// gin.SetMode(gin.ReleaseMode)  // For Production
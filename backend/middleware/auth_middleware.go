@@ -2,8 +2,11 @@ package middleware
 
 import (
 	"net/http"
+	"organizer-backend/config"
+	"organizer-backend/models"
 	"organizer-backend/utils"
 	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
 )
@@ -25,6 +28,11 @@ func AuthMiddleware() gin.HandlerFunc {
 		}
 
 		tokenString := parts[1]
+		if strings.HasPrefix(tokenString, models.PersonalAccessTokenPrefix) {
+			authenticateAccessToken(c, tokenString)
+			return
+		}
+
 		claims, err := utils.ValidateJWT(tokenString)
 		if err != nil {
 			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid or expired token"})
@@ -32,8 +40,92 @@ func AuthMiddleware() gin.HandlerFunc {
 			return
 		}
 
+		// Access tokens stay valid until expiry unless their session is revoked (logout, token reuse)
+		var session models.Session
+		if err := config.DB.Select("id, revoked_at, last_seen_at").Where("id = ? AND user_id = ?", claims.SessionID, claims.UserID).First(&session).Error; err != nil || session.RevokedAt != nil {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Session has been revoked"})
+			c.Abort()
+			return
+		}
+		if time.Since(session.LastSeenAt) > time.Minute {
+			config.DB.Model(&session).UpdateColumn("last_seen_at", time.Now())
+		}
+
 		c.Set("userID", claims.UserID) // Set user ID in context for handlers
 		c.Set("userEmail", claims.Email)
+		c.Set("sessionID", claims.SessionID)
+		c.Set("userRole", claims.Role)
 		c.Next()
 	}
 }
+
+// authenticateAccessToken authenticates a request made with a personal access token. The token scopes are
+// stored in the context and checked by RequireScopes.
+func authenticateAccessToken(c *gin.Context, tokenString string) {
+	var token models.PersonalAccessToken
+	if err := config.DB.Preload("User").Where("token_hash = ?", utils.HashToken(tokenString)).First(&token).Error; err != nil || !token.IsActive() || token.User.DisabledAt != nil || token.User.DeletionScheduledAt != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid or expired token"})
+		c.Abort()
+		return
+	}
+	if token.LastUsedAt == nil || time.Since(*token.LastUsedAt) > time.Minute {
+		config.DB.Model(&token).UpdateColumn("last_used_at", time.Now())
+	}
+
+	c.Set("userID", token.UserID)
+	c.Set("userEmail", token.User.Email)
+	c.Set("userRole", token.User.Role)
+	c.Set("accessToken", token)
+	c.Next()
+}
+
+// RequireScopes limits personal access tokens to the read scope for GET and HEAD requests and to the write
+// scope for all other methods. An empty scope means the requests need a login session. Requests authenticated
+// with a session JWT are not limited. Must be used after AuthMiddleware.
+func RequireScopes(read, write string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		value, exists := c.Get("accessToken")
+		if !exists {
+			c.Next()
+			return
+		}
+		token := value.(models.PersonalAccessToken)
+
+		scope := write
+		if c.Request.Method == http.MethodGet || c.Request.Method == http.MethodHead {
+			scope = read
+		}
+		if scope == "" {
+			c.JSON(http.StatusForbidden, gin.H{"error": "This action is not available with personal access tokens"})
+			c.Abort()
+			return
+		}
+		if !token.HasScope(scope) {
+			c.JSON(http.StatusForbidden, gin.H{"error": "Token does not have the required scope", "requiredScope": scope})
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
+
+// SessionOnly rejects requests made with personal access tokens
+func SessionOnly() gin.HandlerFunc {
+	return RequireScopes("", "")
+}
+
+// RequireRole allows only users with one of the roles. The role comes from the access token; sessions are
+// revoked when a role is taken away, so it cannot be used after demotion. Must be used after AuthMiddleware.
+func RequireRole(roles ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		role, _ := c.Get("userRole")
+		for _, allowed := range roles {
+			if role == allowed {
+				c.Next()
+				return
+			}
+		}
+		c.JSON(http.StatusForbidden, gin.H{"error": "Insufficient permissions"})
+		c.Abort()
+	}
+}
@@ -0,0 +1,23 @@
+package middleware
+
+import (
+	"net/http"
+	"organizer-backend/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RateLimit allows utils.AuthRateLimit requests per utils.AuthRateWindow from one IP address to the routes
+// sharing the name. Further requests are answered with 429 and a Retry-After header.
+func RateLimit(name string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		allowed, retryAfter := utils.AllowRequest(name+":"+c.ClientIP(), utils.AuthRateLimit, utils.AuthRateWindow)
+		if !allowed {
+			c.Header("Retry-After", utils.RetryAfterSeconds(retryAfter))
+			c.JSON(http.StatusTooManyRequests, gin.H{"error": "Too many requests, try again later"})
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
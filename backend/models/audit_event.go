@@ -0,0 +1,28 @@
+package models
+
+import "time"
+
+// Audit event types. Events of the admin API have the acting admin as adminId in the details.
+const (
+	AuditAccountLocked            = "account.locked" // Too many failed logins, the account was locked out
+	AuditAccountDeletionScheduled = "account.deletion_scheduled"
+	AuditAccountDeletionCanceled  = "account.deletion_canceled"
+	AuditAccountDeleted           = "account.deleted" // Kept without email and user, only the former user ID is in the details
+	AuditUserDisabled             = "admin.user_disabled"
+	AuditUserEnabled              = "admin.user_enabled"
+	AuditRoleChanged              = "admin.role_changed" // Details also contain the old and new role
+	AuditPasswordResetForced      = "admin.password_reset_forced"
+)
+
+// AuditEvent records a security relevant event, e.g. an account lockout
+type AuditEvent struct {
+	ID        uint                   `gorm:"primaryKey" json:"id"`
+	UserID    *uint                  `gorm:"index" json:"userId"` // Null when the account does not exist
+	User      *User                  `gorm:"constraint:OnUpdate:CASCADE,OnDelete:CASCADE;" json:"-"`
+	Event     string                 `gorm:"size:64;not null;index" json:"event" example:"account.locked"`
+	Account   string                 `gorm:"size:255" json:"account" example:"user@example.com"` // Email the event refers to
+	IP        string                 `gorm:"size:45" json:"ip" example:"203.0.113.7"`
+	UserAgent string                 `gorm:"size:512" json:"userAgent"`
+	Details   map[string]interface{} `gorm:"type:jsonb;serializer:json" json:"details"`
+	CreatedAt time.Time              `gorm:"index" json:"createdAt"`
+}
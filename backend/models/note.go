@@ -6,15 +6,43 @@ import (
 	"gorm.io/gorm"
 )
 
+// NoteLanguages lists the PostgreSQL text search configurations allowed for notes.
+// "russian" also stems latin words with the english stemmer, so it is the default.
+var NoteLanguages = []string{"russian", "english", "simple"}
+
 type Note struct {
-	ID        uint      `gorm:"primaryKey" json:"id"`
-	UserID    uint      `gorm:"not null" json:"userId"` // Foreign key
-	User      User      `gorm:"constraint:OnUpdate:CASCADE,OnDelete:SET NULL;" json:"-"`
-	Title     string    `json:"title"`
-	Content   string    `gorm:"type:text" json:"content"`
-	CreatedAt time.Time `json:"createdAt"`
-	UpdatedAt time.Time `json:"updatedAt"`
-	Date      string    `gorm:"-" json:"date"`
+	ID         uint           `gorm:"primaryKey" json:"id"`
+	UserID     uint           `gorm:"not null;index:idx_notes_user_created,priority:1;index:idx_notes_user_updated,priority:1" json:"userId"` // Foreign key
+	User       User           `gorm:"constraint:OnUpdate:CASCADE,OnDelete:CASCADE;" json:"-"`
+	NotebookID *uint          `gorm:"index" json:"notebookId"` // nil for notes outside notebooks
+	Notebook   *Notebook      `gorm:"constraint:OnUpdate:CASCADE,OnDelete:SET NULL;" json:"-"`
+	Title      string         `json:"title"`
+	Content    string         `gorm:"type:text" json:"content"`
+	Language   string         `gorm:"type:regconfig;not null;default:'russian'" json:"language"` // Text search configuration
+	Version    int            `gorm:"not null;default:1" json:"version"`                         // Incremented on every update, used for ETag / If-Match
+	CreatedAt  time.Time      `gorm:"index:idx_notes_user_created,priority:2" json:"createdAt"`  // Indexes back the sort options of GetNotes
+	UpdatedAt  time.Time      `gorm:"index:idx_notes_user_updated,priority:2" json:"updatedAt"`
+	DeletedAt  gorm.DeletedAt `gorm:"index" json:"deletedAt,omitempty" swaggertype:"string" format:"date-time"` // Set when moved to trash
+	Date       string         `gorm:"-" json:"date"`
+	Tags       []Tag          `gorm:"many2many:note_tags;" json:"tags"`
+
+	// Full-text search vector, maintained by PostgreSQL (generated column)
+	SearchVector string `gorm:"->:false;<-:false;type:tsvector GENERATED ALWAYS AS (setweight(to_tsvector(language, coalesce(title, '')), 'A') || setweight(to_tsvector(language, coalesce(content, '')), 'B')) STORED;index:idx_notes_search_vector,type:gin" json:"-"`
+
+	// Filled only by search queries (see handlers.GetNotes)
+	Rank           float64 `gorm:"->;-:migration" json:"rank,omitempty"`
+	TitleHighlight string  `gorm:"->;-:migration" json:"titleHighlight,omitempty"`
+	Snippet        string  `gorm:"->;-:migration" json:"snippet,omitempty"`
+}
+
+// IsNoteLanguage reports whether lang is a supported text search configuration
+func IsNoteLanguage(lang string) bool {
+	for _, l := range NoteLanguages {
+		if l == lang {
+			return true
+		}
+	}
+	return false
 }
 
 // GORM Hook to set Date field for frontend compatibility
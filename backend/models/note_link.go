@@ -0,0 +1,16 @@
+package models
+
+import "time"
+
+// NoteLink is a wiki-style [[...]] reference from one note to another
+type NoteLink struct {
+	ID           uint      `gorm:"primaryKey" json:"id"`
+	UserID       uint      `gorm:"not null;index" json:"userId"`
+	User         User      `gorm:"constraint:OnUpdate:CASCADE,OnDelete:CASCADE;" json:"-"`
+	SourceNoteID uint      `gorm:"not null;index" json:"sourceNoteId"`
+	SourceNote   Note      `gorm:"constraint:OnUpdate:CASCADE,OnDelete:CASCADE;" json:"-"`
+	TargetNoteID *uint     `gorm:"index" json:"targetNoteId"` // nil while the link is unresolved
+	TargetNote   *Note     `gorm:"constraint:OnUpdate:CASCADE,OnDelete:SET NULL;" json:"-"`
+	Target       string    `gorm:"not null" json:"target"` // Reference as written: "Note Title" or "id:123"
+	CreatedAt    time.Time `json:"createdAt"`
+}
@@ -0,0 +1,14 @@
+package models
+
+import "time"
+
+// NoteRevision stores the previous title/content of a note before each update
+type NoteRevision struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	NoteID    uint      `gorm:"not null;uniqueIndex:idx_note_revisions_note_rev" json:"noteId"`
+	Note      Note      `gorm:"constraint:OnUpdate:CASCADE,OnDelete:CASCADE;" json:"-"`
+	Revision  int       `gorm:"not null;uniqueIndex:idx_note_revisions_note_rev" json:"revision"` // Sequential number per note
+	Title     string    `json:"title"`
+	Content   string    `gorm:"type:text" json:"content,omitempty"`
+	CreatedAt time.Time `json:"createdAt"`
+}
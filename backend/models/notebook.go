@@ -0,0 +1,21 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Notebook groups notes, notebooks can be nested through ParentID
+type Notebook struct {
+	ID        uint           `gorm:"primaryKey" json:"id"`
+	UserID    uint           `gorm:"not null;index" json:"userId"`
+	User      User           `gorm:"constraint:OnUpdate:CASCADE,OnDelete:CASCADE;" json:"-"`
+	ParentID  *uint          `gorm:"index" json:"parentId"` // nil for top-level notebooks
+	Parent    *Notebook      `gorm:"constraint:OnUpdate:CASCADE,OnDelete:CASCADE;" json:"-"`
+	Name      string         `gorm:"not null" json:"name"`
+	CreatedAt time.Time      `json:"createdAt"`
+	UpdatedAt time.Time      `json:"updatedAt"`
+	DeletedAt gorm.DeletedAt `gorm:"index" json:"deletedAt,omitempty" swaggertype:"string" format:"date-time"` // Set when deleted with its notes to the trash, so restored notes get their notebook back
+	NoteCount int64          `gorm:"->;-:migration" json:"noteCount"`                                          // Filled by handlers.GetNotebooks
+}
@@ -0,0 +1,48 @@
+package models
+
+import "time"
+
+// Scopes of personal access tokens
+const (
+	ScopeNotesRead   = "notes:read"   // Read notes, notebooks, tags and trash
+	ScopeNotesWrite  = "notes:write"  // Create, change and delete notes, notebooks and tags
+	ScopeLinksRead   = "links:read"   // Read knowledge base links
+	ScopeLinksWrite  = "links:write"  // Create and delete knowledge base links
+	ScopeProfileRead = "profile:read" // Read the user profile
+)
+
+// TokenScopes lists all scopes a personal access token can be granted
+var TokenScopes = []string{ScopeNotesRead, ScopeNotesWrite, ScopeLinksRead, ScopeLinksWrite, ScopeProfileRead}
+
+// PersonalAccessTokenPrefix starts every personal access token, which tells them apart from JWTs
+const PersonalAccessTokenPrefix = "org_pat_"
+
+// PersonalAccessToken is a long-lived token for scripts and integrations, limited to its scopes.
+// Only its hash is stored, the token itself is shown once at creation.
+type PersonalAccessToken struct {
+	ID          uint       `gorm:"primaryKey" json:"id"`
+	UserID      uint       `gorm:"not null;uniqueIndex:idx_pat_user_name" json:"-"`
+	User        User       `gorm:"constraint:OnUpdate:CASCADE,OnDelete:CASCADE;" json:"-"`
+	Name        string     `gorm:"not null;size:100;uniqueIndex:idx_pat_user_name" json:"name" example:"CI note sync"`
+	TokenHash   string     `gorm:"size:64;not null;uniqueIndex" json:"-"`                      // SHA-256 of the token
+	TokenPrefix string     `gorm:"size:16;not null" json:"tokenPrefix" example:"org_pat_k3J9"` // First characters of the token, to recognize it
+	Scopes      []string   `gorm:"type:jsonb;not null;serializer:json" json:"scopes" example:"notes:read,notes:write"`
+	ExpiresAt   *time.Time `json:"expiresAt"`  // Never expires when null
+	LastUsedAt  *time.Time `json:"lastUsedAt"` // Updated at most once a minute by AuthMiddleware
+	CreatedAt   time.Time  `json:"createdAt"`
+}
+
+// IsActive reports whether the token can still be used
+func (t PersonalAccessToken) IsActive() bool {
+	return t.ExpiresAt == nil || time.Now().Before(*t.ExpiresAt)
+}
+
+// HasScope reports whether the token was granted the scope
+func (t PersonalAccessToken) HasScope(scope string) bool {
+	for _, s := range t.Scopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
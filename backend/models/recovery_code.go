@@ -0,0 +1,13 @@
+package models
+
+import "time"
+
+// RecoveryCode is a one-time code that replaces a TOTP code when the authenticator is lost
+type RecoveryCode struct {
+	ID        uint       `gorm:"primaryKey"`
+	UserID    uint       `gorm:"not null;index"`
+	User      User       `gorm:"constraint:OnUpdate:CASCADE,OnDelete:CASCADE;"`
+	CodeHash  string     `gorm:"size:64;not null"` // SHA-256 of the normalized code
+	UsedAt    *time.Time // Set when the code was used, used codes are kept for auditing
+	CreatedAt time.Time
+}
@@ -0,0 +1,35 @@
+package models
+
+import "time"
+
+// Session is a login of a user. Its refresh token is rotated on every refresh; all tokens issued
+// for the session form one family that is revoked together.
+type Session struct {
+	ID               uint       `gorm:"primaryKey" json:"id"`
+	UserID           uint       `gorm:"not null;index" json:"-"`
+	User             User       `gorm:"constraint:OnUpdate:CASCADE,OnDelete:CASCADE;" json:"-"`
+	RefreshTokenHash string     `gorm:"size:64;not null;uniqueIndex" json:"-"` // SHA-256 of the current refresh token
+	ExpiresAt        time.Time  `gorm:"not null" json:"expiresAt"`             // Refresh token expiry, extended on rotation
+	RevokedAt        *time.Time `json:"revokedAt,omitempty"`
+	RevokedReason    string     `gorm:"size:64" json:"-"` // logout, logout-all, token-reuse, ...
+	UserAgent        string     `gorm:"size:512" json:"userAgent" example:"Mozilla/5.0 (X11; Linux x86_64) Firefox/128.0"`
+	IP               string     `gorm:"size:45" json:"ip" example:"203.0.113.7"` // Address of the last login or refresh
+	LastSeenAt       time.Time  `json:"lastSeenAt"`                              // Updated at most once a minute by AuthMiddleware
+	Current          bool       `gorm:"-" json:"current"`                        // Set for the session of the request in session listings
+	CreatedAt        time.Time  `json:"createdAt"`
+	UpdatedAt        time.Time  `json:"updatedAt"`
+}
+
+// RotatedRefreshToken remembers refresh tokens that were already exchanged, so their reuse can be detected
+type RotatedRefreshToken struct {
+	ID        uint      `gorm:"primaryKey"`
+	SessionID uint      `gorm:"not null;index"`
+	Session   Session   `gorm:"constraint:OnUpdate:CASCADE,OnDelete:CASCADE;"`
+	TokenHash string    `gorm:"size:64;not null;uniqueIndex"`
+	CreatedAt time.Time // When the token was rotated
+}
+
+// IsActive reports whether the session can still be used
+func (s Session) IsActive() bool {
+	return s.RevokedAt == nil && time.Now().Before(s.ExpiresAt)
+}
@@ -0,0 +1,27 @@
+package models
+
+import "time"
+
+type Tag struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	UserID    uint      `gorm:"not null;uniqueIndex:idx_tags_user_name" json:"userId"`
+	User      User      `gorm:"constraint:OnUpdate:CASCADE,OnDelete:CASCADE;" json:"-"`
+	Name      string    `gorm:"not null;size:64;uniqueIndex:idx_tags_user_name" json:"name"`
+	CreatedAt time.Time `json:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+// NoteTag is the join table between notes and tags (many2many "note_tags")
+type NoteTag struct {
+	NoteID uint `gorm:"primaryKey"`
+	TagID  uint `gorm:"primaryKey;index"`
+	Note   Note `gorm:"constraint:OnUpdate:CASCADE,OnDelete:CASCADE;"`
+	Tag    Tag  `gorm:"constraint:OnUpdate:CASCADE,OnDelete:CASCADE;"`
+}
+
+// TagUsage is a tag together with the number of notes it is attached to
+type TagUsage struct {
+	ID        uint   `json:"id"`
+	Name      string `json:"name"`
+	NoteCount int64  `json:"noteCount"`
+}
@@ -4,16 +4,34 @@ import (
 	"time"
 )
 
+// User roles
+const (
+	RoleUser  = "user"
+	RoleAdmin = "admin" // Access to the admin API
+)
+
 type User struct {
-	ID             uint            `gorm:"primaryKey" json:"id"`
-	Email          string          `gorm:"uniqueIndex;not null" json:"email"`
-	PasswordHash   string          `gorm:"not null" json:"-"` // Don't send password hash in request JSON
-	Fullname       string          `json:"fullname"`
-	Age            int             `json:"age"`
-	Contacts       string          `json:"contacts"`
-	TelegramHash   string          `json:"telegramHash"`
-	CreatedAt      time.Time       `json:"createdAt"`
-	UpdatedAt      time.Time       `json:"updatedAt"`
-	Notes          []Note          `gorm:"foreignKey:UserID" json:"-"` // For GORM relations
-	KnowledgeLinks []KnowledgeLink `gorm:"foreignKey:UserID" json:"-"` // For GORM relations
+	ID                  uint            `gorm:"primaryKey" json:"id"`
+	Email               string          `gorm:"uniqueIndex;not null" json:"email"`
+	EmailVerified       bool            `gorm:"not null;default:false" json:"emailVerified"`
+	Locale              string          `gorm:"size:8;not null;default:'ru'" json:"locale" example:"ru"` // Language of emails sent to the user (ru, en)
+	PasswordHash        string          `gorm:"not null" json:"-"`                                       // Don't send password hash in request JSON
+	Fullname            string          `json:"fullname"`
+	Age                 int             `json:"age"`
+	Contacts            string          `json:"contacts"`
+	TelegramHash        string          `json:"telegramHash"`
+	RevisionLimit       int             `gorm:"not null;default:50" json:"revisionLimit"`     // Max note revisions kept per note, 0 = unlimited
+	RevisionMaxAgeDays  int             `gorm:"not null;default:0" json:"revisionMaxAgeDays"` // Max age of note revisions in days, 0 = unlimited
+	TwoFactorEnabled    bool            `gorm:"not null;default:false" json:"twoFactorEnabled"`
+	TOTPSecret          string          `gorm:"size:64" json:"-"`            // Base32 secret, set on enrollment and active once confirmed
+	TOTPLastStep        int64           `gorm:"not null;default:0" json:"-"` // Time step of the last accepted code, blocks code replay
+	Role                string          `gorm:"size:16;not null;default:'user'" json:"role" example:"user"`
+	WeatherUnits        string          `gorm:"size:8;not null;default:'metric'" json:"weatherUnits" example:"metric"` // Units of the personal weather dashboard (metric, imperial)
+	WeatherLanguage     string          `gorm:"size:8;not null;default:'ru'" json:"weatherLanguage" example:"ru"`      // Language of weather descriptions (ru, en)
+	DisabledAt          *time.Time      `json:"disabledAt,omitempty"`                                                  // Set by an admin, disabled users cannot log in
+	DeletionScheduledAt *time.Time      `json:"deletionScheduledAt,omitempty"`                                         // The account and all its data are deleted at this time unless the user cancels
+	CreatedAt           time.Time       `json:"createdAt"`
+	UpdatedAt           time.Time       `json:"updatedAt"`
+	Notes               []Note          `gorm:"foreignKey:UserID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;" json:"-"` // For GORM relations, defines the foreign key of notes (Note.User is ignored)
+	KnowledgeLinks      []KnowledgeLink `gorm:"foreignKey:UserID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;" json:"-"` // For GORM relations, defines the foreign key of links
 }
@@ -0,0 +1,27 @@
+package models
+
+import "time"
+
+// UserIdentity links a user to an account at an OpenID Connect provider
+type UserIdentity struct {
+	ID          uint      `gorm:"primaryKey" json:"id"`
+	UserID      uint      `gorm:"not null;index" json:"-"`
+	User        User      `gorm:"constraint:OnUpdate:CASCADE,OnDelete:CASCADE;" json:"-"`
+	Provider    string    `gorm:"size:64;not null;uniqueIndex:idx_identity_provider_subject" json:"provider" example:"company"`
+	Subject     string    `gorm:"size:255;not null;uniqueIndex:idx_identity_provider_subject" json:"subject"` // "sub" claim, stable at the provider
+	Email       string    `json:"email" example:"user@example.com"`                                           // Email reported by the provider at the last login
+	LastLoginAt time.Time `json:"lastLoginAt"`
+	CreatedAt   time.Time `json:"createdAt"`
+}
+
+// OIDCLoginState is a started OIDC login, waiting for the provider to redirect back. It is deleted when used.
+type OIDCLoginState struct {
+	ID           uint      `gorm:"primaryKey"`
+	StateHash    string    `gorm:"size:64;not null;uniqueIndex"` // SHA-256 of the state parameter
+	Provider     string    `gorm:"size:64;not null"`
+	CodeVerifier string    `gorm:"size:128;not null"` // PKCE verifier for the token request
+	Nonce        string    `gorm:"size:64;not null"`  // Must match the nonce claim of the ID token
+	RedirectPath string    `gorm:"size:512"`          // Frontend path to continue at after the login
+	ExpiresAt    time.Time `gorm:"not null;index"`
+	CreatedAt    time.Time
+}
@@ -0,0 +1,22 @@
+package models
+
+import "time"
+
+// Purposes of user tokens
+const (
+	TokenPurposeEmailVerification = "verify-email"
+	TokenPurposePasswordReset     = "reset-password"
+	TokenPurposeOIDCLogin         = "oidc-login" // One-time code the frontend exchanges for tokens after an OIDC login
+)
+
+// UserToken is a single-use token sent to the user, e.g. in an email link. Only its hash is stored.
+type UserToken struct {
+	ID        uint       `gorm:"primaryKey"`
+	UserID    uint       `gorm:"not null;index"`
+	User      User       `gorm:"constraint:OnUpdate:CASCADE,OnDelete:CASCADE;"`
+	Purpose   string     `gorm:"size:32;not null"`
+	TokenHash string     `gorm:"size:64;not null;uniqueIndex"` // SHA-256 of the token
+	ExpiresAt time.Time  `gorm:"not null"`
+	UsedAt    *time.Time // Set when the token was consumed or replaced by a newer one
+	CreatedAt time.Time
+}
@@ -1,18 +1,48 @@
 package models
 
+import "time"
+
+// Units of weather answers
+const (
+	WeatherUnitsMetric   = "metric"   // °C, wind in m/s or km/h
+	WeatherUnitsImperial = "imperial" // °F, wind in mph
+)
+
+// Languages of weather descriptions
+const (
+	WeatherLanguageRussian = "ru"
+	WeatherLanguageEnglish = "en"
+)
+
 // WeatherSource represents data from a single weather provider
 type WeatherSource struct {
-	Name        string  `json:"name"`
-	Temp        float64 `json:"temp"` // Temperature in Celsius
-	Description string  `json:"description"`
+	Provider    string    `json:"provider" example:"openmeteo"` // Provider ID, as in WEATHER_PROVIDERS
+	Name        string    `json:"name"`
+	Temp        float64   `json:"temp"` // Temperature in the units of the response
+	Description string    `json:"description"`
+	Outlier     bool      `json:"outlier"`   // Too far from the other sources, not part of averageTemp
+	FetchedAt   time.Time `json:"fetchedAt"` // When the provider was asked, earlier than the request for cached answers
 	// IconClass string  `json:"iconClass,omitempty"` // Optional: for weather icons
 }
 
+// ProviderFailure tells why a provider did not answer
+type ProviderFailure struct {
+	Provider   string `json:"provider" example:"weatherapi"`
+	Kind       string `json:"kind" example:"status"`                   // request, status, decode or not_found
+	StatusCode int    `json:"statusCode,omitempty" example:"401"`      // HTTP status of the provider for the status kind
+	Message    string `json:"message" example:"provider rate limited"` // Details are only logged on the server
+}
+
 // WeatherResponse is the structure for the API response to the frontend
 type WeatherResponse struct {
-	City        string          `json:"city"`
-	AverageTemp float64         `json:"averageTemp"`
-	Sources     []WeatherSource `json:"sources"`
+	City        string            `json:"city"`
+	Units       string            `json:"units" example:"metric"`       // metric (°C) or imperial (°F)
+	AverageTemp float64           `json:"averageTemp"`                  // Combined temperature of the sources that are not outliers
+	Aggregation string            `json:"aggregation" example:"median"` // mean, median, trimmed or weighted
+	Spread      float64           `json:"spread" example:"0.8"`         // Standard deviation of the combined sources
+	Confidence  float64           `json:"confidence" example:"0.9"`     // From 0 to 1, lower when providers fail, are outliers or disagree
+	Sources     []WeatherSource   `json:"sources"`
+	Failures    []ProviderFailure `json:"failures"` // Providers that did not answer
 }
 
 // --- Structs for OpenWeatherMap API Response ---
@@ -37,10 +67,12 @@ type WeatherAPIResponse struct {
 	} `json:"location"`
 	Current struct {
 		TempC     float64 `json:"temp_c"`
+		TempF     float64 `json:"temp_f"`
 		Condition struct {
 			Text string `json:"text"` // e.g., "Partly cloudy"
 		} `json:"condition"`
 		WindKph float64 `json:"wind_kph"`
+		WindMph float64 `json:"wind_mph"`
 	} `json:"current"`
 }
 
@@ -66,3 +98,105 @@ type OpenMeteoWeatherResponse struct {
 	Longitude      float64                 `json:"longitude"`
 	CurrentWeather OpenMeteoCurrentWeather `json:"current"`
 }
+
+// DailyForecast is the forecast of one day from a single provider
+type DailyForecast struct {
+	Date                     string   `json:"date" example:"2026-10-18"` // Local date at the place
+	TempMin                  float64  `json:"tempMin" example:"6.5"`     // In the units of the response
+	TempMax                  float64  `json:"tempMax" example:"13.2"`
+	PrecipitationProbability *float64 `json:"precipitationProbability" example:"40"` // Percent, null when the provider does not report it
+}
+
+// HourlyForecast is the forecast of one point in time from a single provider
+type HourlyForecast struct {
+	Time                     time.Time `json:"time"`
+	Temp                     float64   `json:"temp" example:"9.8"` // In the units of the response
+	PrecipitationProbability *float64  `json:"precipitationProbability" example:"20"`
+}
+
+// SourceForecast is the normalised forecast of a single provider
+type SourceForecast struct {
+	Provider  string           `json:"provider" example:"openmeteo"`
+	Name      string           `json:"name" example:"Open-Meteo"`
+	Daily     []DailyForecast  `json:"daily"`
+	Hourly    []HourlyForecast `json:"hourly,omitempty"` // Only when requested; some providers have 3-hour steps
+	FetchedAt time.Time        `json:"fetchedAt"`
+}
+
+// AggregatedDailyForecast combines the daily forecasts of all sources for one date
+type AggregatedDailyForecast struct {
+	DailyForecast
+	Sources int `json:"sources" example:"3"` // Number of sources with a forecast for the date
+}
+
+// AggregatedHourlyForecast combines the forecasts of all sources for one point in time
+type AggregatedHourlyForecast struct {
+	HourlyForecast
+	Sources int `json:"sources" example:"2"`
+}
+
+// ForecastResponse is the API response of the forecast endpoint
+type ForecastResponse struct {
+	City        string                     `json:"city" example:"London"`
+	Days        int                        `json:"days" example:"3"` // Days in daily, fewer than requested when the sources do not forecast that far
+	Units       string                     `json:"units" example:"metric"`
+	Aggregation string                     `json:"aggregation" example:"median"` // Combination of the temperatures of the sources, like for current weather
+	Daily       []AggregatedDailyForecast  `json:"daily"`
+	Hourly      []AggregatedHourlyForecast `json:"hourly,omitempty"`
+	Sources     []SourceForecast           `json:"sources"`
+	Failures    []ProviderFailure          `json:"failures"`
+}
+
+// --- Structs for OpenWeatherMap 5 day / 3 hour forecast API Response ---
+type OpenWeatherMapForecastResponse struct {
+	List []struct {
+		Dt   int64 `json:"dt"` // Unix time
+		Main struct {
+			Temp    float64 `json:"temp"`
+			TempMin float64 `json:"temp_min"`
+			TempMax float64 `json:"temp_max"`
+		} `json:"main"`
+		Pop float64 `json:"pop"` // Probability of precipitation, 0..1
+	} `json:"list"`
+	City struct {
+		Timezone int64 `json:"timezone"` // Offset from UTC in seconds
+	} `json:"city"`
+}
+
+// --- Structs for WeatherAPI.com Forecast API Response ---
+type WeatherAPIForecastResponse struct {
+	Forecast struct {
+		ForecastDay []struct {
+			Date string `json:"date"` // Local date, e.g. "2026-10-18"
+			Day  struct {
+				MaxTempC          float64 `json:"maxtemp_c"`
+				MinTempC          float64 `json:"mintemp_c"`
+				MaxTempF          float64 `json:"maxtemp_f"`
+				MinTempF          float64 `json:"mintemp_f"`
+				DailyChanceOfRain float64 `json:"daily_chance_of_rain"` // Percent
+			} `json:"day"`
+			Hour []struct {
+				TimeEpoch    int64   `json:"time_epoch"`
+				TempC        float64 `json:"temp_c"`
+				TempF        float64 `json:"temp_f"`
+				ChanceOfRain float64 `json:"chance_of_rain"`
+			} `json:"hour"`
+		} `json:"forecastday"`
+	} `json:"forecast"`
+}
+
+// --- Structs for Open-Meteo Forecast API Response (timeformat=unixtime) ---
+type OpenMeteoForecastResponse struct {
+	UTCOffsetSeconds int64 `json:"utc_offset_seconds"`
+	Daily            struct {
+		Time                        []int64    `json:"time"` // Local midnight as Unix time
+		TemperatureMax              []float64  `json:"temperature_2m_max"`
+		TemperatureMin              []float64  `json:"temperature_2m_min"`
+		PrecipitationProbabilityMax []*float64 `json:"precipitation_probability_max"`
+	} `json:"daily"`
+	Hourly struct {
+		Time                     []int64    `json:"time"`
+		Temperature              []float64  `json:"temperature_2m"`
+		PrecipitationProbability []*float64 `json:"precipitation_probability"`
+	} `json:"hourly"`
+}
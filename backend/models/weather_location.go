@@ -0,0 +1,17 @@
+package models
+
+import "time"
+
+// WeatherLocation is a place saved by the user for the personal weather dashboard. At most one location
+// of a user is the default, it is listed first.
+type WeatherLocation struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	UserID    uint      `gorm:"not null;index;uniqueIndex:idx_weather_locations_default,where:is_default" json:"-"`
+	User      User      `gorm:"constraint:OnUpdate:CASCADE,OnDelete:CASCADE;" json:"-"`
+	Name      string    `gorm:"not null;size:100" json:"name" example:"Home"`
+	Latitude  float64   `gorm:"not null" json:"latitude" example:"55.7558"`
+	Longitude float64   `gorm:"not null" json:"longitude" example:"37.6173"`
+	IsDefault bool      `gorm:"not null;default:false" json:"isDefault"`
+	CreatedAt time.Time `json:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
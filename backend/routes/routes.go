@@ -1,8 +1,12 @@
 package routes
 
 import (
+	"log"
+	"organizer-backend/config"
 	"organizer-backend/handlers"
 	"organizer-backend/middleware"
+	"organizer-backend/models"
+	"strings"
 
 	"github.com/gin-contrib/cors"
 	"github.com/gin-gonic/gin"
@@ -13,52 +17,151 @@ import (
 func SetupRouter() *gin.Engine {
 	r := gin.Default()
 
+	// ClientIP keys rate limits and the audit trail, so X-Forwarded-For is only believed from the proxies
+	// in TRUSTED_PROXIES (comma separated IPs or CIDRs), from no one by default
+	var trustedProxies []string
+	for _, proxy := range strings.Split(config.GetEnv("TRUSTED_PROXIES", ""), ",") {
+		if proxy = strings.TrimSpace(proxy); proxy != "" {
+			trustedProxies = append(trustedProxies, proxy)
+		}
+	}
+	if err := r.SetTrustedProxies(trustedProxies); err != nil {
+		log.Fatal("Invalid TRUSTED_PROXIES: ", err)
+	}
+
 	// CORS middleware configuration using gin-contrib/cors
 	config := cors.DefaultConfig()
 	config.AllowOrigins = []string{"http://localhost:3000"}
 	config.AllowMethods = []string{"GET", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"}
-	config.AllowHeaders = []string{"Origin", "Content-Type", "Accept", "Authorization"}
-	config.ExposeHeaders = []string{"Content-Length"}
+	config.AllowHeaders = []string{"Origin", "Content-Type", "Accept", "Authorization", "If-Match"}
+	config.ExposeHeaders = []string{"Content-Length", "ETag", "X-Cache"}
 	config.AllowCredentials = true
 	config.MaxAge = 12 * time.Hour // Опционально: как долго результаты preflight-запроса могут кэшироваться
 
 	r.Use(cors.New(config))
 
+	r.GET("/.well-known/jwks.json", handlers.GetJWKS)
+
 	api := r.Group("/api")
 	{
 		auth := api.Group("/auth")
 		{
-			auth.POST("/register", handlers.RegisterUser)
-			auth.POST("/login", handlers.LoginUser)
+			auth.POST("/register", middleware.RateLimit("register"), handlers.RegisterUser)
+			auth.POST("/login", middleware.RateLimit("login"), handlers.LoginUser)
+			auth.POST("/login/2fa", middleware.RateLimit("login"), handlers.LoginTwoFactor)
+			auth.POST("/refresh", handlers.RefreshAccessToken)
+			auth.POST("/verify-email", middleware.RateLimit("account-token"), handlers.VerifyEmail)
+			auth.POST("/verify-email/resend", middleware.AuthMiddleware(), middleware.SessionOnly(), handlers.ResendVerificationEmail)
+			auth.POST("/forgot-password", middleware.RateLimit("forgot-password"), handlers.ForgotPassword)
+			auth.POST("/reset-password", middleware.RateLimit("account-token"), handlers.ResetPassword)
+			auth.GET("/oidc/providers", handlers.GetOIDCProviders)
+			auth.GET("/oidc/:provider/login", middleware.RateLimit("oidc"), handlers.StartOIDCLogin)
+			auth.GET("/oidc/:provider/callback", handlers.OIDCCallback)
+			auth.POST("/oidc/exchange", middleware.RateLimit("account-token"), handlers.ExchangeOIDCCode)
+			auth.POST("/logout", middleware.AuthMiddleware(), middleware.SessionOnly(), handlers.Logout)
+			auth.POST("/logout-all", middleware.AuthMiddleware(), middleware.SessionOnly(), handlers.LogoutAll)
 			// auth.POST("/validate-token", handlers.ValidateUserToken)
 		}
 
 		userRoutes := api.Group("/users")
-		userRoutes.Use(middleware.AuthMiddleware())
+		userRoutes.Use(middleware.AuthMiddleware(), middleware.RequireScopes(models.ScopeProfileRead, ""))
 		{
 			userRoutes.GET("/me", handlers.GetUserProfile)
 			userRoutes.PUT("/me", handlers.UpdateUserProfile)
-			userRoutes.POST("/me/password", handlers.ChangeUserPassword)
+			userRoutes.DELETE("/me", middleware.SessionOnly(), middleware.RateLimit("password"), handlers.DeleteAccount)
+			userRoutes.POST("/me/deletion/cancel", middleware.SessionOnly(), handlers.CancelAccountDeletion)
+			userRoutes.GET("/me/export", middleware.SessionOnly(), handlers.ExportAccount)
+			userRoutes.POST("/me/password", middleware.RateLimit("password"), handlers.ChangeUserPassword)
+			userRoutes.PUT("/me/revision-policy", handlers.UpdateRevisionPolicy)
+			userRoutes.PUT("/me/weather-preferences", handlers.UpdateWeatherPreferences)
+			userRoutes.POST("/me/2fa/setup", handlers.SetupTwoFactor)
+			userRoutes.POST("/me/2fa/confirm", handlers.ConfirmTwoFactor)
+			userRoutes.POST("/me/2fa/disable", middleware.RateLimit("password"), handlers.DisableTwoFactor)
+			userRoutes.POST("/me/2fa/recovery-codes", handlers.RegenerateRecoveryCodes)
+			userRoutes.GET("/me/sessions", middleware.SessionOnly(), handlers.GetSessions)
+			userRoutes.DELETE("/me/sessions/:id", handlers.RevokeSession)
+			userRoutes.GET("/me/tokens", middleware.SessionOnly(), handlers.GetAccessTokens)
+			userRoutes.POST("/me/tokens", handlers.CreateAccessToken)
+			userRoutes.DELETE("/me/tokens/:id", handlers.DeleteAccessToken)
 		}
 
 		notesRoutes := api.Group("/notes")
-		notesRoutes.Use(middleware.AuthMiddleware())
+		notesRoutes.Use(middleware.AuthMiddleware(), middleware.RequireScopes(models.ScopeNotesRead, models.ScopeNotesWrite))
 		{
 			notesRoutes.GET("", handlers.GetNotes)
 			notesRoutes.POST("", handlers.CreateNote)
+			notesRoutes.POST("/move", handlers.MoveNotes)
+			notesRoutes.GET("/export", handlers.ExportNotes)
+			notesRoutes.POST("/import", handlers.ImportNotes)
+			notesRoutes.GET("/graph", handlers.GetNoteGraph)
+			notesRoutes.GET("/unresolved-links", handlers.GetUnresolvedNoteLinks)
 			notesRoutes.GET("/:id", handlers.GetNote)
 			notesRoutes.PUT("/:id", handlers.UpdateNote)
 			notesRoutes.DELETE("/:id", handlers.DeleteNote)
+			notesRoutes.GET("/:id/backlinks", handlers.GetNoteBacklinks)
+			notesRoutes.GET("/:id/revisions", handlers.GetNoteRevisions)
+			notesRoutes.GET("/:id/revisions/:rev/diff", handlers.GetNoteRevisionDiff)
+			notesRoutes.POST("/:id/revisions/:rev/restore", handlers.RestoreNoteRevision)
+		}
+
+		notebookRoutes := api.Group("/notebooks")
+		notebookRoutes.Use(middleware.AuthMiddleware(), middleware.RequireScopes(models.ScopeNotesRead, models.ScopeNotesWrite))
+		{
+			notebookRoutes.GET("", handlers.GetNotebooks)
+			notebookRoutes.POST("", handlers.CreateNotebook)
+			notebookRoutes.PUT("/:id", handlers.UpdateNotebook)
+			notebookRoutes.DELETE("/:id", handlers.DeleteNotebook)
+		}
+
+		tagRoutes := api.Group("/tags")
+		tagRoutes.Use(middleware.AuthMiddleware(), middleware.RequireScopes(models.ScopeNotesRead, models.ScopeNotesWrite))
+		{
+			tagRoutes.GET("", handlers.GetTags)
+			tagRoutes.POST("", handlers.CreateTag)
+			tagRoutes.PUT("/:id", handlers.RenameTag)
+			tagRoutes.POST("/:id/merge", handlers.MergeTag)
+			tagRoutes.DELETE("/:id", handlers.DeleteTag)
 		}
 
 		kbRoutes := api.Group("/knowledge-links")
-		kbRoutes.Use(middleware.AuthMiddleware())
+		kbRoutes.Use(middleware.AuthMiddleware(), middleware.RequireScopes(models.ScopeLinksRead, models.ScopeLinksWrite))
 		{
 			kbRoutes.GET("", handlers.GetKnowledgeLinks)
 			kbRoutes.POST("", handlers.CreateKnowledgeLink)
 			kbRoutes.DELETE("/:id", handlers.DeleteKnowledgeLink)
 		}
+		trashRoutes := api.Group("/trash")
+		trashRoutes.Use(middleware.AuthMiddleware()) // Scopes depend on the kind of item, the handlers check them
+		{
+			trashRoutes.GET("", handlers.GetTrash)
+			trashRoutes.DELETE("", handlers.EmptyTrash)
+			trashRoutes.POST("/:kind/:id/restore", handlers.RestoreTrashItem)
+			trashRoutes.DELETE("/:kind/:id", handlers.PurgeTrashItem)
+		}
+		adminRoutes := api.Group("/admin")
+		adminRoutes.Use(middleware.AuthMiddleware(), middleware.SessionOnly(), middleware.RequireRole(models.RoleAdmin))
+		{
+			adminRoutes.GET("/users", handlers.AdminGetUsers)
+			adminRoutes.GET("/users/:id", handlers.AdminGetUser)
+			adminRoutes.POST("/users/:id/disable", handlers.AdminDisableUser)
+			adminRoutes.POST("/users/:id/enable", handlers.AdminEnableUser)
+			adminRoutes.PUT("/users/:id/role", handlers.AdminUpdateUserRole)
+			adminRoutes.POST("/users/:id/force-password-reset", handlers.AdminForcePasswordReset)
+			adminRoutes.GET("/users/:id/usage", handlers.AdminGetUserUsage)
+			adminRoutes.GET("/usage", handlers.AdminGetStorageUsage)
+			adminRoutes.GET("/audit-events", handlers.AdminGetAuditEvents)
+		}
 		api.GET("/weather", handlers.GetWeatherByCity)
+		api.GET("/weather/forecast", handlers.GetWeatherForecast)
+		weatherRoutes := api.Group("/weather")
+		weatherRoutes.Use(middleware.AuthMiddleware(), middleware.RequireScopes(models.ScopeProfileRead, ""))
+		{
+			weatherRoutes.GET("/me", handlers.GetPersonalWeather)
+			weatherRoutes.GET("/locations", handlers.GetWeatherLocations)
+			weatherRoutes.POST("/locations", handlers.CreateWeatherLocation)
+			weatherRoutes.PUT("/locations/:id", handlers.UpdateWeatherLocation)
+			weatherRoutes.DELETE("/locations/:id", handlers.DeleteWeatherLocation)
+		}
 	}
 	return r
 }
@@ -0,0 +1,141 @@
+package utils
+
+import (
+	"bytes"
+	"fmt"
+	"organizer-backend/config"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// DefaultLocale is used for emails when the user has no supported locale
+const DefaultLocale = "ru"
+
+// SupportedLocales lists the locales emails are translated to
+var SupportedLocales = []string{"ru", "en"}
+
+// Email template names
+const (
+	EmailVerification  = "verify-email"
+	EmailPasswordReset = "reset-password"
+)
+
+type emailTemplate struct {
+	Subject string
+	Body    string
+}
+
+var emailTemplates = map[string]map[string]emailTemplate{
+	EmailVerification: {
+		"ru": {
+			Subject: "Подтвердите адрес электронной почты",
+			Body: `Здравствуйте{{if .Name}}, {{.Name}}{{end}}!
+
+Чтобы подтвердить адрес электронной почты для Organizer, перейдите по ссылке:
+{{.Link}}
+
+Ссылка действительна {{hours .ValidFor}}. Если вы не регистрировались в Organizer, просто проигнорируйте это письмо.
+`,
+		},
+		"en": {
+			Subject: "Confirm your email address",
+			Body: `Hello{{if .Name}}, {{.Name}}{{end}}!
+
+To confirm your email address for Organizer, open this link:
+{{.Link}}
+
+The link is valid for {{hours .ValidFor}}. If you did not sign up for Organizer, just ignore this email.
+`,
+		},
+	},
+	EmailPasswordReset: {
+		"ru": {
+			Subject: "Сброс пароля",
+			Body: `Здравствуйте{{if .Name}}, {{.Name}}{{end}}!
+
+Мы получили запрос на сброс пароля для Organizer. Чтобы задать новый пароль, перейдите по ссылке:
+{{.Link}}
+
+Ссылка действительна {{hours .ValidFor}} и может быть использована один раз. Если вы не запрашивали сброс пароля, проигнорируйте это письмо: текущий пароль останется прежним.
+`,
+		},
+		"en": {
+			Subject: "Reset your password",
+			Body: `Hello{{if .Name}}, {{.Name}}{{end}}!
+
+We received a request to reset your Organizer password. To set a new password, open this link:
+{{.Link}}
+
+The link is valid for {{hours .ValidFor}} and can be used once. If you did not request a password reset, ignore this email: your current password stays unchanged.
+`,
+		},
+	},
+}
+
+// EmailData is passed to the email templates
+type EmailData struct {
+	Name     string
+	Link     string
+	ValidFor time.Duration // Token lifetime
+}
+
+// NormalizeLocale maps a locale or an Accept-Language header ("en-US,en;q=0.9") to a supported locale,
+// returning an empty string when none matches
+func NormalizeLocale(value string) string {
+	for _, part := range strings.Split(value, ",") {
+		tag := strings.TrimSpace(strings.SplitN(part, ";", 2)[0])
+		lang := strings.ToLower(strings.SplitN(strings.ReplaceAll(tag, "_", "-"), "-", 2)[0])
+		for _, locale := range SupportedLocales {
+			if lang == locale {
+				return locale
+			}
+		}
+	}
+	return ""
+}
+
+// RenderEmail builds a localized email from a template, falling back to DefaultLocale
+func RenderEmail(name, locale, to string, data EmailData) (Message, error) {
+	translations := emailTemplates[name]
+	tmpl, ok := translations[locale]
+	if !ok {
+		locale = DefaultLocale
+		tmpl = translations[locale]
+	}
+
+	funcs := template.FuncMap{"hours": func(d time.Duration) string { return formatHours(locale, d) }}
+	body, err := template.New(name).Funcs(funcs).Parse(tmpl.Body)
+	if err != nil {
+		return Message{}, err
+	}
+	var buf bytes.Buffer
+	if err := body.Execute(&buf, data); err != nil {
+		return Message{}, err
+	}
+	return Message{To: to, Subject: tmpl.Subject, Body: buf.String()}, nil
+}
+
+// formatHours writes a duration in whole hours with the plural form of the locale
+func formatHours(locale string, d time.Duration) string {
+	n := int(d.Hours())
+	if locale != "ru" {
+		if n == 1 {
+			return "1 hour"
+		}
+		return fmt.Sprintf("%d hours", n)
+	}
+	switch {
+	case n%10 == 1 && n%100 != 11:
+		return fmt.Sprintf("%d час", n)
+	case n%10 >= 2 && n%10 <= 4 && (n%100 < 12 || n%100 > 14):
+		return fmt.Sprintf("%d часа", n)
+	default:
+		return fmt.Sprintf("%d часов", n)
+	}
+}
+
+// AppURL returns the public URL of the frontend, used for links in emails
+func AppURL(path string) string {
+	return strings.TrimRight(config.GetEnv("APP_URL", "http://localhost:3000"), "/") + path
+}
@@ -0,0 +1,34 @@
+package utils
+
+import (
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// SplitFrontMatter separates a leading YAML front matter block ("---" ... "---") from the document body.
+// Documents without front matter are returned unchanged as body.
+func SplitFrontMatter(doc string) (frontMatter string, body string) {
+	text := strings.ReplaceAll(strings.TrimPrefix(doc, "\uFEFF"), "\r\n", "\n")
+	if !strings.HasPrefix(text, "---\n") {
+		return "", doc
+	}
+
+	offset := len("---\n")
+	for _, line := range strings.SplitAfter(text[offset:], "\n") {
+		if marker := strings.TrimRight(line, " \n"); marker == "---" || marker == "..." {
+			return text[len("---\n"):offset], strings.TrimPrefix(text[offset+len(line):], "\n")
+		}
+		offset += len(line)
+	}
+	return "", doc // No closing marker, not front matter
+}
+
+// RenderFrontMatter prepends meta, encoded as YAML front matter, to the document body
+func RenderFrontMatter(meta interface{}, body string) (string, error) {
+	encoded, err := yaml.Marshal(meta)
+	if err != nil {
+		return "", err
+	}
+	return "---\n" + string(encoded) + "---\n\n" + body, nil
+}
@@ -1,13 +1,56 @@
 package utils
 
-import "golang.org/x/crypto/bcrypt"
+import (
+	"log"
+	"organizer-backend/config"
+	"sync"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// PasswordCost is the bcrypt cost of new password hashes, configured by InitPasswordHashing. Every login
+// attempt costs this much CPU, so it is kept moderate.
+var PasswordCost = 12
+
+var (
+	dummyHashOnce sync.Once
+	dummyHash     []byte
+)
+
+// InitPasswordHashing reads BCRYPT_COST (10 to 14)
+func InitPasswordHashing() {
+	config.LoadEnv()
+	cost := intFromEnv("BCRYPT_COST", PasswordCost)
+	if cost < 10 || cost > 14 {
+		log.Printf("Warning: BCRYPT_COST must be between 10 and 14, using default of %d", PasswordCost)
+		return
+	}
+	PasswordCost = cost
+}
 
 func HashPassword(password string) (string, error) {
-	bytes, err := bcrypt.GenerateFromPassword([]byte(password), 14)
+	bytes, err := bcrypt.GenerateFromPassword([]byte(password), PasswordCost)
 	return string(bytes), err
 }
 
+// CheckPasswordHash reports whether the password matches the hash. An empty hash (unknown user, account
+// without a password) never matches, but is checked against a dummy hash, so the response time does not
+// reveal which accounts exist.
 func CheckPasswordHash(password, hash string) bool {
+	if hash == "" {
+		dummyHashOnce.Do(func() {
+			dummyHash, _ = bcrypt.GenerateFromPassword([]byte("dummy password"), PasswordCost)
+		})
+		bcrypt.CompareHashAndPassword(dummyHash, []byte(password))
+		return false
+	}
 	err := bcrypt.CompareHashAndPassword([]byte(hash), []byte(password))
 	return err == nil
 }
+
+// PasswordHashOutdated reports whether the hash was made with another cost than PasswordCost, so it should
+// be replaced after the next successful login
+func PasswordHashOutdated(hash string) bool {
+	cost, err := bcrypt.Cost([]byte(hash))
+	return err == nil && cost != PasswordCost
+}
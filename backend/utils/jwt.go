@@ -1,44 +1,126 @@
 package utils
 
 import (
+	"errors"
+	"log"
 	"organizer-backend/config"
 	"time"
 
+	"github.com/gin-gonic/gin"
 	"github.com/golang-jwt/jwt/v5"
 )
 
-var jwtKey []byte
+var jwtKeys *keySet
 
+var (
+	AccessTokenTTL  = 15 * time.Minute
+	RefreshTokenTTL = 30 * 24 * time.Hour
+	ChallengeTTL    = 5 * time.Minute // Time to enter the second factor after the password
+)
+
+// ChallengePurpose marks tokens that only allow finishing a two-factor login
+const ChallengePurpose = "2fa"
+
+var errWrongTokenPurpose = errors.New("token is not valid for this purpose")
+
+// defaultJWTSecret is used when JWT_SECRET is not set, only acceptable for development
+const defaultJWTSecret = "default_secret_please_change"
+
+// InitJWT loads the signing keys: RS256/EdDSA keys from JWT_KEYS_DIR when set, the HS256 secret
+// JWT_SECRET otherwise. In release mode the server refuses to start with the default secret.
 func InitJWT() {
 	config.LoadEnv()
-	jwtKey = []byte(config.GetEnv("JWT_SECRET", "default_secret_please_change"))
+	if dir := config.GetEnv("JWT_KEYS_DIR", ""); dir != "" {
+		keys, err := loadKeySet(dir, config.GetEnv("JWT_SIGNING_KID", ""))
+		if err != nil {
+			log.Fatal("Failed to load JWT keys: ", err)
+		}
+		jwtKeys = keys
+		log.Printf("JWT: signing with %s key %q, %d key(s) verify", keys.signer.Method.Alg(), keys.signer.ID, len(keys.keys))
+	} else {
+		secret := config.GetEnv("JWT_SECRET", "")
+		if secret == "" || secret == defaultJWTSecret {
+			if gin.Mode() == gin.ReleaseMode {
+				log.Fatal("JWT_SECRET is not set: configure JWT_KEYS_DIR or a unique JWT_SECRET before running in release mode")
+			}
+			log.Println("Warning: using the default JWT secret, set JWT_KEYS_DIR or JWT_SECRET outside development")
+			secret = defaultJWTSecret
+		}
+		jwtKeys = secretKeySet([]byte(secret))
+	}
+
+	AccessTokenTTL = durationFromEnv("ACCESS_TOKEN_TTL", AccessTokenTTL)
+	RefreshTokenTTL = durationFromEnv("REFRESH_TOKEN_TTL", RefreshTokenTTL)
+}
+
+func durationFromEnv(key string, fallback time.Duration) time.Duration {
+	value := config.GetEnv(key, "")
+	if value == "" {
+		return fallback
+	}
+	duration, err := time.ParseDuration(value)
+	if err != nil || duration <= 0 {
+		log.Printf("Warning: invalid %s, using default of %s", key, fallback)
+		return fallback
+	}
+	return duration
 }
 
 type Claims struct {
-	UserID uint   `json:"user_id"`
-	Email  string `json:"email"`
+	UserID    uint   `json:"user_id"`
+	Email     string `json:"email"`
+	Role      string `json:"role,omitempty"`    // models.RoleUser or models.RoleAdmin
+	SessionID uint   `json:"sid"`               // Session the token was issued for, checked for revocation
+	Purpose   string `json:"purpose,omitempty"` // Empty for access tokens
 	jwt.RegisteredClaims
 }
 
-func GenerateJWT(userID uint, email string) (string, error) {
-	expirationTime := time.Now().Add(12 * time.Hour) // Token valid for 12 hours
+// GenerateJWT issues a short-lived access token (ACCESS_TOKEN_TTL) for a session
+func GenerateJWT(userID uint, email, role string, sessionID uint) (string, error) {
+	now := time.Now()
 	claims := &Claims{
-		UserID: userID,
-		Email:  email,
+		UserID:    userID,
+		Email:     email,
+		Role:      role,
+		SessionID: sessionID,
 		RegisteredClaims: jwt.RegisteredClaims{
-			ExpiresAt: jwt.NewNumericDate(expirationTime),
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(now.Add(AccessTokenTTL)),
 		},
 	}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	return token.SignedString(jwtKey)
+	return jwtKeys.sign(claims)
 }
 
+// GenerateChallengeToken issues a token proving that the password of a 2FA user was correct
+func GenerateChallengeToken(userID uint, email string) (string, error) {
+	now := time.Now()
+	claims := &Claims{
+		UserID:  userID,
+		Email:   email,
+		Purpose: ChallengePurpose,
+		RegisteredClaims: jwt.RegisteredClaims{
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(now.Add(ChallengeTTL)),
+		},
+	}
+
+	return jwtKeys.sign(claims)
+}
+
+// ValidateJWT validates an access token
 func ValidateJWT(tokenString string) (*Claims, error) {
+	return validateToken(tokenString, "")
+}
+
+// ValidateChallengeToken validates a token issued by GenerateChallengeToken
+func ValidateChallengeToken(tokenString string) (*Claims, error) {
+	return validateToken(tokenString, ChallengePurpose)
+}
+
+func validateToken(tokenString string, purpose string) (*Claims, error) {
 	claims := &Claims{}
-	token, err := jwt.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (interface{}, error) {
-		return jwtKey, nil
-	})
+	token, err := jwt.ParseWithClaims(tokenString, claims, jwtKeys.keyFunc, jwt.WithValidMethods(jwtKeys.methods()))
 
 	if err != nil {
 		return nil, err
@@ -46,5 +128,8 @@ func ValidateJWT(tokenString string) (*Claims, error) {
 	if !token.Valid {
 		return nil, jwt.ErrSignatureInvalid
 	}
+	if claims.Purpose != purpose {
+		return nil, errWrongTokenPurpose
+	}
 	return claims, nil
 }
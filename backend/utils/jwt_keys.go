@@ -0,0 +1,214 @@
+package utils
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// signingKey is a key tokens are signed or verified with. For HMAC both keys are the secret.
+type signingKey struct {
+	ID     string // kid header, empty for the HMAC secret
+	Method jwt.SigningMethod
+	sign   interface{} // nil for keys that only verify
+	verify interface{}
+}
+
+// keySet holds all keys that verify tokens. Exactly one of them signs new tokens.
+type keySet struct {
+	signer *signingKey
+	keys   map[string]*signingKey
+}
+
+// secretKeySet uses a shared HS256 secret, the behaviour without JWT_KEYS_DIR
+func secretKeySet(secret []byte) *keySet {
+	key := &signingKey{Method: jwt.SigningMethodHS256, sign: secret, verify: secret}
+	return &keySet{signer: key, keys: map[string]*signingKey{"": key}}
+}
+
+// loadKeySet reads the RS256 and EdDSA keys from the .pem files in dir; the file name without extension is
+// the kid. Private keys sign and verify, public keys only verify (e.g. keys of a retired signer).
+// The key signingKID signs, or the private key with the greatest kid when it is empty, which is the newest
+// key created by the rotation command.
+func loadKeySet(dir, signingKID string) (*keySet, error) {
+	files, err := filepath.Glob(filepath.Join(dir, "*.pem"))
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(files)
+
+	set := &keySet{keys: map[string]*signingKey{}}
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return nil, err
+		}
+		key, err := parseKeyPEM(data)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", file, err)
+		}
+		key.ID = strings.TrimSuffix(filepath.Base(file), ".pem")
+		set.keys[key.ID] = key
+
+		if key.sign != nil && (signingKID == "" || signingKID == key.ID) {
+			set.signer = key
+		}
+	}
+
+	if set.signer == nil {
+		if signingKID != "" {
+			return nil, fmt.Errorf("no private key with kid %q in %s", signingKID, dir)
+		}
+		return nil, fmt.Errorf("no private key in %s", dir)
+	}
+	return set, nil
+}
+
+func parseKeyPEM(data []byte) (*signingKey, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("no PEM data found")
+	}
+
+	var parsed interface{}
+	var err error
+	switch block.Type {
+	case "PRIVATE KEY":
+		parsed, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	case "RSA PRIVATE KEY":
+		parsed, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	case "PUBLIC KEY":
+		parsed, err = x509.ParsePKIXPublicKey(block.Bytes)
+	default:
+		return nil, fmt.Errorf("unsupported PEM block %q", block.Type)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	switch key := parsed.(type) {
+	case *rsa.PrivateKey:
+		return &signingKey{Method: jwt.SigningMethodRS256, sign: key, verify: &key.PublicKey}, nil
+	case *rsa.PublicKey:
+		return &signingKey{Method: jwt.SigningMethodRS256, verify: key}, nil
+	case ed25519.PrivateKey:
+		return &signingKey{Method: jwt.SigningMethodEdDSA, sign: key, verify: key.Public()}, nil
+	case ed25519.PublicKey:
+		return &signingKey{Method: jwt.SigningMethodEdDSA, verify: key}, nil
+	default:
+		return nil, fmt.Errorf("unsupported key type %T, use RSA or Ed25519", parsed)
+	}
+}
+
+// sign signs the claims with the signing key and sets the kid header
+func (s *keySet) sign(claims jwt.Claims) (string, error) {
+	token := jwt.NewWithClaims(s.signer.Method, claims)
+	if s.signer.ID != "" {
+		token.Header["kid"] = s.signer.ID
+	}
+	return token.SignedString(s.signer.sign)
+}
+
+// keyFunc finds the verification key of a token by its kid and checks that the algorithm matches the key
+func (s *keySet) keyFunc(token *jwt.Token) (interface{}, error) {
+	kid, _ := token.Header["kid"].(string)
+	key, ok := s.keys[kid]
+	if !ok {
+		return nil, fmt.Errorf("unknown signing key %q", kid)
+	}
+	if token.Method.Alg() != key.Method.Alg() {
+		return nil, fmt.Errorf("signing method %s does not match key %q", token.Method.Alg(), kid)
+	}
+	return key.verify, nil
+}
+
+func (s *keySet) methods() []string {
+	var methods []string
+	seen := map[string]bool{}
+	for _, key := range s.keys {
+		if alg := key.Method.Alg(); !seen[alg] {
+			seen[alg] = true
+			methods = append(methods, alg)
+		}
+	}
+	return methods
+}
+
+// JWK is a public key in JSON Web Key format (RFC 7517)
+type JWK struct {
+	Kty string `json:"kty" example:"OKP"`
+	Use string `json:"use" example:"sig"`
+	Alg string `json:"alg" example:"EdDSA"`
+	Kid string `json:"kid" example:"20261018-092449-eddsa"`
+	Crv string `json:"crv,omitempty" example:"Ed25519"` // EdDSA keys
+	X   string `json:"x,omitempty"`                     // EdDSA public key
+	N   string `json:"n,omitempty"`                     // RSA modulus
+	E   string `json:"e,omitempty"`                     // RSA exponent
+}
+
+// JWKSet is the document published at /.well-known/jwks.json
+type JWKSet struct {
+	Keys []JWK `json:"keys"`
+}
+
+// PublicJWKS returns the public keys that verify our tokens. It is empty with a shared HS256 secret,
+// which must never be published.
+func PublicJWKS() JWKSet {
+	set := JWKSet{Keys: []JWK{}}
+	ids := make([]string, 0, len(jwtKeys.keys))
+	for id := range jwtKeys.keys {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	encode := base64.RawURLEncoding.EncodeToString
+	for _, id := range ids {
+		key := jwtKeys.keys[id]
+		switch public := key.verify.(type) {
+		case *rsa.PublicKey:
+			set.Keys = append(set.Keys, JWK{Kty: "RSA", Use: "sig", Alg: key.Method.Alg(), Kid: id,
+				N: encode(public.N.Bytes()), E: encode(big.NewInt(int64(public.E)).Bytes())})
+		case ed25519.PublicKey:
+			set.Keys = append(set.Keys, JWK{Kty: "OKP", Use: "sig", Alg: key.Method.Alg(), Kid: id, Crv: "Ed25519", X: encode(public)})
+		}
+	}
+	return set
+}
+
+// GenerateSigningKeyPEM creates a private key for the algorithm (RS256 or EdDSA) in PKCS #8 PEM format,
+// together with a kid that sorts after all earlier generated keys
+func GenerateSigningKeyPEM(alg string) (string, []byte, error) {
+	var key interface{}
+	var err error
+	switch alg {
+	case jwt.SigningMethodRS256.Alg():
+		key, err = rsa.GenerateKey(rand.Reader, 3072)
+	case jwt.SigningMethodEdDSA.Alg():
+		_, key, err = ed25519.GenerateKey(rand.Reader)
+	default:
+		return "", nil, fmt.Errorf("unsupported algorithm %q, use RS256 or EdDSA", alg)
+	}
+	if err != nil {
+		return "", nil, err
+	}
+
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		return "", nil, err
+	}
+	kid := time.Now().UTC().Format("20060102-150405") + "-" + strings.ToLower(alg)
+	return kid, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}), nil
+}
@@ -0,0 +1,151 @@
+package utils
+
+import (
+	"fmt"
+	"log"
+	"mime"
+	"net/smtp"
+	"organizer-backend/config"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Message is a plain text email
+type Message struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+// Mailer delivers emails. SMTPMailer is used in production, FileMailer for development and tests.
+type Mailer interface {
+	Send(msg Message) error
+}
+
+var mailer Mailer = FileMailer{}
+
+// InitMailer selects the mailer from MAIL_DRIVER (smtp, file or log). Without MAIL_DRIVER the log driver
+// is used in development; release mode requires an explicit driver other than log, since emails contain
+// links with account tokens.
+func InitMailer() {
+	config.LoadEnv()
+	from := config.GetEnv("MAIL_FROM", "Organizer <no-reply@localhost>")
+
+	driver := config.GetEnv("MAIL_DRIVER", "")
+	if gin.Mode() == gin.ReleaseMode {
+		if driver == "" || driver == "log" {
+			log.Fatal("MAIL_DRIVER must be smtp or file in release mode")
+		}
+	} else if driver == "" {
+		log.Println("Warning: MAIL_DRIVER is not set, emails are written to the log")
+		driver = "log"
+	}
+	switch driver {
+	case "smtp":
+		mailer = SMTPMailer{
+			Host:     config.GetEnv("SMTP_HOST", "localhost"),
+			Port:     config.GetEnv("SMTP_PORT", "587"),
+			Username: config.GetEnv("SMTP_USERNAME", ""),
+			Password: config.GetEnv("SMTP_PASSWORD", ""),
+			From:     from,
+		}
+	case "file":
+		mailer = FileMailer{Dir: config.GetEnv("MAIL_DIR", "mail"), From: from}
+	case "log":
+		mailer = FileMailer{From: from}
+	default:
+		log.Fatalf("Unknown MAIL_DRIVER %q: use smtp, file or log", driver)
+	}
+}
+
+// SetMailer replaces the mailer, e.g. with a fake in tests
+func SetMailer(m Mailer) {
+	mailer = m
+}
+
+// SendMail delivers the message with the configured mailer
+func SendMail(msg Message) error {
+	return mailer.Send(msg)
+}
+
+// SendMailAsync delivers the message in the background, so slow mail servers do not delay responses
+// and response times do not reveal whether an email was sent. Failures are logged.
+func SendMailAsync(msg Message) {
+	go func() {
+		if err := SendMail(msg); err != nil {
+			log.Printf("Failed to send email %q to %s: %v", msg.Subject, msg.To, err)
+		}
+	}()
+}
+
+// formatMessage renders the message in RFC 5322 format
+func formatMessage(from string, msg Message) []byte {
+	var sb strings.Builder
+	sb.WriteString("From: " + from + "\r\n")
+	sb.WriteString("To: " + msg.To + "\r\n")
+	sb.WriteString("Subject: " + mime.QEncoding.Encode("utf-8", msg.Subject) + "\r\n")
+	sb.WriteString("Date: " + time.Now().Format(time.RFC1123Z) + "\r\n")
+	sb.WriteString("MIME-Version: 1.0\r\n")
+	sb.WriteString("Content-Type: text/plain; charset=utf-8\r\n")
+	sb.WriteString("Content-Transfer-Encoding: 8bit\r\n\r\n")
+	sb.WriteString(strings.ReplaceAll(msg.Body, "\n", "\r\n"))
+	return []byte(sb.String())
+}
+
+// SMTPMailer sends emails through an SMTP server, using STARTTLS when the server offers it
+type SMTPMailer struct {
+	Host     string
+	Port     string
+	Username string
+	Password string
+	From     string // Display name and address, e.g. "Organizer <no-reply@example.com>"
+}
+
+func (m SMTPMailer) Send(msg Message) error {
+	if strings.ContainsAny(msg.To, "\r\n") {
+		return fmt.Errorf("invalid recipient %q", msg.To)
+	}
+	var auth smtp.Auth
+	if m.Username != "" {
+		auth = smtp.PlainAuth("", m.Username, m.Password, m.Host)
+	}
+	envelopeFrom := m.From
+	if start, end := strings.LastIndex(m.From, "<"), strings.LastIndex(m.From, ">"); start >= 0 && end > start {
+		envelopeFrom = m.From[start+1 : end]
+	}
+	return smtp.SendMail(m.Host+":"+m.Port, auth, envelopeFrom, []string{msg.To}, formatMessage(m.From, msg))
+}
+
+// FileMailer writes emails as .eml files to Dir, or to the log when Dir is empty. Tokens in links are
+// redacted in the log.
+type FileMailer struct {
+	Dir  string
+	From string
+}
+
+func (m FileMailer) Send(msg Message) error {
+	data := formatMessage(m.From, msg)
+	if m.Dir == "" {
+		log.Printf("Email to %s:\n%s", msg.To, redactTokens(data))
+		return nil
+	}
+
+	if err := os.MkdirAll(m.Dir, 0o755); err != nil {
+		return err
+	}
+	name := fmt.Sprintf("%s-%s.eml", time.Now().Format("20060102-150405.000000000"), strings.NewReplacer("@", "_at_", "/", "_", "\\", "_").Replace(msg.To))
+	return os.WriteFile(filepath.Join(m.Dir, name), data, 0o644)
+}
+
+// tokenParam matches the token query parameter of links in emails
+var tokenParam = regexp.MustCompile(`([?&]token=)[^&\s]+`)
+
+// redactTokens hides the tokens of links, so the log cannot be used to take over accounts
+func redactTokens(data []byte) []byte {
+	return tokenParam.ReplaceAll(data, []byte("${1}[redacted]"))
+}
@@ -0,0 +1,223 @@
+package utils
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+var (
+	inlineSpaceRegex   = regexp.MustCompile(`\s+`)
+	trailingSpaceRegex = regexp.MustCompile(`[ \t]+\n`)
+	blankLinesRegex    = regexp.MustCompile(`\n{3,}`)
+)
+
+// HTMLToMarkdown converts HTML, including Evernote ENML (<en-note>, <en-todo>), to Markdown.
+// Unknown elements keep only their text; attachments (<en-media>) are dropped.
+func HTMLToMarkdown(source string) (string, error) {
+	doc, err := html.Parse(strings.NewReader(source))
+	if err != nil {
+		return "", err
+	}
+	converter := markdownConverter{}
+	return cleanMarkdown(converter.children(doc)), nil
+}
+
+type markdownConverter struct {
+	pre int // Depth of <pre> elements, whitespace is kept inside them
+}
+
+func cleanMarkdown(text string) string {
+	text = trailingSpaceRegex.ReplaceAllString(text, "\n")
+	text = blankLinesRegex.ReplaceAllString(text, "\n\n")
+	return strings.TrimSpace(text)
+}
+
+func findAttr(n *html.Node, key string) (string, bool) {
+	for _, a := range n.Attr {
+		if a.Key == key {
+			return a.Val, true
+		}
+	}
+	return "", false
+}
+
+func attr(n *html.Node, key string) string {
+	value, _ := findAttr(n, key)
+	return value
+}
+
+func (c *markdownConverter) children(n *html.Node) string {
+	var sb strings.Builder
+	for child := n.FirstChild; child != nil; child = child.NextSibling {
+		sb.WriteString(c.node(child))
+	}
+	return sb.String()
+}
+
+// wrap surrounds the trimmed text with a marker, keeping the outer whitespace
+func wrap(text, marker string) string {
+	trimmed := strings.TrimSpace(text)
+	if trimmed == "" {
+		return text
+	}
+	start := strings.Index(text, trimmed)
+	return text[:start] + marker + trimmed + marker + text[start+len(trimmed):]
+}
+
+func (c *markdownConverter) node(n *html.Node) string {
+	switch n.Type {
+	case html.TextNode:
+		if c.pre > 0 {
+			return n.Data
+		}
+		if strings.TrimSpace(n.Data) == "" && strings.Contains(n.Data, "\n") {
+			return "" // Indentation between tags
+		}
+		return inlineSpaceRegex.ReplaceAllString(n.Data, " ")
+	case html.ElementNode:
+	default:
+		return c.children(n)
+	}
+
+	switch n.Data {
+	case "head", "script", "style", "title", "en-media", "en-crypt":
+		return ""
+	case "br":
+		return "\n"
+	case "hr":
+		return "\n\n---\n\n"
+	case "div", "en-note", "section", "article", "header", "footer":
+		return "\n" + c.children(n) + "\n"
+	case "p":
+		return "\n\n" + c.children(n) + "\n\n"
+	case "h1", "h2", "h3", "h4", "h5", "h6":
+		level := int(n.Data[1] - '0')
+		return "\n\n" + strings.Repeat("#", level) + " " + strings.TrimSpace(c.children(n)) + "\n\n"
+	case "b", "strong":
+		return wrap(c.children(n), "**")
+	case "i", "em":
+		return wrap(c.children(n), "_")
+	case "s", "strike", "del":
+		return wrap(c.children(n), "~~")
+	case "code":
+		if c.pre > 0 {
+			return c.children(n)
+		}
+		return wrap(c.children(n), "`")
+	case "pre":
+		c.pre++
+		text := c.children(n)
+		c.pre--
+		return "\n\n```\n" + strings.Trim(text, "\n") + "\n```\n\n"
+	case "blockquote":
+		lines := strings.Split(cleanMarkdown(c.children(n)), "\n")
+		for i, line := range lines {
+			lines[i] = strings.TrimRight("> "+line, " ")
+		}
+		return "\n\n" + strings.Join(lines, "\n") + "\n\n"
+	case "a":
+		text, href := c.children(n), attr(n, "href")
+		if href == "" || strings.TrimSpace(text) == "" {
+			return text
+		}
+		return "[" + strings.TrimSpace(text) + "](" + href + ")"
+	case "img":
+		if src := attr(n, "src"); src != "" {
+			return "![" + attr(n, "alt") + "](" + src + ")"
+		}
+		return ""
+	case "en-todo": // Parsed as a container, so following text ends up inside it
+		if attr(n, "checked") == "true" {
+			return "[x] " + c.children(n)
+		}
+		return "[ ] " + c.children(n)
+	case "input":
+		if attr(n, "type") != "checkbox" {
+			return ""
+		}
+		if _, checked := findAttr(n, "checked"); checked {
+			return "[x] "
+		}
+		return "[ ] "
+	case "ul", "ol":
+		return c.list(n)
+	case "table":
+		return c.table(n)
+	}
+	return c.children(n)
+}
+
+func (c *markdownConverter) list(n *html.Node) string {
+	var items []string
+	number := 1
+	for child := n.FirstChild; child != nil; child = child.NextSibling {
+		if child.Type != html.ElementNode || child.Data != "li" {
+			continue
+		}
+		marker := "- "
+		if n.Data == "ol" {
+			marker = fmt.Sprintf("%d. ", number)
+			number++
+		}
+		text := strings.ReplaceAll(cleanMarkdown(c.children(child)), "\n\n", "\n")
+		lines := strings.Split(text, "\n")
+		for i := range lines {
+			if i == 0 {
+				lines[i] = marker + lines[i]
+			} else if lines[i] != "" {
+				lines[i] = strings.Repeat(" ", len(marker)) + lines[i]
+			}
+		}
+		items = append(items, strings.Join(lines, "\n"))
+	}
+	return "\n\n" + strings.Join(items, "\n") + "\n\n"
+}
+
+func (c *markdownConverter) table(n *html.Node) string {
+	var rows [][]string
+	var collect func(*html.Node)
+	collect = func(node *html.Node) {
+		for child := node.FirstChild; child != nil; child = child.NextSibling {
+			if child.Type != html.ElementNode {
+				continue
+			}
+			if child.Data != "tr" {
+				collect(child)
+				continue
+			}
+			var row []string
+			for cell := child.FirstChild; cell != nil; cell = cell.NextSibling {
+				if cell.Type == html.ElementNode && (cell.Data == "td" || cell.Data == "th") {
+					text := strings.ReplaceAll(cleanMarkdown(c.children(cell)), "\n", " ")
+					row = append(row, strings.ReplaceAll(text, "|", `\|`))
+				}
+			}
+			rows = append(rows, row)
+		}
+	}
+	collect(n)
+	if len(rows) == 0 {
+		return ""
+	}
+
+	columns := 0
+	for _, row := range rows {
+		if len(row) > columns {
+			columns = len(row)
+		}
+	}
+	var sb strings.Builder
+	for i, row := range rows {
+		for len(row) < columns {
+			row = append(row, "")
+		}
+		sb.WriteString("| " + strings.Join(row, " | ") + " |\n")
+		if i == 0 {
+			sb.WriteString("|" + strings.Repeat(" --- |", columns) + "\n")
+		}
+	}
+	return "\n\n" + sb.String() + "\n"
+}
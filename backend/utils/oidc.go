@@ -0,0 +1,118 @@
+package utils
+
+import (
+	"context"
+	"log"
+	"organizer-backend/config"
+	"strings"
+	"sync"
+
+	"github.com/coreos/go-oidc/v3/oidc"
+	"golang.org/x/oauth2"
+)
+
+// OIDCProvider is an OpenID Connect identity provider users can sign in with
+type OIDCProvider struct {
+	Name         string // Used in URLs, e.g. /api/auth/oidc/company/login
+	DisplayName  string
+	Issuer       string
+	ClientID     string
+	ClientSecret string
+	Scopes       []string
+
+	mu        sync.Mutex
+	discovery *oidc.Provider
+}
+
+var (
+	oidcProviders     = map[string]*OIDCProvider{}
+	oidcProviderOrder []string
+)
+
+// InitOIDC reads the providers listed in OIDC_PROVIDERS (comma separated names). Each provider NAME is
+// configured by OIDC_NAME_ISSUER, OIDC_NAME_CLIENT_ID, OIDC_NAME_CLIENT_SECRET and optionally
+// OIDC_NAME_DISPLAY_NAME and OIDC_NAME_SCOPES (space separated, "openid email profile" by default).
+func InitOIDC() {
+	config.LoadEnv()
+	for _, name := range strings.Split(config.GetEnv("OIDC_PROVIDERS", ""), ",") {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "" {
+			continue
+		}
+		prefix := "OIDC_" + strings.ToUpper(strings.ReplaceAll(name, "-", "_")) + "_"
+		provider := &OIDCProvider{
+			Name:         name,
+			DisplayName:  config.GetEnv(prefix+"DISPLAY_NAME", name),
+			Issuer:       config.GetEnv(prefix+"ISSUER", ""),
+			ClientID:     config.GetEnv(prefix+"CLIENT_ID", ""),
+			ClientSecret: config.GetEnv(prefix+"CLIENT_SECRET", ""),
+			Scopes:       strings.Fields(config.GetEnv(prefix+"SCOPES", "openid email profile")),
+		}
+		if provider.Issuer == "" || provider.ClientID == "" {
+			log.Printf("Warning: OIDC provider %q needs %sISSUER and %sCLIENT_ID, skipping it", name, prefix, prefix)
+			continue
+		}
+		oidcProviders[name] = provider
+		oidcProviderOrder = append(oidcProviderOrder, name)
+	}
+}
+
+// GetOIDCProvider returns the configured provider with the name
+func GetOIDCProvider(name string) (*OIDCProvider, bool) {
+	provider, ok := oidcProviders[name]
+	return provider, ok
+}
+
+// OIDCProviders returns the configured providers in the order of OIDC_PROVIDERS
+func OIDCProviders() []*OIDCProvider {
+	providers := make([]*OIDCProvider, 0, len(oidcProviderOrder))
+	for _, name := range oidcProviderOrder {
+		providers = append(providers, oidcProviders[name])
+	}
+	return providers
+}
+
+// discover fetches the discovery document of the issuer on first use, so the server also starts while an
+// identity provider is unreachable. Failed discoveries are retried on the next login.
+func (p *OIDCProvider) discover(ctx context.Context) (*oidc.Provider, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.discovery != nil {
+		return p.discovery, nil
+	}
+	discovery, err := oidc.NewProvider(ctx, p.Issuer)
+	if err != nil {
+		return nil, err
+	}
+	p.discovery = discovery
+	return discovery, nil
+}
+
+// OAuth2Config returns the authorization code flow configuration with the endpoints from discovery
+func (p *OIDCProvider) OAuth2Config(ctx context.Context, redirectURL string) (*oauth2.Config, error) {
+	discovery, err := p.discover(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &oauth2.Config{
+		ClientID:     p.ClientID,
+		ClientSecret: p.ClientSecret,
+		Endpoint:     discovery.Endpoint(),
+		RedirectURL:  redirectURL,
+		Scopes:       p.Scopes,
+	}, nil
+}
+
+// Verifier checks the signature, issuer, audience and expiry of ID tokens of the provider
+func (p *OIDCProvider) Verifier(ctx context.Context) (*oidc.IDTokenVerifier, error) {
+	discovery, err := p.discover(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return discovery.Verifier(&oidc.Config{ClientID: p.ClientID}), nil
+}
+
+// APIURL returns the public URL of this API, used for OIDC redirect URLs
+func APIURL(path string) string {
+	return strings.TrimRight(config.GetEnv("API_URL", "http://localhost:8080"), "/") + path
+}
@@ -0,0 +1,182 @@
+package utils
+
+import (
+	"log"
+	"math"
+	"organizer-backend/config"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// RateLimitStore keeps counters and locks for rate limiting. MemoryRateLimitStore works for a single
+// instance; when several instances run behind a load balancer a shared store (e.g. Redis) can implement it.
+type RateLimitStore interface {
+	// Increment adds a hit to the counter of the key and returns the new count and the time until the
+	// counter resets. A counter resets when window has passed since its first hit.
+	Increment(key string, window time.Duration) (count int, resetIn time.Duration)
+	// Reset deletes the counter of the key
+	Reset(key string)
+	// Lock blocks the key for the duration
+	Lock(key string, d time.Duration)
+	// Locked returns how long the key is still blocked, 0 when it is not
+	Locked(key string) time.Duration
+}
+
+// Limits for authentication endpoints, configured by InitRateLimits
+var (
+	AuthRateLimit    = 10          // Requests per AuthRateWindow from one IP address
+	AuthRateWindow   = time.Minute // Window of AuthRateLimit
+	LoginMaxFailures = 5           // Failed attempts before an account is locked
+	LoginLockout     = time.Minute // First lockout, doubled with every further failure
+	LoginLockoutMax  = time.Hour   // Upper bound of a lockout
+)
+
+const loginFailureWindow = 24 * time.Hour // Failures older than this are forgotten
+
+var rateLimitStore RateLimitStore = NewMemoryRateLimitStore()
+
+// InitRateLimits reads the limits from the environment
+func InitRateLimits() {
+	config.LoadEnv()
+	AuthRateLimit = intFromEnv("AUTH_RATE_LIMIT", AuthRateLimit)
+	AuthRateWindow = durationFromEnv("AUTH_RATE_WINDOW", AuthRateWindow)
+	LoginMaxFailures = intFromEnv("LOGIN_MAX_FAILURES", LoginMaxFailures)
+	LoginLockout = durationFromEnv("LOGIN_LOCKOUT", LoginLockout)
+	LoginLockoutMax = durationFromEnv("LOGIN_LOCKOUT_MAX", LoginLockoutMax)
+}
+
+func intFromEnv(key string, fallback int) int {
+	value := config.GetEnv(key, "")
+	if value == "" {
+		return fallback
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil || n <= 0 {
+		log.Printf("Warning: invalid %s, using default of %d", key, fallback)
+		return fallback
+	}
+	return n
+}
+
+// SetRateLimitStore replaces the store, e.g. with a shared one
+func SetRateLimitStore(store RateLimitStore) {
+	rateLimitStore = store
+}
+
+// AllowRequest counts a request for the key and reports whether it is within limit per window.
+// When it is not, the time until the next request is allowed is returned.
+func AllowRequest(key string, limit int, window time.Duration) (bool, time.Duration) {
+	count, resetIn := rateLimitStore.Increment("rate:"+key, window)
+	if count > limit {
+		return false, resetIn
+	}
+	return true, 0
+}
+
+// AccountLockedFor returns how long the account is still locked out
+func AccountLockedFor(account string) time.Duration {
+	return rateLimitStore.Locked("lock:" + account)
+}
+
+// RecordLoginFailure counts a failed attempt for the account. After LoginMaxFailures failures the account
+// is locked, for LoginLockout at first and twice as long with every further failure, up to LoginLockoutMax.
+// The number of failures and the new lockout (0 when the account was not locked) are returned.
+func RecordLoginFailure(account string) (int, time.Duration) {
+	failures, _ := rateLimitStore.Increment("failures:"+account, loginFailureWindow)
+	if failures < LoginMaxFailures {
+		return failures, 0
+	}
+
+	exponent := float64(failures - LoginMaxFailures)
+	lockout := time.Duration(math.Min(float64(LoginLockout)*math.Pow(2, exponent), float64(LoginLockoutMax)))
+	rateLimitStore.Lock("lock:"+account, lockout)
+	return failures, lockout
+}
+
+// ResetLoginFailures forgets the failed attempts of the account after a successful login
+func ResetLoginFailures(account string) {
+	rateLimitStore.Reset("failures:" + account)
+}
+
+// RetryAfterSeconds formats a wait time for the Retry-After header, rounded up to whole seconds
+func RetryAfterSeconds(d time.Duration) string {
+	return strconv.Itoa(int(math.Ceil(d.Seconds())))
+}
+
+// MemoryRateLimitStore is a RateLimitStore for a single instance. Expired entries are swept once a minute.
+type MemoryRateLimitStore struct {
+	mu        sync.Mutex
+	counters  map[string]memoryCounter
+	locks     map[string]time.Time
+	lastSweep time.Time
+}
+
+type memoryCounter struct {
+	count   int
+	resetAt time.Time
+}
+
+func NewMemoryRateLimitStore() *MemoryRateLimitStore {
+	return &MemoryRateLimitStore{counters: map[string]memoryCounter{}, locks: map[string]time.Time{}, lastSweep: time.Now()}
+}
+
+func (s *MemoryRateLimitStore) Increment(key string, window time.Duration) (int, time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := time.Now()
+	s.sweep(now)
+
+	counter, ok := s.counters[key]
+	if !ok || !now.Before(counter.resetAt) {
+		counter = memoryCounter{resetAt: now.Add(window)}
+	}
+	counter.count++
+	s.counters[key] = counter
+	return counter.count, counter.resetAt.Sub(now)
+}
+
+func (s *MemoryRateLimitStore) Reset(key string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.counters, key)
+}
+
+func (s *MemoryRateLimitStore) Lock(key string, d time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.locks[key] = time.Now().Add(d)
+}
+
+func (s *MemoryRateLimitStore) Locked(key string) time.Duration {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	until, ok := s.locks[key]
+	if !ok {
+		return 0
+	}
+	remaining := time.Until(until)
+	if remaining <= 0 {
+		delete(s.locks, key)
+		return 0
+	}
+	return remaining
+}
+
+// sweep deletes expired counters and locks, so the maps do not grow with every IP address ever seen
+func (s *MemoryRateLimitStore) sweep(now time.Time) {
+	if now.Sub(s.lastSweep) < time.Minute {
+		return
+	}
+	s.lastSweep = now
+	for key, counter := range s.counters {
+		if !now.Before(counter.resetAt) {
+			delete(s.counters, key)
+		}
+	}
+	for key, until := range s.locks {
+		if !now.Before(until) {
+			delete(s.locks, key)
+		}
+	}
+}
@@ -0,0 +1,98 @@
+package utils
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base32"
+	"encoding/base64"
+	"image/png"
+	"organizer-backend/config"
+	"strings"
+	"time"
+
+	"github.com/pquerna/otp"
+	"github.com/pquerna/otp/totp"
+)
+
+const totpPeriod = 30 // Seconds per code (RFC 6238 default)
+
+var totpOpts = totp.ValidateOpts{Period: totpPeriod, Digits: otp.DigitsSix, Algorithm: otp.AlgorithmSHA1}
+
+// TOTPEnrollment is a new TOTP secret with everything an authenticator app needs to add it
+type TOTPEnrollment struct {
+	Secret     string
+	OtpauthURI string
+	QRCodePNG  string // data: URL of a PNG with the otpauth URI
+}
+
+// NewTOTPEnrollment generates a TOTP secret for the account (the user's email)
+func NewTOTPEnrollment(account string) (TOTPEnrollment, error) {
+	key, err := totp.Generate(totp.GenerateOpts{
+		Issuer:      config.GetEnv("TOTP_ISSUER", "Organizer"),
+		AccountName: account,
+		Period:      totpPeriod,
+		Digits:      totpOpts.Digits,
+		Algorithm:   totpOpts.Algorithm,
+	})
+	if err != nil {
+		return TOTPEnrollment{}, err
+	}
+
+	img, err := key.Image(256, 256)
+	if err != nil {
+		return TOTPEnrollment{}, err
+	}
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return TOTPEnrollment{}, err
+	}
+
+	return TOTPEnrollment{
+		Secret:     key.Secret(),
+		OtpauthURI: key.URL(),
+		QRCodePNG:  "data:image/png;base64," + base64.StdEncoding.EncodeToString(buf.Bytes()),
+	}, nil
+}
+
+// ValidateTOTP checks a code against the secret, allowing one period of clock skew. Codes of time steps up to
+// lastStep were already used and are rejected. On success the matched step is returned to be stored as lastStep.
+func ValidateTOTP(secret, code string, lastStep int64) (int64, bool) {
+	code = strings.ReplaceAll(code, " ", "")
+	now := time.Now()
+	current := now.Unix() / totpPeriod
+	for offset := int64(-1); offset <= 1; offset++ {
+		step := current + offset
+		if step <= lastStep {
+			continue
+		}
+		expected, err := totp.GenerateCodeCustom(secret, now.Add(time.Duration(offset*totpPeriod)*time.Second), totpOpts)
+		if err != nil {
+			return 0, false
+		}
+		if subtle.ConstantTimeCompare([]byte(expected), []byte(code)) == 1 {
+			return step, true
+		}
+	}
+	return 0, false
+}
+
+// GenerateRecoveryCodes returns n random one-time codes formatted as xxxxx-xxxxx
+func GenerateRecoveryCodes(n int) ([]string, error) {
+	encoding := base32.StdEncoding.WithPadding(base32.NoPadding)
+	codes := make([]string, n)
+	for i := range codes {
+		buf := make([]byte, 7)
+		if _, err := rand.Read(buf); err != nil {
+			return nil, err
+		}
+		code := strings.ToLower(encoding.EncodeToString(buf))[:10]
+		codes[i] = code[:5] + "-" + code[5:]
+	}
+	return codes, nil
+}
+
+// NormalizeRecoveryCode makes user input comparable to generated codes (case, spaces and dashes are ignored)
+func NormalizeRecoveryCode(code string) string {
+	return strings.ToLower(strings.NewReplacer("-", "", " ", "").Replace(code))
+}
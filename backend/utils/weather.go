@@ -0,0 +1,294 @@
+package utils
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"organizer-backend/config"
+	"organizer-backend/models"
+	"strings"
+	"sync"
+	"time"
+)
+
+// WeatherQuery describes the place the weather is requested for, by city name or by coordinates, and the
+// units and language of the answer (metric and Russian when empty)
+type WeatherQuery struct {
+	City      string
+	Latitude  *float64 // Used instead of City when both coordinates are set
+	Longitude *float64
+	Units     string // models.WeatherUnitsMetric or models.WeatherUnitsImperial
+	Language  string // models.WeatherLanguageRussian or models.WeatherLanguageEnglish
+}
+
+func (q WeatherQuery) hasCoordinates() bool {
+	return q.Latitude != nil && q.Longitude != nil
+}
+
+func (q WeatherQuery) units() string {
+	if q.Units == "" {
+		return models.WeatherUnitsMetric
+	}
+	return q.Units
+}
+
+func (q WeatherQuery) imperial() bool {
+	return q.units() == models.WeatherUnitsImperial
+}
+
+func (q WeatherQuery) language() string {
+	if q.Language == "" {
+		return models.WeatherLanguageRussian
+	}
+	return q.Language
+}
+
+// cacheKey identifies the place, units and language of the query in cache keys. Coordinates are rounded
+// to about a kilometre, closer places share the weather.
+func (q WeatherQuery) cacheKey() string {
+	place := normalizeCity(q.City)
+	if q.hasCoordinates() {
+		place = fmt.Sprintf("@%.2f,%.2f", *q.Latitude, *q.Longitude)
+	}
+	return q.units() + "|" + q.language() + "|" + place
+}
+
+// WeatherProvider is a weather service. Providers are registered with RegisterWeatherProvider and queried
+// in parallel by FetchCurrentWeather.
+type WeatherProvider interface {
+	ID() string   // Used in configuration, e.g. "openweathermap"
+	Name() string // Shown to users, e.g. "OpenWeatherMap"
+	Current(ctx context.Context, query WeatherQuery) (models.WeatherSource, error)
+}
+
+// WeatherForecaster is implemented by providers that also forecast the weather. Providers return up to
+// days days, starting today; hourly points are only fetched when hourly is set.
+type WeatherForecaster interface {
+	WeatherProvider
+	MaxForecastDays() int
+	Forecast(ctx context.Context, query WeatherQuery, days int, hourly bool) (models.SourceForecast, error)
+}
+
+// Kinds of weather provider errors
+const (
+	WeatherErrorRequest  = "request"   // The provider could not be reached
+	WeatherErrorStatus   = "status"    // The provider answered with an error status
+	WeatherErrorDecode   = "decode"    // The response could not be read
+	WeatherErrorNotFound = "not_found" // The provider does not know the place
+)
+
+// WeatherProviderError is the error of a single provider. The other providers still answer.
+type WeatherProviderError struct {
+	Provider   string // Provider ID
+	Kind       string // One of the WeatherError* kinds
+	StatusCode int    // HTTP status for WeatherErrorStatus
+	Err        error
+}
+
+func (e *WeatherProviderError) Error() string {
+	message := e.Provider + ": " + e.Kind
+	if e.StatusCode != 0 {
+		message += fmt.Sprintf(" (HTTP %d)", e.StatusCode)
+	}
+	if e.Err != nil {
+		message += ": " + e.Err.Error()
+	}
+	return message
+}
+
+func (e *WeatherProviderError) Unwrap() error {
+	return e.Err
+}
+
+var (
+	weatherProviders  []WeatherProvider
+	weatherHTTPClient = &http.Client{Timeout: 10 * time.Second}
+
+	currentWeatherCache = newTTLCache[models.WeatherSource](10 * time.Minute)
+	forecastCache       = newTTLCache[models.SourceForecast](10 * time.Minute)
+	geocodingCache      = newTTLCache[models.OpenMeteoGeocodingResult](30 * 24 * time.Hour) // Cities do not move
+)
+
+// InitWeatherProviders registers the built-in providers listed in WEATHER_PROVIDERS (comma separated IDs,
+// all by default). Base URLs can be changed with OPENWEATHERMAP_URL, WEATHERAPI_URL, OPENMETEO_URL and
+// OPENMETEO_GEOCODING_URL; providers that need a key are skipped when it is not set. Answers are cached
+// for WEATHER_CACHE_TTL, geocoding results for WEATHER_GEOCODING_CACHE_TTL. The settings of the aggregation of
+// the answers are read as well, see initWeatherAggregation.
+func InitWeatherProviders() {
+	config.LoadEnv()
+	initWeatherAggregation()
+	currentWeatherCache = newTTLCache[models.WeatherSource](durationFromEnv("WEATHER_CACHE_TTL", 10*time.Minute))
+	forecastCache = newTTLCache[models.SourceForecast](durationFromEnv("WEATHER_CACHE_TTL", 10*time.Minute))
+	geocodingCache = newTTLCache[models.OpenMeteoGeocodingResult](durationFromEnv("WEATHER_GEOCODING_CACHE_TTL", 30*24*time.Hour))
+
+	builtIn := map[string]WeatherProvider{
+		"openweathermap": &OpenWeatherMapProvider{
+			BaseURL: config.GetEnv("OPENWEATHERMAP_URL", "https://api.openweathermap.org/data/2.5"),
+			APIKey:  config.GetEnv("OPENWEATHERMAP_API_KEY", ""),
+		},
+		"weatherapi": &WeatherAPIProvider{
+			BaseURL: config.GetEnv("WEATHERAPI_URL", "https://api.weatherapi.com/v1"),
+			APIKey:  config.GetEnv("WEATHERAPI_API_KEY", ""),
+		},
+		"openmeteo": &OpenMeteoProvider{
+			BaseURL:      config.GetEnv("OPENMETEO_URL", "https://api.open-meteo.com/v1"),
+			GeocodingURL: config.GetEnv("OPENMETEO_GEOCODING_URL", "https://geocoding-api.open-meteo.com/v1"),
+		},
+	}
+
+	weatherProviders = nil
+	for _, id := range strings.Split(config.GetEnv("WEATHER_PROVIDERS", "openweathermap,weatherapi,openmeteo"), ",") {
+		id = strings.ToLower(strings.TrimSpace(id))
+		if id == "" {
+			continue
+		}
+		provider, ok := builtIn[id]
+		if !ok {
+			log.Printf("Warning: unknown weather provider %q in WEATHER_PROVIDERS", id)
+			continue
+		}
+		if keyed, ok := provider.(interface{ HasAPIKey() bool }); ok && !keyed.HasAPIKey() {
+			log.Printf("Warning: API key of weather provider %s is not set, skipping it", id)
+			continue
+		}
+		RegisterWeatherProvider(provider)
+	}
+	if len(weatherProviders) == 0 {
+		log.Println("Warning: no weather providers are enabled")
+	}
+}
+
+// RegisterWeatherProvider adds a provider, e.g. a stand-in in tests
+func RegisterWeatherProvider(provider WeatherProvider) {
+	weatherProviders = append(weatherProviders, provider)
+}
+
+// SetWeatherProviders replaces all registered providers
+func SetWeatherProviders(providers ...WeatherProvider) {
+	weatherProviders = providers
+}
+
+// WeatherProviders returns the registered providers
+func WeatherProviders() []WeatherProvider {
+	return weatherProviders
+}
+
+// FetchCurrentWeather asks all registered providers in parallel, or takes their answers from the cache.
+// Sources are in registration order; providers that failed are left out and their errors returned.
+// The last result is the cache status of the sources (CacheHit, CacheMiss or CachePartial).
+func FetchCurrentWeather(ctx context.Context, query WeatherQuery) ([]models.WeatherSource, []error, string) {
+	return queryProviders(weatherProviders, func(provider WeatherProvider) (models.WeatherSource, bool, error) {
+		key := provider.ID() + "|current|" + query.cacheKey()
+		return currentWeatherCache.get(key, func() (models.WeatherSource, error) {
+			source, err := provider.Current(detachedContext(ctx), query)
+			source.Provider = provider.ID()
+			source.FetchedAt = time.Now()
+			return source, providerError(provider, err)
+		})
+	})
+}
+
+// FetchForecast asks all registered forecasters in parallel, each for at most its MaxForecastDays.
+// Providers without forecasts are skipped. Forecasts are cached like current weather.
+func FetchForecast(ctx context.Context, query WeatherQuery, days int, hourly bool) ([]models.SourceForecast, []error, string) {
+	var forecasters []WeatherForecaster
+	for _, provider := range weatherProviders {
+		if forecaster, ok := provider.(WeatherForecaster); ok {
+			forecasters = append(forecasters, forecaster)
+		}
+	}
+	return queryProviders(forecasters, func(forecaster WeatherForecaster) (models.SourceForecast, bool, error) {
+		days := min(days, forecaster.MaxForecastDays())
+		key := fmt.Sprintf("%s|forecast|%d|%t|%s", forecaster.ID(), days, hourly, query.cacheKey())
+		return forecastCache.get(key, func() (models.SourceForecast, error) {
+			forecast, err := forecaster.Forecast(detachedContext(ctx), query, days, hourly)
+			forecast.Provider = forecaster.ID()
+			forecast.FetchedAt = time.Now()
+			return forecast, providerError(forecaster, err)
+		})
+	})
+}
+
+// providerError makes sure the error of a provider tells which provider failed
+func providerError(provider WeatherProvider, err error) error {
+	var providerErr *WeatherProviderError
+	if err == nil || errors.As(err, &providerErr) {
+		return err
+	}
+	return &WeatherProviderError{Provider: provider.ID(), Kind: WeatherErrorRequest, Err: err}
+}
+
+// detachedContext keeps the values of the request context but not its cancellation: a coalesced load is
+// shared by all waiting requests, so the first of them going away must not cancel it. The HTTP client
+// timeout still limits the load.
+func detachedContext(ctx context.Context) context.Context {
+	return context.WithoutCancel(ctx)
+}
+
+// queryProviders calls fetch for all providers in parallel and returns the results in provider order,
+// followed by the errors of the providers that failed and the cache status of the results
+func queryProviders[P any, T any](providers []P, fetch func(P) (T, bool, error)) ([]T, []error, string) {
+	results := make([]T, len(providers))
+	hits := make([]bool, len(providers))
+	errs := make([]error, len(providers))
+
+	var wg sync.WaitGroup
+	for i, provider := range providers {
+		wg.Add(1)
+		go func(i int, provider P) {
+			defer wg.Done()
+			results[i], hits[i], errs[i] = fetch(provider)
+		}(i, provider)
+	}
+	wg.Wait()
+
+	var succeeded []T
+	var failures []error
+	var cached int
+	for i := range providers {
+		if errs[i] != nil {
+			failures = append(failures, errs[i])
+			continue
+		}
+		succeeded = append(succeeded, results[i])
+		if hits[i] {
+			cached++
+		}
+	}
+	return succeeded, failures, cacheStatus(cached, len(succeeded))
+}
+
+// getWeatherJSON requests baseURL+path with the query parameters and decodes the JSON response into out
+func getWeatherJSON(ctx context.Context, provider, baseURL, path string, params url.Values, out interface{}) error {
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, strings.TrimRight(baseURL, "/")+path+"?"+params.Encode(), nil)
+	if err != nil {
+		return &WeatherProviderError{Provider: provider, Kind: WeatherErrorRequest, Err: err}
+	}
+	response, err := weatherHTTPClient.Do(request)
+	if err != nil {
+		var urlErr *url.Error
+		if errors.As(err, &urlErr) {
+			err = urlErr.Err // Without the URL, which contains the API key
+		}
+		return &WeatherProviderError{Provider: provider, Kind: WeatherErrorRequest, Err: err}
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode != http.StatusOK {
+		providerErr := &WeatherProviderError{Provider: provider, Kind: WeatherErrorStatus, StatusCode: response.StatusCode}
+		if body, _ := io.ReadAll(io.LimitReader(response.Body, 512)); len(bytes.TrimSpace(body)) > 0 {
+			providerErr.Err = errors.New(string(bytes.TrimSpace(body))) // Providers explain errors in the body, e.g. an invalid key
+		}
+		return providerErr
+	}
+	if err := json.NewDecoder(response.Body).Decode(out); err != nil {
+		return &WeatherProviderError{Provider: provider, Kind: WeatherErrorDecode, Err: err}
+	}
+	return nil
+}
@@ -0,0 +1,249 @@
+package utils
+
+import (
+	"errors"
+	"log"
+	"math"
+	"net/http"
+	"organizer-backend/config"
+	"organizer-backend/models"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Strategies to combine the temperatures of several providers
+const (
+	AggregateMean        = "mean"     // Arithmetic mean
+	AggregateMedian      = "median"   // Middle value, the mean of the two middle values for an even count
+	AggregateTrimmedMean = "trimmed"  // Mean without the highest and lowest values
+	AggregateWeighted    = "weighted" // Mean weighted by the reliability of the providers
+)
+
+// AggregationStrategies lists all strategies
+var AggregationStrategies = []string{AggregateMean, AggregateMedian, AggregateTrimmedMean, AggregateWeighted}
+
+var (
+	defaultAggregation      = AggregateMedian
+	outlierThresholdCelsius = 5.0                  // Sources further than this from the median are outliers
+	trimFraction            = 0.2                  // Share of the values dropped at each end by AggregateTrimmedMean
+	providerWeights         = map[string]float64{} // Provider ID to reliability, 1 when not set
+)
+
+// TemperatureAggregate is the combination of the temperatures of several sources
+type TemperatureAggregate struct {
+	Value      float64 // Combined temperature, rounded to two decimals
+	Spread     float64 // Standard deviation of the sources that were used
+	Confidence float64 // From 0 to 1, see AggregateTemperatures
+	Outliers   []bool  // Per source, outliers are not used
+}
+
+// initWeatherAggregation reads WEATHER_AGGREGATION, WEATHER_OUTLIER_THRESHOLD (°C), WEATHER_TRIM_FRACTION
+// and WEATHER_PROVIDER_WEIGHTS (e.g. "openweathermap=1,weatherapi=0.8")
+func initWeatherAggregation() {
+	defaultAggregation = AggregateMedian
+	if strategy := config.GetEnv("WEATHER_AGGREGATION", AggregateMedian); IsAggregationStrategy(strategy) {
+		defaultAggregation = strategy
+	} else {
+		log.Printf("Warning: unknown WEATHER_AGGREGATION %q, using %s", strategy, AggregateMedian)
+	}
+	outlierThresholdCelsius = floatFromEnv("WEATHER_OUTLIER_THRESHOLD", 5, 0, math.Inf(1))
+	trimFraction = floatFromEnv("WEATHER_TRIM_FRACTION", 0.2, 0, 0.5)
+
+	providerWeights = map[string]float64{}
+	for _, pair := range strings.Split(config.GetEnv("WEATHER_PROVIDER_WEIGHTS", ""), ",") {
+		if strings.TrimSpace(pair) == "" {
+			continue
+		}
+		id, value, _ := strings.Cut(pair, "=")
+		weight, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+		if err != nil || weight < 0 {
+			log.Printf("Warning: invalid weight %q in WEATHER_PROVIDER_WEIGHTS", pair)
+			continue
+		}
+		providerWeights[strings.ToLower(strings.TrimSpace(id))] = weight
+	}
+}
+
+// floatFromEnv reads a number between min and max (exclusive) from the environment
+func floatFromEnv(key string, fallback, min, max float64) float64 {
+	value := config.GetEnv(key, "")
+	if value == "" {
+		return fallback
+	}
+	number, err := strconv.ParseFloat(value, 64)
+	if err != nil || number <= min || number >= max {
+		log.Printf("Warning: invalid %s, using default of %g", key, fallback)
+		return fallback
+	}
+	return number
+}
+
+// DefaultAggregation returns the strategy of WEATHER_AGGREGATION
+func DefaultAggregation() string {
+	return defaultAggregation
+}
+
+// IsAggregationStrategy reports whether the strategy is one of AggregationStrategies
+func IsAggregationStrategy(strategy string) bool {
+	for _, s := range AggregationStrategies {
+		if s == strategy {
+			return true
+		}
+	}
+	return false
+}
+
+// AggregateTemperatures combines the temperatures of the providers with the strategy. With three or more
+// sources, those further than WEATHER_OUTLIER_THRESHOLD from the median are outliers and left out, as long
+// as most sources agree. The confidence is the share of the queried providers that were used, lowered as
+// the spread of the used sources grows: halved when it reaches the threshold.
+func AggregateTemperatures(strategy, units string, providers []string, temps []float64, queried int) TemperatureAggregate {
+	aggregate := TemperatureAggregate{Outliers: make([]bool, len(temps))}
+	if len(temps) == 0 {
+		return aggregate
+	}
+
+	threshold := outlierThresholdCelsius
+	if units == models.WeatherUnitsImperial {
+		threshold *= 1.8
+	}
+
+	center := median(temps)
+	if len(temps) >= 3 {
+		var outliers int
+		for i, temp := range temps {
+			aggregate.Outliers[i] = math.Abs(temp-center) > threshold
+			if aggregate.Outliers[i] {
+				outliers++
+			}
+		}
+		if outliers*2 >= len(temps) { // No majority to trust
+			aggregate.Outliers = make([]bool, len(temps))
+		}
+	}
+
+	var used []float64
+	var weights []float64
+	for i, temp := range temps {
+		if !aggregate.Outliers[i] {
+			used = append(used, temp)
+			weights = append(weights, providerWeight(providers[i]))
+		}
+	}
+
+	switch strategy {
+	case AggregateMedian:
+		aggregate.Value = median(used)
+	case AggregateTrimmedMean:
+		aggregate.Value = trimmedMean(used)
+	case AggregateWeighted:
+		aggregate.Value = weightedMean(used, weights)
+	default:
+		aggregate.Value = mean(used)
+	}
+	aggregate.Value = round2(aggregate.Value)
+	aggregate.Spread = round2(standardDeviation(used))
+
+	coverage := float64(len(used)) / float64(max(queried, len(temps)))
+	agreement := 1 / (1 + aggregate.Spread/threshold)
+	aggregate.Confidence = round2(coverage * agreement)
+	return aggregate
+}
+
+// WeatherFailures describes the errors of the providers that failed for the API response. Messages are
+// fixed per kind: the answers of the providers may contain details of the API account, they are only logged.
+func WeatherFailures(errs []error) []models.ProviderFailure {
+	failures := []models.ProviderFailure{}
+	for _, err := range errs {
+		failure := models.ProviderFailure{Kind: WeatherErrorRequest, Message: "provider unavailable"}
+		var providerErr *WeatherProviderError
+		if errors.As(err, &providerErr) {
+			failure = models.ProviderFailure{Provider: providerErr.Provider, Kind: providerErr.Kind, StatusCode: providerErr.StatusCode, Message: failureMessage(providerErr)}
+		}
+		failures = append(failures, failure)
+	}
+	return failures
+}
+
+func failureMessage(err *WeatherProviderError) string {
+	switch {
+	case err.Kind == WeatherErrorNotFound:
+		return "place not found"
+	case err.Kind == WeatherErrorDecode:
+		return "invalid provider response"
+	case err.Kind == WeatherErrorStatus && err.StatusCode == http.StatusTooManyRequests:
+		return "provider rate limited"
+	case err.Kind == WeatherErrorStatus && (err.StatusCode == http.StatusUnauthorized || err.StatusCode == http.StatusForbidden):
+		return "provider refused the request"
+	case err.Kind == WeatherErrorStatus && err.StatusCode < http.StatusInternalServerError:
+		return "provider rejected the request"
+	default:
+		return "provider unavailable"
+	}
+}
+
+func providerWeight(provider string) float64 {
+	if weight, ok := providerWeights[provider]; ok {
+		return weight
+	}
+	return 1
+}
+
+func mean(values []float64) float64 {
+	var total float64
+	for _, value := range values {
+		total += value
+	}
+	return total / float64(len(values))
+}
+
+func median(values []float64) float64 {
+	sorted := append([]float64(nil), values...)
+	sort.Float64s(sorted)
+	middle := len(sorted) / 2
+	if len(sorted)%2 == 0 {
+		return (sorted[middle-1] + sorted[middle]) / 2
+	}
+	return sorted[middle]
+}
+
+// trimmedMean drops trimFraction of the values, rounded up, at each end, but keeps at least one value
+func trimmedMean(values []float64) float64 {
+	sorted := append([]float64(nil), values...)
+	sort.Float64s(sorted)
+	trim := int(math.Ceil(float64(len(sorted)) * trimFraction))
+	for trim > 0 && len(sorted)-2*trim < 1 {
+		trim--
+	}
+	return mean(sorted[trim : len(sorted)-trim])
+}
+
+// weightedMean falls back to the mean when all weights are 0
+func weightedMean(values, weights []float64) float64 {
+	var total, totalWeight float64
+	for i, value := range values {
+		total += value * weights[i]
+		totalWeight += weights[i]
+	}
+	if totalWeight == 0 {
+		return mean(values)
+	}
+	return total / totalWeight
+}
+
+func standardDeviation(values []float64) float64 {
+	if len(values) < 2 {
+		return 0
+	}
+	center := mean(values)
+	var squares float64
+	for _, value := range values {
+		squares += (value - center) * (value - center)
+	}
+	return math.Sqrt(squares / float64(len(values)))
+}
+
+func round2(value float64) float64 {
+	return math.Round(value*100) / 100
+}
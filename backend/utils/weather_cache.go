@@ -0,0 +1,114 @@
+package utils
+
+import (
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/sync/singleflight"
+)
+
+// Values of the X-Cache header of weather responses
+const (
+	CacheHit     = "HIT"     // All sources came from the cache
+	CacheMiss    = "MISS"    // All sources were fetched
+	CachePartial = "PARTIAL" // Some sources came from the cache
+)
+
+const weatherCacheMaxEntries = 10000
+
+// ttlCache keeps loaded values for a fixed time and coalesces concurrent loads of the same key into one.
+// Errors are not cached.
+type ttlCache[T any] struct {
+	ttl     time.Duration
+	mu      sync.Mutex
+	entries map[string]ttlCacheEntry[T]
+	group   singleflight.Group
+}
+
+type ttlCacheEntry[T any] struct {
+	value   T
+	expires time.Time
+}
+
+func newTTLCache[T any](ttl time.Duration) *ttlCache[T] {
+	return &ttlCache[T]{ttl: ttl, entries: map[string]ttlCacheEntry[T]{}}
+}
+
+// get returns the cached value of the key, or loads it. The bool reports a cache hit.
+func (c *ttlCache[T]) get(key string, load func() (T, error)) (T, bool, error) {
+	c.mu.Lock()
+	entry, ok := c.entries[key]
+	c.mu.Unlock()
+	if ok && time.Now().Before(entry.expires) {
+		return entry.value, true, nil
+	}
+
+	value, err, _ := c.group.Do(key, func() (interface{}, error) {
+		value, err := load()
+		if err == nil {
+			c.set(key, value)
+		}
+		return value, err
+	})
+	return value.(T), false, err
+}
+
+func (c *ttlCache[T]) set(key string, value T) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := time.Now()
+	if len(c.entries) >= weatherCacheMaxEntries {
+		for key, entry := range c.entries {
+			if !now.Before(entry.expires) {
+				delete(c.entries, key)
+			}
+		}
+	}
+	if len(c.entries) >= weatherCacheMaxEntries {
+		for key := range c.entries { // Still full of fresh entries, drop any one
+			delete(c.entries, key)
+			break
+		}
+	}
+	c.entries[key] = ttlCacheEntry[T]{value: value, expires: now.Add(c.ttl)}
+}
+
+// CombineCacheStatus summarizes the cache statuses of several responses, e.g. of all saved locations
+func CombineCacheStatus(statuses ...string) string {
+	var hits, misses int
+	for _, status := range statuses {
+		switch status {
+		case CacheHit:
+			hits++
+		case CacheMiss:
+			misses++
+		}
+	}
+	switch {
+	case len(statuses) > 0 && hits == len(statuses):
+		return CacheHit
+	case len(statuses) == misses:
+		return CacheMiss
+	default:
+		return CachePartial
+	}
+}
+
+// normalizeCity makes cache keys of differently typed names of the same city equal, e.g. " new  York"
+func normalizeCity(city string) string {
+	return strings.ToLower(strings.Join(strings.Fields(city), " "))
+}
+
+// cacheStatus summarizes the cache hits of the sources of a response
+func cacheStatus(hits, sources int) string {
+	switch {
+	case sources > 0 && hits == sources:
+		return CacheHit
+	case hits > 0:
+		return CachePartial
+	default:
+		return CacheMiss
+	}
+}
@@ -0,0 +1,390 @@
+package utils
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"net/url"
+	"organizer-backend/models"
+	"strconv"
+	"time"
+)
+
+// OpenWeatherMapProvider queries the current weather API of OpenWeatherMap (needs an API key)
+type OpenWeatherMapProvider struct {
+	BaseURL string // e.g. https://api.openweathermap.org/data/2.5
+	APIKey  string
+}
+
+func (p *OpenWeatherMapProvider) ID() string      { return "openweathermap" }
+func (p *OpenWeatherMapProvider) Name() string    { return "OpenWeatherMap" }
+func (p *OpenWeatherMapProvider) HasAPIKey() bool { return p.APIKey != "" }
+
+func (p *OpenWeatherMapProvider) Current(ctx context.Context, query WeatherQuery) (models.WeatherSource, error) {
+	params := p.params(query)
+	params.Add("lang", query.language())
+
+	var response models.OpenWeatherMapResponse
+	if err := getWeatherJSON(ctx, p.ID(), p.BaseURL, "/weather", params, &response); err != nil {
+		return models.WeatherSource{}, err
+	}
+
+	desc := "N/A"
+	if len(response.Weather) > 0 {
+		desc = response.Weather[0].Description
+	}
+	windUnit := "m/s"
+	if query.imperial() {
+		windUnit = "mph"
+	}
+	return models.WeatherSource{
+		Name:        p.Name(),
+		Temp:        response.Main.Temp,
+		Description: describeWeather(query, desc, response.Wind.Speed, windUnit),
+	}, nil
+}
+
+// params selects the place and the units; OpenWeatherMap names the units like WeatherQuery
+func (p *OpenWeatherMapProvider) params(query WeatherQuery) url.Values {
+	params := url.Values{}
+	if query.hasCoordinates() {
+		params.Add("lat", strconv.FormatFloat(*query.Latitude, 'f', -1, 64))
+		params.Add("lon", strconv.FormatFloat(*query.Longitude, 'f', -1, 64))
+	} else {
+		params.Add("q", query.City)
+	}
+	params.Add("appid", p.APIKey)
+	params.Add("units", query.units())
+	return params
+}
+
+func (p *OpenWeatherMapProvider) MaxForecastDays() int { return 5 }
+
+// Forecast uses the free 5 day / 3 hour forecast; daily values are derived from the 3-hour steps of each local date
+func (p *OpenWeatherMapProvider) Forecast(ctx context.Context, query WeatherQuery, days int, hourly bool) (models.SourceForecast, error) {
+	params := p.params(query)
+	params.Add("cnt", strconv.Itoa(min((days+1)*8, 40))) // 8 steps per day, today is partial; the API returns at most 40
+
+	var response models.OpenWeatherMapForecastResponse
+	if err := getWeatherJSON(ctx, p.ID(), p.BaseURL, "/forecast", params, &response); err != nil {
+		return models.SourceForecast{}, err
+	}
+
+	forecast := models.SourceForecast{Name: p.Name(), Daily: []models.DailyForecast{}}
+	for _, step := range response.List {
+		probability := step.Pop * 100
+		date := localDate(step.Dt, response.City.Timezone)
+		if last := len(forecast.Daily) - 1; last >= 0 && forecast.Daily[last].Date == date {
+			day := &forecast.Daily[last]
+			day.TempMin = math.Min(day.TempMin, step.Main.TempMin)
+			day.TempMax = math.Max(day.TempMax, step.Main.TempMax)
+			if probability > *day.PrecipitationProbability {
+				day.PrecipitationProbability = &probability
+			}
+		} else if len(forecast.Daily) < days {
+			forecast.Daily = append(forecast.Daily, models.DailyForecast{Date: date, TempMin: step.Main.TempMin, TempMax: step.Main.TempMax, PrecipitationProbability: &probability})
+		} else {
+			break // The steps reach into the day after the last requested one
+		}
+		if hourly {
+			forecast.Hourly = append(forecast.Hourly, models.HourlyForecast{Time: time.Unix(step.Dt, 0).UTC(), Temp: step.Main.Temp, PrecipitationProbability: &probability})
+		}
+	}
+	return forecast, nil
+}
+
+// WeatherAPIProvider queries WeatherAPI.com (needs an API key)
+type WeatherAPIProvider struct {
+	BaseURL string // e.g. https://api.weatherapi.com/v1
+	APIKey  string
+}
+
+func (p *WeatherAPIProvider) ID() string      { return "weatherapi" }
+func (p *WeatherAPIProvider) Name() string    { return "WeatherAPI.com" }
+func (p *WeatherAPIProvider) HasAPIKey() bool { return p.APIKey != "" }
+
+func (p *WeatherAPIProvider) Current(ctx context.Context, query WeatherQuery) (models.WeatherSource, error) {
+	params := p.params(query)
+	params.Add("lang", query.language())
+
+	var response models.WeatherAPIResponse
+	if err := getWeatherJSON(ctx, p.ID(), p.BaseURL, "/current.json", params, &response); err != nil {
+		return models.WeatherSource{}, err
+	}
+
+	// WeatherAPI.com answers in both units
+	source := models.WeatherSource{Name: p.Name(), Temp: response.Current.TempC}
+	source.Description = describeWeather(query, response.Current.Condition.Text, response.Current.WindKph, "km/h")
+	if query.imperial() {
+		source.Temp = response.Current.TempF
+		source.Description = describeWeather(query, response.Current.Condition.Text, response.Current.WindMph, "mph")
+	}
+	return source, nil
+}
+
+// params selects the place, given by name or as "latitude,longitude"
+func (p *WeatherAPIProvider) params(query WeatherQuery) url.Values {
+	params := url.Values{}
+	params.Add("key", p.APIKey)
+	if query.hasCoordinates() {
+		params.Add("q", strconv.FormatFloat(*query.Latitude, 'f', -1, 64)+","+strconv.FormatFloat(*query.Longitude, 'f', -1, 64))
+	} else {
+		params.Add("q", query.City)
+	}
+	return params
+}
+
+func (p *WeatherAPIProvider) MaxForecastDays() int { return 14 } // The free plan returns 3 days
+
+func (p *WeatherAPIProvider) Forecast(ctx context.Context, query WeatherQuery, days int, hourly bool) (models.SourceForecast, error) {
+	params := p.params(query)
+	params.Add("days", strconv.Itoa(days))
+	params.Add("aqi", "no")
+	params.Add("alerts", "no")
+
+	var response models.WeatherAPIForecastResponse
+	if err := getWeatherJSON(ctx, p.ID(), p.BaseURL, "/forecast.json", params, &response); err != nil {
+		return models.SourceForecast{}, err
+	}
+
+	forecast := models.SourceForecast{Name: p.Name(), Daily: []models.DailyForecast{}}
+	for _, day := range response.Forecast.ForecastDay {
+		probability := day.Day.DailyChanceOfRain
+		entry := models.DailyForecast{Date: day.Date, TempMin: day.Day.MinTempC, TempMax: day.Day.MaxTempC, PrecipitationProbability: &probability}
+		if query.imperial() {
+			entry.TempMin, entry.TempMax = day.Day.MinTempF, day.Day.MaxTempF
+		}
+		forecast.Daily = append(forecast.Daily, entry)
+		if !hourly {
+			continue
+		}
+		for _, hour := range day.Hour {
+			probability := hour.ChanceOfRain
+			entry := models.HourlyForecast{Time: time.Unix(hour.TimeEpoch, 0).UTC(), Temp: hour.TempC, PrecipitationProbability: &probability}
+			if query.imperial() {
+				entry.Temp = hour.TempF
+			}
+			forecast.Hourly = append(forecast.Hourly, entry)
+		}
+	}
+	return forecast, nil
+}
+
+// OpenMeteoProvider queries Open-Meteo, which needs no key but only knows coordinates, so cities are
+// looked up with its geocoding API first
+type OpenMeteoProvider struct {
+	BaseURL      string // e.g. https://api.open-meteo.com/v1
+	GeocodingURL string // e.g. https://geocoding-api.open-meteo.com/v1
+}
+
+func (p *OpenMeteoProvider) ID() string   { return "openmeteo" }
+func (p *OpenMeteoProvider) Name() string { return "Open-Meteo" }
+
+// Geocode returns the coordinates of the best match for the city name. Results are cached for a long time.
+func (p *OpenMeteoProvider) Geocode(ctx context.Context, city, language string) (models.OpenMeteoGeocodingResult, error) {
+	location, _, err := geocodingCache.get(p.GeocodingURL+"|"+normalizeCity(city), func() (models.OpenMeteoGeocodingResult, error) {
+		return p.geocode(ctx, city, language)
+	})
+	return location, err
+}
+
+func (p *OpenMeteoProvider) geocode(ctx context.Context, city, language string) (models.OpenMeteoGeocodingResult, error) {
+	params := url.Values{}
+	params.Add("name", city)
+	params.Add("count", "1")
+	params.Add("language", language)
+	params.Add("format", "json")
+
+	var response models.OpenMeteoGeocodingResponse
+	if err := getWeatherJSON(ctx, p.ID(), p.GeocodingURL, "/search", params, &response); err != nil {
+		return models.OpenMeteoGeocodingResult{}, err
+	}
+	if len(response.Results) == 0 {
+		return models.OpenMeteoGeocodingResult{}, &WeatherProviderError{Provider: p.ID(), Kind: WeatherErrorNotFound, Err: errors.New("no geocoding results for " + city)}
+	}
+	return response.Results[0], nil
+}
+
+// params selects the place, geocoding cities, and the units
+func (p *OpenMeteoProvider) params(ctx context.Context, query WeatherQuery) (url.Values, error) {
+	latitude, longitude := query.Latitude, query.Longitude
+	if !query.hasCoordinates() {
+		location, err := p.Geocode(ctx, query.City, query.language())
+		if err != nil {
+			return nil, err
+		}
+		latitude, longitude = &location.Latitude, &location.Longitude
+	}
+
+	params := url.Values{}
+	params.Add("latitude", fmt.Sprintf("%.2f", *latitude))
+	params.Add("longitude", fmt.Sprintf("%.2f", *longitude))
+	if query.imperial() {
+		params.Add("temperature_unit", "fahrenheit")
+		params.Add("wind_speed_unit", "mph")
+	}
+	return params, nil
+}
+
+func (p *OpenMeteoProvider) Current(ctx context.Context, query WeatherQuery) (models.WeatherSource, error) {
+	params, err := p.params(ctx, query)
+	if err != nil {
+		return models.WeatherSource{}, err
+	}
+	params.Add("current", "temperature_2m,weather_code,wind_speed_10m") // Запрашиваем нужные поля
+	params.Add("timezone", "auto")                                      // Автоматическое определение таймзоны
+
+	var response models.OpenMeteoWeatherResponse
+	if err := getWeatherJSON(ctx, p.ID(), p.BaseURL, "/forecast", params, &response); err != nil {
+		return models.WeatherSource{}, err
+	}
+
+	windUnit := "km/h"
+	if query.imperial() {
+		windUnit = "mph"
+	}
+	return models.WeatherSource{
+		Name:        p.Name(),
+		Temp:        response.CurrentWeather.Temperature,
+		Description: describeWeather(query, WMOCodeDescription(response.CurrentWeather.WeatherCode, query.language()), response.CurrentWeather.WindSpeed, windUnit),
+	}, nil
+}
+
+func (p *OpenMeteoProvider) MaxForecastDays() int { return 16 }
+
+func (p *OpenMeteoProvider) Forecast(ctx context.Context, query WeatherQuery, days int, hourly bool) (models.SourceForecast, error) {
+	params, err := p.params(ctx, query)
+	if err != nil {
+		return models.SourceForecast{}, err
+	}
+	params.Add("daily", "temperature_2m_max,temperature_2m_min,precipitation_probability_max")
+	if hourly {
+		params.Add("hourly", "temperature_2m,precipitation_probability")
+	}
+	params.Add("forecast_days", strconv.Itoa(days))
+	params.Add("timezone", "auto")
+	params.Add("timeformat", "unixtime")
+
+	var response models.OpenMeteoForecastResponse
+	if err := getWeatherJSON(ctx, p.ID(), p.BaseURL, "/forecast", params, &response); err != nil {
+		return models.SourceForecast{}, err
+	}
+
+	forecast := models.SourceForecast{Name: p.Name(), Daily: []models.DailyForecast{}}
+	daily := response.Daily
+	if len(daily.TemperatureMax) < len(daily.Time) || len(daily.TemperatureMin) < len(daily.Time) {
+		return forecast, &WeatherProviderError{Provider: p.ID(), Kind: WeatherErrorDecode, Err: errors.New("daily series have different lengths")}
+	}
+	for i, day := range daily.Time {
+		entry := models.DailyForecast{Date: localDate(day, response.UTCOffsetSeconds), TempMin: daily.TemperatureMin[i], TempMax: daily.TemperatureMax[i]}
+		if i < len(daily.PrecipitationProbabilityMax) {
+			entry.PrecipitationProbability = daily.PrecipitationProbabilityMax[i]
+		}
+		forecast.Daily = append(forecast.Daily, entry)
+	}
+	for i, hour := range response.Hourly.Time {
+		if i >= len(response.Hourly.Temperature) {
+			break
+		}
+		entry := models.HourlyForecast{Time: time.Unix(hour, 0).UTC(), Temp: response.Hourly.Temperature[i]}
+		if i < len(response.Hourly.PrecipitationProbability) {
+			entry.PrecipitationProbability = response.Hourly.PrecipitationProbability[i]
+		}
+		forecast.Hourly = append(forecast.Hourly, entry)
+	}
+	return forecast, nil
+}
+
+// localDate formats the Unix time as a date in the time zone with the offset (seconds east of UTC)
+func localDate(unix, utcOffset int64) string {
+	return time.Unix(unix+utcOffset, 0).UTC().Format("2006-01-02")
+}
+
+// describeWeather joins the conditions and the wind speed in the language of the query
+func describeWeather(query WeatherQuery, conditions string, windSpeed float64, windUnit string) string {
+	if query.language() == models.WeatherLanguageEnglish {
+		return fmt.Sprintf("%s, wind %.1f %s", conditions, windSpeed, windUnit)
+	}
+	return fmt.Sprintf("%s, ветер %.1f %s", conditions, windSpeed, windUnit)
+}
+
+// WMOCodeDescription - упрощенная функция для маппинга WMO кодов погоды Open-Meteo
+func WMOCodeDescription(code int, language string) string {
+	if language == models.WeatherLanguageEnglish {
+		return wmoCodeDescriptionEnglish(code)
+	}
+	// Источник: https://open-meteo.com/en/docs WMO Weather interpretation codes (WW)
+	switch code {
+	case 0:
+		return "Ясно"
+	case 1:
+		return "В основном ясно"
+	case 2:
+		return "Переменная облачность"
+	case 3:
+		return "Пасмурно"
+	case 45, 48:
+		return "Туман" // и изморозь (иней)
+	case 51, 53, 55:
+		return "Морось" // легкая, умеренная, сильная
+	case 56, 57:
+		return "Ледяная морось" // легкая, сильная
+	case 61, 63, 65:
+		return "Дождь" // слабый, умеренный, сильный
+	case 66, 67:
+		return "Ледяной дождь" // слабый, сильный
+	case 71, 73, 75:
+		return "Снег" // слабый, умеренный, сильный
+	case 77:
+		return "Снежные зерна"
+	case 80, 81, 82:
+		return "Ливень" // слабый, умеренный, сильный
+	case 85, 86:
+		return "Снежный ливень" // слабый, сильный
+	case 95:
+		return "Гроза" // слабая или умеренная
+	case 96, 99:
+		return "Гроза с градом" // слабая, сильная
+	default:
+		return fmt.Sprintf("Код погоды: %d", code)
+	}
+}
+
+// wmoCodeDescriptionEnglish is WMOCodeDescription for English
+func wmoCodeDescriptionEnglish(code int) string {
+	switch code {
+	case 0:
+		return "Clear sky"
+	case 1:
+		return "Mainly clear"
+	case 2:
+		return "Partly cloudy"
+	case 3:
+		return "Overcast"
+	case 45, 48:
+		return "Fog"
+	case 51, 53, 55:
+		return "Drizzle"
+	case 56, 57:
+		return "Freezing drizzle"
+	case 61, 63, 65:
+		return "Rain"
+	case 66, 67:
+		return "Freezing rain"
+	case 71, 73, 75:
+		return "Snow"
+	case 77:
+		return "Snow grains"
+	case 80, 81, 82:
+		return "Rain showers"
+	case 85, 86:
+		return "Snow showers"
+	case 95:
+		return "Thunderstorm"
+	case 96, 99:
+		return "Thunderstorm with hail"
+	default:
+		return fmt.Sprintf("Weather code: %d", code)
+	}
+}
@@ -0,0 +1,61 @@
+package utils
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// WikiLink is a [[Note Title]] or [[id:123]] reference found in note content
+type WikiLink struct {
+	Target string // Reference as written, without alias: "Note Title" or "id:123"
+	Title  string // Referenced title, empty for id references
+	NoteID uint   // Referenced note id, 0 for title references
+}
+
+// [[target]] or [[target|alias]]
+var wikiLinkPattern = regexp.MustCompile(`\[\[([^\[\]|\n]+)(\|[^\[\]\n]*)?\]\]`)
+
+// ParseWikiLinks returns the distinct wiki links of content in order of appearance
+func ParseWikiLinks(content string) []WikiLink {
+	var links []WikiLink
+	seen := make(map[string]bool)
+	for _, match := range wikiLinkPattern.FindAllStringSubmatch(content, -1) {
+		target := strings.TrimSpace(match[1])
+		if target == "" || seen[strings.ToLower(target)] {
+			continue
+		}
+		seen[strings.ToLower(target)] = true
+
+		link := WikiLink{Target: target, Title: target}
+		if idPart, ok := strings.CutPrefix(target, "id:"); ok {
+			if id, err := strconv.ParseUint(strings.TrimSpace(idPart), 10, 64); err == nil && id > 0 {
+				link = WikiLink{Target: target, NoteID: uint(id)}
+			}
+		}
+		links = append(links, link)
+	}
+	return links
+}
+
+// IsWikiLinkTitle reports whether [[title]] is a title reference to the title: brackets, "|" and line
+// breaks end the reference, and an "id:" prefix makes it an id reference
+func IsWikiLinkTitle(title string) bool {
+	title = strings.TrimSpace(title)
+	return title != "" && !strings.ContainsAny(title, "[]|\n") && !strings.HasPrefix(title, "id:")
+}
+
+// RewriteWikiLinks replaces [[oldTitle]] references (case-insensitive) with newTitle, keeping aliases.
+// Content is left unchanged when newTitle cannot be written as a reference (see IsWikiLinkTitle).
+func RewriteWikiLinks(content, oldTitle, newTitle string) string {
+	if !IsWikiLinkTitle(newTitle) {
+		return content
+	}
+	return wikiLinkPattern.ReplaceAllStringFunc(content, func(link string) string {
+		match := wikiLinkPattern.FindStringSubmatch(link)
+		if !strings.EqualFold(strings.TrimSpace(match[1]), strings.TrimSpace(oldTitle)) {
+			return link
+		}
+		return "[[" + newTitle + match[2] + "]]"
+	})
+}
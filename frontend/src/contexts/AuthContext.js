@@ -21,6 +21,7 @@ export const AuthProvider = ({ children }) => {
         })
         .catch(() => { // Token invalid or expired
           localStorage.removeItem('authToken');
+          localStorage.removeItem('refreshToken');
           setUser(null);
           setToken(null);
           setIsAuthenticated(false);
@@ -33,8 +34,9 @@ export const AuthProvider = ({ children }) => {
 
   const login = async (credentials) => {
     try {
-      const { token: newToken, user: userData } = await loginUser(credentials);
+      const { token: newToken, refreshToken, user: userData } = await loginUser(credentials);
       localStorage.setItem('authToken', newToken);
+      localStorage.setItem('refreshToken', refreshToken);
       setToken(newToken);
       setUser(userData);
       setIsAuthenticated(true);
@@ -47,8 +49,9 @@ export const AuthProvider = ({ children }) => {
 
   const register = async (userData) => {
     try {
-      const { token: newToken, user: newUser } = await registerUser(userData);
+      const { token: newToken, refreshToken, user: newUser } = await registerUser(userData);
       localStorage.setItem('authToken', newToken);
+      localStorage.setItem('refreshToken', refreshToken);
       setToken(newToken);
       setUser(newUser);
       setIsAuthenticated(true); // Or redirect to login
@@ -61,6 +64,7 @@ export const AuthProvider = ({ children }) => {
 
   const logout = () => {
     localStorage.removeItem('authToken');
+    localStorage.removeItem('refreshToken');
     setUser(null);
     setToken(null);
     setIsAuthenticated(false);
@@ -23,12 +23,50 @@ apiClient.interceptors.request.use(
   }
 );
 
+// Access tokens are short-lived: on 401 the refresh token is exchanged for a new pair and the request is
+// retried once. Refresh tokens are single use, so concurrent 401s share one refresh.
+let refreshPromise = null;
+
+const refreshTokens = () => {
+  if (!refreshPromise) {
+    const refreshToken = localStorage.getItem('refreshToken');
+    refreshPromise = axios
+      .post(`${API_BASE_URL}/auth/refresh`, { refreshToken })
+      .then(response => {
+        localStorage.setItem('authToken', response.data.token);
+        localStorage.setItem('refreshToken', response.data.refreshToken);
+        return response.data.token;
+      })
+      .catch(error => {
+        localStorage.removeItem('authToken');
+        localStorage.removeItem('refreshToken');
+        throw error;
+      })
+      .finally(() => {
+        refreshPromise = null;
+      });
+  }
+  return refreshPromise;
+};
+
 apiClient.interceptors.response.use(
   response => response,
-  error => {
-    if (error.response && error.response.status === 401) {
-      // localStorage.removeItem('authToken');
-      // window.location.href = '/auth'; 
+  async error => {
+    const request = error.config;
+    if (
+      error.response && error.response.status === 401 &&
+      request && !request._retried &&
+      !/^\/auth\/(login|register|refresh)/.test(request.url) &&
+      localStorage.getItem('refreshToken')
+    ) {
+      request._retried = true;
+      try {
+        const token = await refreshTokens();
+        request.headers.Authorization = `Bearer ${token}`;
+        return apiClient(request);
+      } catch (refreshError) {
+        return Promise.reject(error);
+      }
     }
     return Promise.reject(error);
   }